@@ -0,0 +1,318 @@
+package main
+
+import (
+	"discovery-service/proto"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// errDiverged makes compare exit with 1 after printing the comparison.
+var errDiverged = errors.New("nodes disagree")
+
+// nodeState is one node's status as reported by compare and counters.
+type nodeState struct {
+	Node        string   `json:"node"`
+	Error       string   `json:"error,omitempty"`
+	Counter     int64    `json:"counter"`
+	Partitioned bool     `json:"partitioned"`
+	Members     []string `json:"members,omitempty"`
+	Diverged    bool     `json:"diverged,omitempty"`
+}
+
+func (c *cli) members(args []string) error {
+	ctx, cancel := c.context()
+	defer cancel()
+	status, err := c.discovery.GetStatus(ctx, &proto.Empty{})
+	if err != nil {
+		return err
+	}
+
+	type member struct {
+		ID          string  `json:"id"`
+		State       string  `json:"state"`
+		HeartbeatMs float64 `json:"heartbeat_ms,omitempty"`
+		MissedOps   int64   `json:"missed_ops,omitempty"`
+	}
+	result := []member{{ID: status.Id, State: "self"}}
+	for state, peers := range map[string][]string{"live": status.LivePeers, "suspect": status.SuspectPeers, "dead": status.DeadPeers} {
+		for _, p := range peers {
+			result = append(result, member{ID: p, State: state, HeartbeatMs: status.HeartbeatMs[p], MissedOps: status.MissedOps[p]})
+		}
+	}
+	sort.Slice(result[1:], func(i, j int) bool { return result[i+1].ID < result[j+1].ID })
+
+	if c.output == "json" {
+		return printJSON(map[string]interface{}{"node": status.Id, "partitioned": status.Partitioned, "members": result})
+	}
+	rows := [][]string{{"MEMBER", "STATE", "HEARTBEAT", "MISSED OPS"}}
+	for _, m := range result {
+		heartbeat := ""
+		if m.HeartbeatMs > 0 {
+			heartbeat = strconv.FormatFloat(m.HeartbeatMs, 'f', 2, 64) + "ms"
+		}
+		rows = append(rows, []string{m.ID, m.State, heartbeat, strconv.FormatInt(m.MissedOps, 10)})
+	}
+	printTable(rows)
+	if status.Partitioned {
+		fmt.Println("\nThe node cannot reach the majority of the cluster.")
+	}
+	return nil
+}
+
+func (c *cli) counters(args []string) error {
+	states, err := c.cluster()
+	if err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return printJSON(map[string]interface{}{"nodes": states})
+	}
+	rows := [][]string{{"NODE", "COUNTER", "PARTITIONED"}}
+	for _, st := range states {
+		if st.Error != "" {
+			rows = append(rows, []string{st.Node, "error: " + st.Error, ""})
+			continue
+		}
+		rows = append(rows, []string{st.Node, strconv.FormatInt(st.Counter, 10), strconv.FormatBool(st.Partitioned)})
+	}
+	printTable(rows)
+	return nil
+}
+
+// compare reports nodes that are unreachable or whose counter or membership
+// differs from what most nodes agree on.
+func (c *cli) compare(args []string) error {
+	states, err := c.cluster()
+	if err != nil {
+		return err
+	}
+
+	counters := map[string]int{}
+	views := map[string]int{}
+	for _, st := range states {
+		if st.Error == "" {
+			counters[strconv.FormatInt(st.Counter, 10)]++
+			views[strings.Join(st.Members, ",")]++
+		}
+	}
+	agreedCounter, agreedView := mostCommon(counters), mostCommon(views)
+	diverged := false
+	for i := range states {
+		st := &states[i]
+		st.Diverged = st.Error != "" || strconv.FormatInt(st.Counter, 10) != agreedCounter || strings.Join(st.Members, ",") != agreedView
+		diverged = diverged || st.Diverged
+	}
+
+	if c.output == "json" {
+		if err := printJSON(map[string]interface{}{"nodes": states, "agreed": !diverged}); err != nil {
+			return err
+		}
+	} else {
+		rows := [][]string{{"NODE", "COUNTER", "MEMBERS", "STATUS"}}
+		for _, st := range states {
+			switch {
+			case st.Error != "":
+				rows = append(rows, []string{st.Node, "", "", "unreachable: " + st.Error})
+			case st.Diverged:
+				rows = append(rows, []string{st.Node, strconv.FormatInt(st.Counter, 10), strings.Join(st.Members, ","), "DIVERGED"})
+			default:
+				rows = append(rows, []string{st.Node, strconv.FormatInt(st.Counter, 10), strings.Join(st.Members, ","), "ok"})
+			}
+		}
+		printTable(rows)
+	}
+	if diverged {
+		return errDiverged
+	}
+	return nil
+}
+
+func (c *cli) increment(args []string, sign int64) error {
+	fs := flag.NewFlagSet("increment", flag.ContinueOnError)
+	counter := fs.String("counter", "", "named counter, the default counter if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	delta := int64(1)
+	if fs.NArg() > 0 {
+		var err error
+		if delta, err = strconv.ParseInt(fs.Arg(0), 10, 64); err != nil || delta <= 0 {
+			return fmt.Errorf("invalid amount %q", fs.Arg(0))
+		}
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+	resp, err := c.admin.Increment(ctx, &proto.AdminIncrementRequest{Counter: *counter, Delta: sign * delta})
+	if err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return printJSON(map[string]interface{}{"counter": *counter, "count": resp.Count})
+	}
+	fmt.Println(resp.Count)
+	return nil
+}
+
+func (c *cli) sync(args []string) error {
+	peer, err := peerArg("sync", args)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+	resp, err := c.admin.SyncFrom(ctx, &proto.PeerRequest{Peer: peer})
+	if err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return printJSON(map[string]interface{}{"peer": peer, "counter": resp.Counter})
+	}
+	fmt.Printf("Synced from %s, counter is %d\n", peer, resp.Counter)
+	return nil
+}
+
+func (c *cli) resend(args []string) error {
+	peer, err := peerArg("resend", args)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+	resp, err := c.admin.Resend(ctx, &proto.PeerRequest{Peer: peer})
+	if err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return printJSON(map[string]interface{}{"peer": peer, "sent": resp.Sent, "remaining": resp.Remaining})
+	}
+	fmt.Printf("Resent %d ops to %s, %d remaining\n", resp.Sent, peer, resp.Remaining)
+	return nil
+}
+
+func (c *cli) remove(args []string) error {
+	peer, err := peerArg("remove", args)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+	if _, err := c.admin.RemovePeer(ctx, &proto.PeerRequest{Peer: peer}); err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return printJSON(map[string]interface{}{"removed": peer})
+	}
+	fmt.Printf("Removed %s from %s\n", peer, c.node)
+	return nil
+}
+
+func (c *cli) queues(args []string) error {
+	ctx, cancel := c.context()
+	defer cancel()
+	queues, err := c.admin.GetQueues(ctx, &proto.Empty{})
+	if err != nil {
+		return err
+	}
+
+	missed := map[string][]string{}
+	for p, ops := range queues.MissedOps {
+		missed[p] = ops.Ids
+	}
+	if c.output == "json" {
+		return printJSON(map[string]interface{}{"missed_ops": missed, "seen_ops": queues.SeenOps})
+	}
+	peers := make([]string, 0, len(missed))
+	for p := range missed {
+		peers = append(peers, p)
+	}
+	sort.Strings(peers)
+	rows := [][]string{{"PEER", "MISSED OPS", "IDS"}}
+	for _, p := range peers {
+		rows = append(rows, []string{p, strconv.Itoa(len(missed[p])), strings.Join(missed[p], ",")})
+	}
+	printTable(rows)
+	fmt.Printf("\n%d ops seen\n", queues.SeenOps)
+	return nil
+}
+
+// cluster asks the node and every peer it knows about, live or dead, for
+// their status.
+func (c *cli) cluster() ([]nodeState, error) {
+	ctx, cancel := c.context()
+	status, err := c.discovery.GetStatus(ctx, &proto.Empty{})
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	states := []nodeState{toState(status)}
+	peers := slices.Concat(status.LivePeers, status.SuspectPeers, status.DeadPeers)
+	sort.Strings(peers)
+	for _, p := range peers {
+		states = append(states, c.peerState(p))
+	}
+	return states, nil
+}
+
+func (c *cli) peerState(peer string) nodeState {
+	conn, err := c.dial(peer)
+	if err != nil {
+		return nodeState{Node: peer, Error: err.Error()}
+	}
+	defer conn.Close()
+	ctx, cancel := c.context()
+	defer cancel()
+	status, err := proto.NewDiscoveryClient(conn).GetStatus(ctx, &proto.Empty{})
+	if err != nil {
+		return nodeState{Node: peer, Error: err.Error()}
+	}
+	return toState(status)
+}
+
+// toState turns a status into a nodeState whose members include the node
+// itself, so the views of different nodes can be compared.
+func toState(status *proto.NodeStatus) nodeState {
+	members := slices.Concat([]string{status.Id}, status.LivePeers, status.SuspectPeers)
+	sort.Strings(members)
+	return nodeState{Node: status.Id, Counter: status.Counter, Partitioned: status.Partitioned, Members: members}
+}
+
+func mostCommon(counts map[string]int) string {
+	best := ""
+	for value, n := range counts {
+		if n > counts[best] || (n == counts[best] && value > best) {
+			best = value
+		}
+	}
+	return best
+}
+
+func peerArg(cmd string, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("usage: counterctl %s <peer>", cmd)
+	}
+	return args[0], nil
+}
+
+func printTable(rows [][]string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,126 @@
+// Command counterctl operates a discovery-service cluster over gRPC.
+//
+//	counterctl [flags] <command> [args]
+//
+// Commands:
+//
+//	members                     list the members known to the node and their state
+//	counters                    show the default counter of every node
+//	compare                     compare counters and membership across nodes, exit 1 if they differ
+//	increment [-counter name] [n]
+//	decrement [-counter name] [n] (strong counters only)
+//	sync <peer>                 raise the node's counter to the one of peer
+//	resend <peer>               resend the ops peer missed now
+//	remove <peer>               force-remove a dead peer from the node's membership
+//	queues                      dump the ops waiting to be resent
+package main
+
+import (
+	"context"
+	"discovery-service/proto"
+	"discovery-service/security/mtls"
+	"flag"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"os"
+	"time"
+)
+
+// cli holds the global flags and the connection to the node commands act on.
+type cli struct {
+	node    string
+	apiKey  string
+	output  string
+	timeout time.Duration
+	creds   credentials.TransportCredentials
+
+	discovery proto.DiscoveryClient
+	admin     proto.AdminClient
+}
+
+func main() {
+	c := &cli{}
+	flag.StringVar(&c.node, "node", "localhost:8080", "gRPC address of the node to talk to")
+	flag.StringVar(&c.apiKey, "api-key", os.Getenv("COUNTERCTL_API_KEY"), "API key sent to the node (default $COUNTERCTL_API_KEY)")
+	flag.StringVar(&c.output, "o", "table", "output format, table or json")
+	flag.DurationVar(&c.timeout, "timeout", 5*time.Second, "timeout of each call")
+	tlsCA := flag.String("tls-ca", "", "CA bundle used to verify the nodes")
+	tlsCert := flag.String("tls-cert", "", "client certificate for clusters using mutual TLS")
+	tlsKey := flag.String("tls-key", "", "client private key")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	if c.output != "table" && c.output != "json" {
+		fail(fmt.Errorf("unknown output format %q, expected table or json", c.output))
+	}
+
+	c.creds = insecure.NewCredentials()
+	if *tlsCA != "" || *tlsCert != "" || *tlsKey != "" {
+		reloader, err := mtls.Load(*tlsCA, *tlsCert, *tlsKey)
+		if err != nil {
+			fail(err)
+		}
+		c.creds = reloader.ClientCredentials()
+	}
+	conn, err := c.dial(c.node)
+	if err != nil {
+		fail(err)
+	}
+	defer conn.Close()
+	c.discovery = proto.NewDiscoveryClient(conn)
+	c.admin = proto.NewAdminClient(conn)
+
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd(c, flag.Args()[1:]); err != nil {
+		fail(err)
+	}
+}
+
+var commands = map[string]func(c *cli, args []string) error{
+	"members":   (*cli).members,
+	"counters":  (*cli).counters,
+	"compare":   (*cli).compare,
+	"increment": func(c *cli, args []string) error { return c.increment(args, 1) },
+	"decrement": func(c *cli, args []string) error { return c.increment(args, -1) },
+	"sync":      (*cli).sync,
+	"resend":    (*cli).resend,
+	"remove":    (*cli).remove,
+	"queues":    (*cli).queues,
+}
+
+func (c *cli) dial(addr string) (*grpc.ClientConn, error) {
+	return grpc.NewClient(addr, grpc.WithTransportCredentials(c.creds))
+}
+
+// context returns the context of one call, carrying the API key.
+func (c *cli) context() (context.Context, context.CancelFunc) {
+	ctx := context.Background()
+	if c.apiKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.apiKey)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: counterctl [flags] <command> [args]")
+	fmt.Fprintln(os.Stderr, "\nCommands: members, counters, compare, increment, decrement, sync, resend, remove, queues")
+	fmt.Fprintln(os.Stderr, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "counterctl:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,308 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is the configuration of a node. It is built from the defaults, a
+// YAML or TOML file, DISCOVERY_* environment variables and flags, each
+// overriding the ones before. Settings tagged reload:"live" can be changed
+// without restarting the node.
+type Config struct {
+	Node      Node      `yaml:"node"`
+	Heartbeat Heartbeat `yaml:"heartbeat"`
+	RPC       RPC       `yaml:"rpc"`
+	HTTP      HTTP      `yaml:"http"`
+	TLS       TLS       `yaml:"tls"`
+	Counters  Counters  `yaml:"counters"`
+	Watch     Watch     `yaml:"watch"`
+	Health    Health    `yaml:"health"`
+	Tracing   Tracing   `yaml:"tracing"`
+	Log       Log       `yaml:"log"`
+	Audit     Audit     `yaml:"audit"`
+}
+
+type Node struct {
+	Port             string        `yaml:"port"`
+	ClientPort       string        `yaml:"client_port"` // Port of the CounterService, the peer port if empty
+	Peers            []string      `yaml:"peers" reload:"live"`
+	Cluster          string        `yaml:"cluster"`
+	JoinToken        string        `yaml:"join_token"`
+	JoinProofTTL     time.Duration `yaml:"join_proof_ttl" reload:"live"` // How long join challenges and call proofs are accepted
+	MinorityReadOnly bool          `yaml:"minority_read_only"`
+}
+
+type Heartbeat struct {
+	Period      time.Duration `yaml:"period" reload:"live"`
+	Timeout     time.Duration `yaml:"timeout" reload:"live"`
+	MaxRetries  int           `yaml:"max_retries" reload:"live"`  // Failed heartbeats before a peer is dead
+	BaseBackoff time.Duration `yaml:"base_backoff" reload:"live"` // Doubled after every failed heartbeat
+}
+
+// RPC configures the gRPC server and the calls nodes make to each other.
+type RPC struct {
+	Timeout         time.Duration `yaml:"timeout" reload:"live"`
+	MaxMessageBytes int           `yaml:"max_message_bytes"`
+	Rate            float64       `yaml:"rate" reload:"live"` // Calls per second per peer IP, 0 is unlimited
+	Burst           int           `yaml:"burst" reload:"live"`
+	DialRetries     int           `yaml:"dial_retries" reload:"live"` // Attempts to create a connection to a peer
+	DialBackoff     time.Duration `yaml:"dial_backoff" reload:"live"` // Wait after the first failed attempt, longer after every other
+	ClientRate      float64       `yaml:"client_rate" reload:"live"`  // Calls per second per IP on the client port, 0 is unlimited
+	ClientBurst     int           `yaml:"client_burst" reload:"live"`
+	RateIdle        time.Duration `yaml:"rate_idle"` // How long the bucket of a quiet client is kept, for the HTTP limit as well
+}
+
+type HTTP struct {
+	PortOffset      int           `yaml:"port_offset"`        // The HTTP port is the gRPC port plus this
+	APIKeys         string        `yaml:"api_keys"`           // JSON file of API keys, the API is open without it
+	Rate            float64       `yaml:"rate" reload:"live"` // Requests per second per API key or IP, 0 is unlimited
+	Burst           int           `yaml:"burst" reload:"live"`
+	MaxRequestBytes int64         `yaml:"max_request_bytes"`
+	StatusTimeout   time.Duration `yaml:"status_timeout" reload:"live"` // Of each node asked for /cluster/status
+}
+
+type TLS struct {
+	CA             string        `yaml:"ca"`
+	Cert           string        `yaml:"cert"`
+	Key            string        `yaml:"key"`
+	ReloadInterval time.Duration `yaml:"reload_interval" reload:"live"` // How often the files are checked for changes
+}
+
+type Counters struct {
+	Strong        []string      `yaml:"strong"`         // Counters replicated through raft
+	RaftBootstrap bool          `yaml:"raft_bootstrap"` // Set on one node, once, to create the raft cluster
+	RaftHeartbeat time.Duration `yaml:"raft_heartbeat"` // Between the leader's appends
+	RaftElection  time.Duration `yaml:"raft_election"`  // Least time without a leader before an election, up to twice that
+	RaftPropose   time.Duration `yaml:"raft_propose"`   // Longest wait for a write or read to be committed
+	RaftDir       string        `yaml:"raft_dir"`       // Where raft keeps its term, vote and log across restarts
+	RaftSnapshot  int           `yaml:"raft_snapshot"`  // Log entries after which the log is compacted into a snapshot
+	Bounded       []string      `yaml:"bounded"`        // name:limit
+	BoundedSeed   bool          `yaml:"bounded_seed"`   // Set on one node, once, to hold the initial allowance
+	HLLPrecision  int           `yaml:"hll_precision"`
+	RateWindow    time.Duration `yaml:"rate_window"`
+	FlushInterval time.Duration `yaml:"flush_interval" reload:"live"` // How often windowed and distinct counters are pushed to peers
+}
+
+type Watch struct {
+	MaxRate      float64       `yaml:"max_rate" reload:"live"`
+	PollInterval time.Duration `yaml:"poll_interval" reload:"live"`
+	TokenTTL     time.Duration `yaml:"token_ttl" reload:"live"` // Of the tokens browsers watch with instead of API keys
+}
+
+type Health struct {
+	CheckInterval time.Duration `yaml:"check_interval" reload:"live"` // How often gRPC health follows readiness
+}
+
+type Tracing struct {
+	Exporter     string `yaml:"exporter"`
+	OTLPEndpoint string `yaml:"otlp_endpoint"`
+	OTLPInsecure bool   `yaml:"otlp_insecure"`
+}
+
+type Log struct {
+	Level  string `yaml:"level" reload:"live"`
+	Format string `yaml:"format"`
+}
+
+type Audit struct {
+	File string `yaml:"file"`
+}
+
+// Default returns the configuration of a node nothing was configured for.
+func Default() *Config {
+	return &Config{
+		Node: Node{
+			Port:         "8080",
+			Cluster:      "default",
+			JoinProofTTL: 30 * time.Second,
+		},
+		Heartbeat: Heartbeat{
+			Period:      5 * time.Second,
+			Timeout:     2 * time.Second,
+			MaxRetries:  5,
+			BaseBackoff: time.Second,
+		},
+		RPC: RPC{
+			Timeout:         2 * time.Second,
+			MaxMessageBytes: 4 << 20,
+			Burst:           500,
+			DialRetries:     5,
+			DialBackoff:     time.Second,
+			ClientRate:      100,
+			ClientBurst:     200,
+			RateIdle:        10 * time.Minute,
+		},
+		HTTP: HTTP{
+			PortOffset:      1000,
+			Rate:            100,
+			Burst:           200,
+			MaxRequestBytes: 1 << 20,
+			StatusTimeout:   time.Second,
+		},
+		TLS: TLS{ReloadInterval: 10 * time.Second},
+		Counters: Counters{
+			RaftHeartbeat: 200 * time.Millisecond,
+			RaftElection:  time.Second,
+			RaftPropose:   5 * time.Second,
+			RaftDir:       "data",
+			RaftSnapshot:  1000,
+			HLLPrecision:  14,
+			RateWindow:    5 * time.Minute,
+			FlushInterval: time.Second,
+		},
+		Watch: Watch{
+			MaxRate:      10,
+			PollInterval: 50 * time.Millisecond,
+			TokenTTL:     time.Minute,
+		},
+		Health:  Health{CheckInterval: time.Second},
+		Tracing: Tracing{Exporter: "none"},
+		Log:     Log{Level: "info", Format: "text"},
+	}
+}
+
+// Validate reports every invalid setting of c.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	port, err := parsePort(c.Node.Port)
+	check(err == nil, "node.port: %v", err)
+	if c.Node.ClientPort != "" {
+		_, err := parsePort(c.Node.ClientPort)
+		check(err == nil, "node.client_port: %v", err)
+	}
+	check(c.Node.Cluster != "", "node.cluster must not be empty")
+	check(c.Node.JoinProofTTL > 0, "node.join_proof_ttl must be positive")
+	check(port+c.HTTP.PortOffset > 0 && port+c.HTTP.PortOffset <= 65535 && c.HTTP.PortOffset != 0,
+		"http.port_offset %d does not give a valid HTTP port", c.HTTP.PortOffset)
+
+	check(c.Heartbeat.Period > 0, "heartbeat.period must be positive")
+	check(c.Heartbeat.Timeout > 0, "heartbeat.timeout must be positive")
+	check(c.Heartbeat.MaxRetries > 0, "heartbeat.max_retries must be at least 1")
+	check(c.Heartbeat.BaseBackoff >= 0, "heartbeat.base_backoff must not be negative")
+
+	check(c.RPC.Timeout > 0, "rpc.timeout must be positive")
+	check(c.RPC.MaxMessageBytes > 0, "rpc.max_message_bytes must be positive")
+	check(c.RPC.Rate >= 0, "rpc.rate must not be negative")
+	check(c.RPC.Rate == 0 || c.RPC.Burst > 0, "rpc.burst must be positive")
+	check(c.RPC.DialRetries > 0, "rpc.dial_retries must be at least 1")
+	check(c.RPC.DialBackoff >= 0, "rpc.dial_backoff must not be negative")
+	check(c.RPC.ClientRate >= 0, "rpc.client_rate must not be negative")
+	check(c.RPC.ClientRate == 0 || c.RPC.ClientBurst > 0, "rpc.client_burst must be positive")
+	// A bucket must not be dropped before it refilled, or clients get a
+	// full burst early.
+	for _, l := range []struct {
+		name  string
+		rate  float64
+		burst int
+	}{{"rpc", c.RPC.Rate, c.RPC.Burst}, {"rpc.client", c.RPC.ClientRate, c.RPC.ClientBurst}, {"http", c.HTTP.Rate, c.HTTP.Burst}} {
+		if l.rate > 0 {
+			refill := time.Duration(float64(l.burst) / l.rate * float64(time.Second))
+			check(c.RPC.RateIdle >= refill, "rpc.rate_idle must be at least %s, the time the %s burst takes to refill", refill, l.name)
+		}
+	}
+
+	check(c.HTTP.Rate >= 0, "http.rate must not be negative")
+	check(c.HTTP.Rate == 0 || c.HTTP.Burst > 0, "http.burst must be positive")
+	check(c.HTTP.MaxRequestBytes >= 0, "http.max_request_bytes must not be negative")
+	check(c.HTTP.StatusTimeout > 0, "http.status_timeout must be positive")
+
+	tls := 0
+	for _, file := range []string{c.TLS.CA, c.TLS.Cert, c.TLS.Key} {
+		if file != "" {
+			tls++
+		}
+	}
+	check(tls == 0 || tls == 3, "tls.ca, tls.cert and tls.key must be set together")
+	check(c.TLS.ReloadInterval > 0, "tls.reload_interval must be positive")
+
+	check(c.Counters.RaftHeartbeat > 0, "counters.raft_heartbeat must be positive")
+	check(c.Counters.RaftElection > 2*c.Counters.RaftHeartbeat, "counters.raft_election must be more than twice counters.raft_heartbeat")
+	check(c.Counters.RaftPropose > 0, "counters.raft_propose must be positive")
+	check(c.Counters.RaftDir != "", "counters.raft_dir must be set")
+	check(c.Counters.RaftSnapshot > 0, "counters.raft_snapshot must be positive")
+	check(c.Counters.HLLPrecision >= 4 && c.Counters.HLLPrecision <= 16, "counters.hll_precision must be between 4 and 16")
+	check(c.Counters.RateWindow > 0, "counters.rate_window must be positive")
+	check(c.Counters.FlushInterval > 0, "counters.flush_interval must be positive")
+	for _, b := range c.Counters.Bounded {
+		name, limit, ok := strings.Cut(b, ":")
+		_, err := strconv.ParseInt(limit, 10, 64)
+		check(ok && name != "" && err == nil, "counters.bounded: %q is not name:limit", b)
+	}
+
+	check(c.Watch.MaxRate > 0, "watch.max_rate must be positive")
+	check(c.Watch.PollInterval > 0, "watch.poll_interval must be positive")
+	check(c.Watch.TokenTTL > 0, "watch.token_ttl must be positive")
+	check(c.Health.CheckInterval > 0, "health.check_interval must be positive")
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter must be none, stdout or otlp, not %q", c.Tracing.Exporter))
+	}
+	switch c.Log.Format {
+	case "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("log.format must be text or json, not %q", c.Log.Format))
+	}
+	return errors.Join(errs...)
+}
+
+// HTTPPort returns the address the HTTP API of the node listening for gRPC
+// on port serves on.
+func (c *Config) HTTPPort(port string) (string, error) {
+	p, err := parsePort(strings.TrimPrefix(port, ":"))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(":%d", p+c.HTTP.PortOffset), nil
+}
+
+// Changes are the settings a reload changed, as section.key names.
+type Changes struct {
+	Applied         []string `json:"applied"`          // In effect already
+	RestartRequired []string `json:"restart_required"` // Only in effect after a restart
+}
+
+// Merge returns next with the settings that cannot change live kept at the
+// values of c, and which settings differ between the two.
+func (c *Config) Merge(next *Config) (*Config, Changes) {
+	merged := *next
+	changes := Changes{Applied: []string{}, RestartRequired: []string{}}
+	current := map[string]reflect.Value{}
+	c.walk(func(section string, key string, v reflect.Value, _ reflect.StructField) {
+		current[section+"."+key] = v
+	})
+	merged.walk(func(section string, key string, v reflect.Value, field reflect.StructField) {
+		name := section + "." + key
+		old := current[name]
+		if reflect.DeepEqual(v.Interface(), old.Interface()) || v.Kind() == reflect.Slice && v.Len() == 0 && old.Len() == 0 {
+			return
+		}
+		if field.Tag.Get("reload") == "live" {
+			changes.Applied = append(changes.Applied, name)
+		} else {
+			changes.RestartRequired = append(changes.RestartRequired, name)
+			v.Set(old)
+		}
+	})
+	return &merged, changes
+}
+
+func parsePort(port string) (int, error) {
+	p, err := strconv.Atoi(port)
+	if err != nil || p <= 0 || p > 65535 {
+		return 0, fmt.Errorf("invalid port %q", port)
+	}
+	return p, nil
+}
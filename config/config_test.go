@@ -0,0 +1,158 @@
+package config_test
+
+import (
+	"discovery-service/config"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func load(t *testing.T, args ...string) (*config.Config, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return config.Load(fs, args)
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return file
+}
+
+func TestPrecedence(t *testing.T) {
+	file := writeFile(t, "node.yaml", `
+node:
+  port: "7001"
+  peers: [localhost:7002, localhost:7003]
+heartbeat:
+  period: 3s
+http:
+  rate: 5
+  burst: 10
+`)
+	t.Setenv("DISCOVERY_HTTP_RATE", "7")
+	t.Setenv("DISCOVERY_HTTP_BURST", "20")
+	t.Setenv("JOIN_TOKEN", "secret")
+
+	c, err := load(t, "-config", file, "-http-rate=9")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if c.Node.Port != "7001" || !reflect.DeepEqual(c.Node.Peers, []string{"localhost:7002", "localhost:7003"}) || c.Heartbeat.Period != 3*time.Second {
+		t.Errorf("Expected the file settings, got %+v %+v", c.Node, c.Heartbeat)
+	}
+	if c.HTTP.Burst != 20 || c.HTTP.Rate != 9 {
+		t.Errorf("Expected the burst of the environment and the rate of the flag, got %+v", c.HTTP)
+	}
+	if c.Node.JoinToken != "secret" || c.RPC.Timeout != 2*time.Second {
+		t.Errorf("Expected $JOIN_TOKEN and the default RPC timeout, got %q %s", c.Node.JoinToken, c.RPC.Timeout)
+	}
+	if addr, _ := c.HTTPPort(c.Node.Port); addr != ":8001" {
+		t.Errorf("Expected the HTTP API on :8001, got %s", addr)
+	}
+}
+
+func TestTOML(t *testing.T) {
+	file := writeFile(t, "node.toml", `
+# A node with strong counters
+watch.max_rate = 5.0
+
+[node]
+port = "7101"
+cluster = 'prod' # inline comment
+
+[heartbeat]
+period = "10s"
+max_retries = 3
+
+[counters]
+strong = [
+  "orders",
+  "payments", # trailing comma
+]
+hll_precision = 12
+
+[http]
+port_offset = 2_000
+rate = 50.5
+`)
+	c, err := load(t, "--config="+file)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if c.Node.Cluster != "prod" || c.Heartbeat.Period != 10*time.Second || c.Heartbeat.MaxRetries != 3 ||
+		!reflect.DeepEqual(c.Counters.Strong, []string{"orders", "payments"}) || c.Counters.HLLPrecision != 12 ||
+		c.HTTP.PortOffset != 2000 || c.HTTP.Rate != 50.5 || c.Watch.MaxRate != 5 {
+		t.Errorf("Unexpected configuration %+v", c)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	cases := []struct {
+		name    string
+		file    string
+		content string
+		env     string
+		args    []string
+		want    string
+	}{
+		{"unknown setting", "c.yaml", "heartbeat:\n  perod: 1s\n", "", nil, "field perod not found"},
+		{"bad duration", "c.toml", "[rpc]\ntimeout = \"soon\"\n", "", nil, "parse"},
+		{"bad format", "c.json", "{}", "", nil, ".yaml, .yml or .toml"},
+		{"bad environment", "", "", "DISCOVERY_HEARTBEAT_MAX_RETRIES=many", nil, "DISCOVERY_HEARTBEAT_MAX_RETRIES"},
+		{"retries", "", "", "", []string{"-heartbeat-retries=0"}, "heartbeat.max_retries"},
+		{"port offset", "", "", "", []string{"-port=65000"}, "http.port_offset"},
+		{"partial TLS", "", "", "", []string{"-tls-cert=node.pem"}, "set together"},
+		{"bounded", "", "", "", []string{"-bounded=signups"}, "name:limit"},
+		{"raft timing", "", "", "", []string{"-raft-election=300ms"}, "counters.raft_election"},
+		{"dial retries", "", "", "", []string{"-dial-retries=0"}, "rpc.dial_retries"},
+		{"raft snapshot", "", "", "", []string{"-raft-snapshot=0"}, "counters.raft_snapshot"},
+		{"rate idle", "", "", "", []string{"-http-rate=1", "-http-burst=100", "-rate-idle=1m"}, "rpc.rate_idle"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			args := c.args
+			if c.file != "" {
+				args = append(args, "-config", writeFile(t, c.file, c.content))
+			}
+			if c.env != "" {
+				name, value, _ := strings.Cut(c.env, "=")
+				t.Setenv(name, value)
+			}
+			if _, err := load(t, args...); err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Errorf("Expected an error about %q, got %v", c.want, err)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	current := config.Default()
+	next := config.Default()
+	next.Node.Port = "7001"
+	next.Node.Peers = []string{"localhost:7002"}
+	next.Heartbeat.Timeout = time.Second
+	next.Counters.Strong = []string{}
+	next.Tracing.Exporter = "stdout"
+
+	merged, changes := current.Merge(next)
+	if !reflect.DeepEqual(changes.Applied, []string{"node.peers", "heartbeat.timeout"}) ||
+		!reflect.DeepEqual(changes.RestartRequired, []string{"node.port", "tracing.exporter"}) {
+		t.Errorf("Unexpected changes %+v", changes)
+	}
+	if merged.Node.Port != "8080" || merged.Tracing.Exporter != "none" || merged.Heartbeat.Timeout != time.Second || len(merged.Node.Peers) != 1 {
+		t.Errorf("Expected only the live settings to change, got %+v %+v %+v", merged.Node, merged.Heartbeat, merged.Tracing)
+	}
+	if current.Heartbeat.Timeout != 2*time.Second {
+		t.Errorf("Expected Merge to leave the current configuration alone")
+	}
+}
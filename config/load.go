@@ -0,0 +1,263 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix starts the environment variable of every setting, followed by
+// its section and key, e.g. DISCOVERY_HEARTBEAT_PERIOD.
+const EnvPrefix = "DISCOVERY_"
+
+// ErrInvalid wraps the errors of a reload caused by the configuration
+// itself, rather than by the node failing to apply it.
+var ErrInvalid = errors.New("invalid configuration")
+
+// Load builds the configuration of a node from the defaults, the file given
+// with -config (or $DISCOVERY_CONFIG), the environment and the flags in
+// args, parsed with fs. Flags only override what they are given for.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	c := Default()
+	file := fileArg(args)
+	if file == "" {
+		file = os.Getenv(EnvPrefix + "CONFIG")
+	}
+	if file != "" {
+		if err := c.LoadFile(file); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.LoadEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	fs.String("config", file, "YAML or TOML configuration file (default $DISCOVERY_CONFIG)")
+	c.bindFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// fileArg finds the -config flag in args, which is needed before the other
+// flags are parsed as they override the file.
+func fileArg(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// LoadFile reads the settings in file over c, as YAML or TOML depending on
+// its extension. Unknown settings are an error, so typos are not ignored.
+func (c *Config) LoadFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	switch filepath.Ext(file) {
+	case ".yaml", ".yml":
+	case ".toml":
+		var tree map[string]any
+		if _, err := toml.Decode(string(data), &tree); err != nil {
+			return fmt.Errorf("parse %s: %w", file, err)
+		}
+		// The TOML tree is decoded like YAML to share the field mapping.
+		if data, err = yaml.Marshal(tree); err != nil {
+			return fmt.Errorf("parse %s: %w", file, err)
+		}
+	default:
+		return fmt.Errorf("%s: configuration files must be .yaml, .yml or .toml", file)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse %s: %w", file, err)
+	}
+	return nil
+}
+
+// LoadEnv reads the settings set in the environment over c. Lists are comma
+// separated. JOIN_TOKEN is still read for the join token.
+func (c *Config) LoadEnv(lookup func(string) (string, bool)) error {
+	if token, ok := lookup("JOIN_TOKEN"); ok {
+		c.Node.JoinToken = token
+	}
+	var errs []error
+	c.walk(func(section string, key string, v reflect.Value, _ reflect.StructField) {
+		name := EnvPrefix + strings.ToUpper(section+"_"+key)
+		value, ok := lookup(name)
+		if !ok {
+			return
+		}
+		if err := set(v, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// walk calls fn with every setting of c.
+func (c *Config) walk(fn func(section string, key string, v reflect.Value, field reflect.StructField)) {
+	root := reflect.ValueOf(c).Elem()
+	for i := range root.NumField() {
+		section := root.Type().Field(i).Tag.Get("yaml")
+		fields := root.Field(i)
+		for j := range fields.NumField() {
+			field := fields.Type().Field(j)
+			fn(section, field.Tag.Get("yaml"), fields.Field(j), field)
+		}
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set parses value into the setting v.
+func set(v reflect.Value, value string) error {
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Slice:
+		v.Set(reflect.ValueOf(splitList(value)))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// listFlag is a comma separated list flag.
+type listFlag struct{ list *[]string }
+
+func (f listFlag) String() string {
+	if f.list == nil {
+		return ""
+	}
+	return strings.Join(*f.list, ",")
+}
+
+func (f listFlag) Set(value string) error {
+	*f.list = splitList(value)
+	return nil
+}
+
+// bindFlags defines the flags of the settings on fs, writing to c.
+func (c *Config) bindFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Node.Port, "port", c.Node.Port, "port to listen on")
+	fs.Var(listFlag{&c.Node.Peers}, "peers", "comma-separated list of initial peers")
+	fs.StringVar(&c.Node.ClientPort, "client-port", c.Node.ClientPort, "port serving the client CounterService on its own, served with the peer RPCs if empty")
+	fs.StringVar(&c.Node.Cluster, "cluster", c.Node.Cluster, "name of the cluster, nodes of other clusters are refused")
+	fs.StringVar(&c.Node.JoinToken, "join-token", c.Node.JoinToken, "shared secret nodes prove to join the cluster (default $JOIN_TOKEN)")
+	fs.DurationVar(&c.Node.JoinProofTTL, "join-proof-ttl", c.Node.JoinProofTTL, "how long join challenges and the proofs sent with peer calls are accepted")
+	fs.BoolVar(&c.Node.MinorityReadOnly, "minority-read-only", c.Node.MinorityReadOnly, "refuse writes while this node cannot reach the majority of the cluster")
+
+	fs.DurationVar(&c.Heartbeat.Period, "heartbeat-period", c.Heartbeat.Period, "time between heartbeat rounds")
+	fs.DurationVar(&c.Heartbeat.Timeout, "heartbeat-timeout", c.Heartbeat.Timeout, "timeout of a heartbeat")
+	fs.IntVar(&c.Heartbeat.MaxRetries, "heartbeat-retries", c.Heartbeat.MaxRetries, "failed heartbeats before a peer is marked dead")
+	fs.DurationVar(&c.Heartbeat.BaseBackoff, "heartbeat-backoff", c.Heartbeat.BaseBackoff, "wait after the first failed heartbeat, doubled after every other")
+
+	fs.DurationVar(&c.RPC.Timeout, "rpc-timeout", c.RPC.Timeout, "timeout of the calls nodes make to each other")
+	fs.IntVar(&c.RPC.MaxMessageBytes, "max-message-bytes", c.RPC.MaxMessageBytes, "largest gRPC message accepted")
+	fs.Float64Var(&c.RPC.Rate, "grpc-rate", c.RPC.Rate, "gRPC calls per second allowed per peer IP, 0 disables the limit")
+	fs.IntVar(&c.RPC.Burst, "grpc-burst", c.RPC.Burst, "gRPC calls a peer IP may send at once")
+	fs.IntVar(&c.RPC.DialRetries, "dial-retries", c.RPC.DialRetries, "attempts to create a connection to a peer")
+	fs.DurationVar(&c.RPC.DialBackoff, "dial-backoff", c.RPC.DialBackoff, "wait after the first failed connection attempt, growing with every other")
+	fs.Float64Var(&c.RPC.ClientRate, "client-rate", c.RPC.ClientRate, "gRPC calls per second allowed per IP on the client port, 0 disables the limit")
+	fs.IntVar(&c.RPC.ClientBurst, "client-burst", c.RPC.ClientBurst, "gRPC calls a client IP may send at once on the client port")
+	fs.DurationVar(&c.RPC.RateIdle, "rate-idle", c.RPC.RateIdle, "how long the rate limits keep the bucket of a quiet client")
+
+	fs.IntVar(&c.HTTP.PortOffset, "http-port-offset", c.HTTP.PortOffset, "the HTTP API listens on the gRPC port plus this")
+	fs.StringVar(&c.HTTP.APIKeys, "api-keys", c.HTTP.APIKeys, "JSON file of HTTP API keys, the API is open without it")
+	fs.Float64Var(&c.HTTP.Rate, "http-rate", c.HTTP.Rate, "HTTP requests per second allowed per API key or IP, 0 disables the limit")
+	fs.IntVar(&c.HTTP.Burst, "http-burst", c.HTTP.Burst, "HTTP requests a client may send at once")
+	fs.Int64Var(&c.HTTP.MaxRequestBytes, "max-request-bytes", c.HTTP.MaxRequestBytes, "largest HTTP request body accepted")
+
+	fs.StringVar(&c.TLS.CA, "tls-ca", c.TLS.CA, "CA bundle used to verify peer and client certificates")
+	fs.StringVar(&c.TLS.Cert, "tls-cert", c.TLS.Cert, "node certificate, its SAN must match the node host")
+	fs.StringVar(&c.TLS.Key, "tls-key", c.TLS.Key, "node private key")
+	fs.DurationVar(&c.TLS.ReloadInterval, "tls-reload-interval", c.TLS.ReloadInterval, "how often the certificate files are checked for changes")
+
+	fs.Var(listFlag{&c.Counters.Strong}, "strong-counters", "comma-separated counter names replicated through raft")
+	fs.BoolVar(&c.Counters.RaftBootstrap, "raft-bootstrap", c.Counters.RaftBootstrap, "create the raft cluster with this node as its only member, on the first start of one node only")
+	fs.DurationVar(&c.Counters.RaftHeartbeat, "raft-heartbeat", c.Counters.RaftHeartbeat, "time between the raft leader's heartbeats")
+	fs.DurationVar(&c.Counters.RaftElection, "raft-election", c.Counters.RaftElection, "least time without a raft leader before an election, randomized up to twice that")
+	fs.DurationVar(&c.Counters.RaftPropose, "raft-propose", c.Counters.RaftPropose, "longest wait for a strong counter write or read to be committed")
+	fs.StringVar(&c.Counters.RaftDir, "raft-dir", c.Counters.RaftDir, "directory where raft keeps its term, vote and log across restarts")
+	fs.IntVar(&c.Counters.RaftSnapshot, "raft-snapshot", c.Counters.RaftSnapshot, "raft log entries after which the log is compacted into a snapshot")
+	fs.Var(listFlag{&c.Counters.Bounded}, "bounded", "comma-separated name:limit bounded counters")
+	fs.BoolVar(&c.Counters.BoundedSeed, "bounded-seed", c.Counters.BoundedSeed, "hold the whole allowance of the bounded counters, on the first start of one node only")
+	fs.IntVar(&c.Counters.HLLPrecision, "hll-precision", c.Counters.HLLPrecision, "HyperLogLog precision of distinct counters (4-16)")
+	fs.DurationVar(&c.Counters.RateWindow, "rate-window", c.Counters.RateWindow, "longest window kept for windowed rate counters")
+
+	fs.Float64Var(&c.Watch.MaxRate, "watch-max-rate", c.Watch.MaxRate, "most updates per second sent to each client watching a counter")
+	fs.DurationVar(&c.Watch.TokenTTL, "watch-token-ttl", c.Watch.TokenTTL, "how long a token from /v1/watch-token can be used to start watching")
+
+	fs.StringVar(&c.Tracing.Exporter, "trace-exporter", c.Tracing.Exporter, "where increment traces are sent: none, stdout or otlp")
+	fs.StringVar(&c.Tracing.OTLPEndpoint, "otlp-endpoint", c.Tracing.OTLPEndpoint, "OTLP/gRPC collector address (default $OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4317)")
+	fs.BoolVar(&c.Tracing.OTLPInsecure, "otlp-insecure", c.Tracing.OTLPInsecure, "connect to the OTLP collector without TLS")
+
+	fs.StringVar(&c.Log.Level, "log-level", c.Log.Level, "log level, optionally followed by subsystem=level overrides, e.g. info,raft=debug")
+	fs.StringVar(&c.Log.Format, "log-format", c.Log.Format, "log output format, text or json")
+
+	fs.StringVar(&c.Audit.File, "audit-log", c.Audit.File, "file the audit log is appended to, kept in memory only without it")
+}
@@ -0,0 +1,154 @@
+package reload
+
+import (
+	"discovery-service/config"
+	"discovery-service/discovery/client"
+	"discovery-service/lib/logging"
+	"discovery-service/models"
+	"discovery-service/security/audit"
+	"discovery-service/security/throttle"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Reloader reloads the configuration of a node from the file, environment
+// and flags it was started with, so flags keep overriding the file.
+type Reloader struct {
+	s         *models.Server
+	args      []string
+	grpcLimit *throttle.Limiter
+
+	mu sync.Mutex
+}
+
+// New creates the reloader of s, started with args. grpcLimit is the
+// limiter of its peer gRPC server, nil if it is unlimited.
+func New(s *models.Server, args []string, grpcLimit *throttle.Limiter) *Reloader {
+	return &Reloader{s: s, args: args, grpcLimit: grpcLimit}
+}
+
+// OnSignal reloads the configuration whenever the process gets SIGHUP.
+func (r *Reloader) OnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			if _, err := r.Reload("signal:SIGHUP"); err != nil {
+				r.s.Logger("config").Error("Failed to reload configuration", "err", err)
+			}
+		}
+	}()
+}
+
+// Reload reads the configuration again and applies the settings that can
+// change live. Settings that need a restart keep their current values and
+// are reported. Nothing changes if the configuration is invalid.
+func (r *Reloader) Reload(actor string) (config.Changes, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fs := flag.NewFlagSet("reload", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	next, err := config.Load(fs, r.args)
+	if err != nil {
+		return config.Changes{}, fmt.Errorf("%w: %w", config.ErrInvalid, err)
+	}
+	check, _ := logging.New(io.Discard, "text", slog.LevelInfo)
+	if err := check.Configure(next.Log.Level); err != nil {
+		return config.Changes{}, fmt.Errorf("%w: %w", config.ErrInvalid, err)
+	}
+
+	// Certificates and keys are read again even if their files are the
+	// same, as that is how they are rotated. Both are read before either
+	// is applied, so a failure leaves the node as it was.
+	var apply []func()
+	if r.s.APIKeys != nil {
+		keys, err := r.s.APIKeys.Prepare()
+		if err != nil {
+			return config.Changes{}, fmt.Errorf("reload API keys: %w", err)
+		}
+		apply = append(apply, keys)
+	}
+	if r.s.TLS != nil {
+		certs, err := r.s.TLS.Prepare()
+		if err != nil {
+			return config.Changes{}, fmt.Errorf("reload TLS certificates: %w", err)
+		}
+		apply = append(apply, certs)
+	}
+	for _, f := range apply {
+		f()
+	}
+
+	current := r.s.Config()
+	merged, changes := current.Merge(next)
+	limit(r.s.HTTPLimit, &merged.HTTP.Rate, &merged.HTTP.Burst, current.HTTP.Rate, current.HTTP.Burst, "http.rate", "http.burst", &changes)
+	limit(r.grpcLimit, &merged.RPC.Rate, &merged.RPC.Burst, current.RPC.Rate, current.RPC.Burst, "rpc.rate", "rpc.burst", &changes)
+	if current.Node.ClientPort != "" {
+		limit(r.s.ClientLimit, &merged.RPC.ClientRate, &merged.RPC.ClientBurst, current.RPC.ClientRate, current.RPC.ClientBurst, "rpc.client_rate", "rpc.client_burst", &changes)
+	}
+	if merged.Log.Level != current.Log.Level && r.s.Log != nil {
+		_, overrides := r.s.Log.Levels()
+		for subsystem := range overrides {
+			r.s.Log.ResetLevel(subsystem)
+		}
+		r.s.Log.SetLevel("", slog.LevelInfo)
+		r.s.Log.Configure(merged.Log.Level)
+	}
+	r.s.SetConfig(merged)
+	// Settings kept outside the configuration
+	if r.s.Auth != nil {
+		r.s.Auth.SetTTL(merged.Node.JoinProofTTL)
+	}
+	if r.s.TLS != nil {
+		r.s.TLS.SetReloadInterval(merged.TLS.ReloadInterval)
+	}
+
+	var seeds []string
+	for _, peer := range merged.Node.Peers {
+		if !slices.Contains(current.Node.Peers, peer) {
+			seeds = append(seeds, peer)
+		}
+	}
+	if len(seeds) > 0 {
+		go client.Join(r.s, seeds)
+	}
+
+	logger := r.s.Logger("config")
+	logger.Info("Configuration reloaded", "by", actor, "applied", changes.Applied)
+	if len(changes.RestartRequired) > 0 {
+		logger.Warn("Settings changed that only take effect after a restart", "settings", changes.RestartRequired)
+	}
+	r.s.Audit.Record(audit.ConfigReloaded, actor, r.s.Id, fmt.Sprintf("applied: %s; restart required: %s",
+		strings.Join(changes.Applied, ", "), strings.Join(changes.RestartRequired, ", ")))
+	return changes, nil
+}
+
+// limit applies a changed rate limit. A limiter that was off when the node
+// started does not exist, turning it on needs a restart.
+func limit(l *throttle.Limiter, rate *float64, burst *int, oldRate float64, oldBurst int, rateName string, burstName string, changes *config.Changes) {
+	if *rate == oldRate && *burst == oldBurst {
+		return
+	}
+	if l != nil {
+		l.SetRate(*rate, *burst)
+		return
+	}
+	if *rate > 0 {
+		*rate, *burst = oldRate, oldBurst
+		for _, name := range []string{rateName, burstName} {
+			if i := slices.Index(changes.Applied, name); i >= 0 {
+				changes.Applied = slices.Delete(changes.Applied, i, i+1)
+				changes.RestartRequired = append(changes.RestartRequired, name)
+			}
+		}
+	}
+}
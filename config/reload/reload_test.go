@@ -0,0 +1,122 @@
+package reload_test
+
+import (
+	"discovery-service/config"
+	"discovery-service/config/reload"
+	"discovery-service/models"
+	"discovery-service/security/apikey"
+	"discovery-service/security/throttle"
+	"discovery-service/web"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func write(t *testing.T, file string, content string) {
+	t.Helper()
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write the configuration: %v", err)
+	}
+}
+
+func post(t *testing.T, key string) (int, config.Changes) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost:9115/admin/reload", nil)
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to call reload: %v", err)
+	}
+	defer resp.Body.Close()
+	var changes config.Changes
+	data, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(data, &changes)
+	return resp.StatusCode, changes
+}
+
+func TestReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "node.yaml")
+	write(t, file, "node:\n  port: \"8115\"\nheartbeat:\n  period: 5s\nhttp:\n  rate: 1\n  burst: 2\n")
+	args := []string{"-config", file}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err := config.Load(fs, args)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	s := models.NewServer("localhost:8115")
+	s.SetConfig(cfg)
+	s.HTTPLimit = throttle.New(cfg.HTTP.Rate, cfg.HTTP.Burst, cfg.RPC.RateIdle)
+	s.Reloader = reload.New(s, args, nil)
+	web.StartHTTPServer(s, "8115")
+	time.Sleep(200 * time.Millisecond)
+
+	write(t, file, "node:\n  port: \"7000\"\nheartbeat:\n  period: 2s\nhttp:\n  rate: 1000\n  burst: 1000\nrpc:\n  rate: 5\n")
+	code, changes := post(t, "")
+	if code != http.StatusOK {
+		t.Fatalf("Expected the reload to succeed, got %d", code)
+	}
+	for _, name := range []string{"heartbeat.period", "http.rate", "http.burst"} {
+		if !slices.Contains(changes.Applied, name) {
+			t.Errorf("Expected %s to be applied, got %+v", name, changes)
+		}
+	}
+	// The gRPC limiter was off at startup, so turning it on needs a restart.
+	for _, name := range []string{"node.port", "rpc.rate"} {
+		if !slices.Contains(changes.RestartRequired, name) {
+			t.Errorf("Expected %s to require a restart, got %+v", name, changes)
+		}
+	}
+	c := s.Config()
+	if c.Heartbeat.Period != 2*time.Second || c.HTTP.Rate != 1000 || c.Node.Port != "8115" || c.RPC.Rate != 0 {
+		t.Errorf("Unexpected configuration after the reload %+v %+v %+v %+v", c.Node, c.Heartbeat, c.HTTP, c.RPC)
+	}
+
+	// The old limit of 2 requests at once no longer applies.
+	for i := 0; i < 5; i++ {
+		resp, err := http.Get("http://localhost:9115/peers")
+		if err != nil {
+			t.Fatalf("Failed to call peers API: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected request %d to pass the new rate limit, got %d", i, resp.StatusCode)
+		}
+	}
+
+	write(t, file, "heartbeat:\n  period: never\n")
+	if code, _ := post(t, ""); code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid configuration to be refused, got %d", code)
+	}
+	if s.Config() != c {
+		t.Errorf("Expected an invalid configuration to change nothing")
+	}
+
+	// A keys file the node cannot read is not the caller's fault, and
+	// leaves the keys and the configuration as they were.
+	keys := filepath.Join(t.TempDir(), "keys.json")
+	write(t, keys, `[{"name":"ops","key":"secret","permission":"admin"}]`)
+	s.APIKeys, err = apikey.Load(keys)
+	if err != nil {
+		t.Fatalf("Failed to load API keys: %v", err)
+	}
+	write(t, file, "heartbeat:\n  period: 3s\n")
+	write(t, keys, "not json")
+	if code, _ := post(t, "secret"); code != http.StatusInternalServerError {
+		t.Errorf("Expected an unreadable keys file to fail the reload with 500, got %d", code)
+	}
+	if s.Config() != c {
+		t.Errorf("Expected a failed reload to change nothing")
+	}
+	if _, err := s.APIKeys.Lookup("secret"); err != nil {
+		t.Errorf("Expected the old keys to be kept: %v", err)
+	}
+}
@@ -0,0 +1,310 @@
+package api
+
+import (
+	"context"
+	"discovery-service/counter/bounded"
+	"discovery-service/counter/increment"
+	"discovery-service/counter/ratelimit"
+	"discovery-service/models"
+	pb "discovery-service/proto"
+	"discovery-service/security/apikey"
+	"errors"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxIncrements caps the delta of one increment of the default
+	// counter, which is applied as that many separate ops.
+	maxIncrements = 10000
+	// maxBatch caps the number of increments of one BatchIncrement.
+	maxBatch = 1000
+)
+
+// Increment adds delta to a counter, dispatching on the kind of counter
+// like the HTTP API. Only strong counters can be decremented: the default
+// counter and the eventually consistent named counters only grow.
+// Increments of the default counter with an id are applied once however
+// often client sends them, named counters have no op IDs and refuse an id.
+// Errors are gRPC status errors.
+func Increment(ctx context.Context, s *models.Server, client string, counter string, delta int64, id string) (count int64, duplicate bool, err error) {
+	count, duplicate, ops, err := apply(ctx, s, client, counter, delta, id)
+	increment.PropagateIncrement(ctx, s, ops...)
+	return count, duplicate, err
+}
+
+// apply applies an increment like Increment, but returns the ops of the
+// default counter it applied instead of propagating them.
+func apply(ctx context.Context, s *models.Server, client string, counter string, delta int64, id string) (count int64, duplicate bool, ops []string, err error) {
+	if s.ReadOnlyInMinority && s.IsPartitioned() {
+		return 0, false, nil, status.Error(codes.Unavailable, "node is partitioned from the majority of the cluster")
+	}
+	if ratelimit.Reserved(counter) {
+		return 0, false, nil, reserved()
+	}
+	strong := counter != "" && s.Strong != nil && s.Strong.Strong(counter)
+	if delta == 0 || (delta < 0 && !strong) {
+		return 0, false, nil, status.Error(codes.InvalidArgument, "delta must be positive, only strong counters can be decremented")
+	}
+	if id != "" && counter != "" {
+		return 0, false, nil, status.Error(codes.InvalidArgument, "only increments of the default counter can have an id")
+	}
+
+	switch {
+	case counter == "":
+		if delta > maxIncrements {
+			return 0, false, nil, status.Errorf(codes.InvalidArgument, "delta must be at most %d", maxIncrements)
+		}
+		for i := range delta {
+			opID := opID(client, id, i)
+			if !s.ClaimOp(opID) {
+				s.Metrics.IncrementsDeduplicated.Inc()
+				continue
+			}
+			s.QueueIncrement(ctx, opID)
+			ops = append(ops, opID)
+		}
+		s.Mu.Lock()
+		defer s.Mu.Unlock()
+		return s.Counter, len(ops) == 0, ops, nil
+
+	case strong:
+		count, err := s.Strong.Increment(ctx, counter, delta)
+		if err != nil {
+			return 0, false, nil, status.Error(codes.Unavailable, err.Error())
+		}
+		return count, false, nil, nil
+
+	case s.Bounded != nil && s.Bounded.Bounded(counter):
+		_, err := s.Bounded.Increment(ctx, counter, delta)
+		// Not ResourceExhausted, which is for rate limits: retrying
+		// later does not help once the allowance is used up.
+		if errors.Is(err, bounded.ErrLimitReached) {
+			return 0, false, nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		if err != nil {
+			return 0, false, nil, status.Error(codes.Unavailable, err.Error())
+		}
+		used, _, err := s.Bounded.Get(ctx, counter)
+		if err != nil {
+			return 0, false, nil, status.Error(codes.Unavailable, err.Error())
+		}
+		return used, false, nil, nil
+
+	case s.Windowed != nil:
+		s.Windowed.Increment(counter, delta)
+		count, _ := s.Windowed.Count(counter, s.Windowed.Retention())
+		return count, false, nil, nil
+
+	default:
+		return 0, false, nil, status.Error(codes.NotFound, "unknown counter "+counter)
+	}
+}
+
+// Batch applies several increments for client like Increment, each one
+// allowed by allow. Each is applied on its own and a failed one does not
+// stop the others, but the ops of the default counter are propagated in one
+// message. Batches of more than maxBatch increments, or adding more than
+// maxIncrements to the default counter, are refused as a whole.
+func Batch(ctx context.Context, s *models.Server, client string, incs []*pb.CounterIncrementRequest, allow func(counter string) error) ([]*pb.BatchResult, error) {
+	if len(incs) > maxBatch {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d increments per batch", maxBatch)
+	}
+	total := int64(0)
+	for _, inc := range incs {
+		if inc.Counter == "" {
+			// Capped so the sum cannot overflow, larger deltas are refused
+			total += min(max(inc.Delta, 1), maxIncrements+1)
+		}
+	}
+	if total > maxIncrements {
+		return nil, status.Errorf(codes.InvalidArgument, "a batch can add at most %d to the default counter", maxIncrements)
+	}
+
+	results := make([]*pb.BatchResult, len(incs))
+	var ops []string
+	for i, inc := range incs {
+		delta := inc.Delta
+		if delta == 0 {
+			delta = 1
+		}
+		err := allow(inc.Counter)
+		if err == nil && delta < 0 {
+			err = status.Error(codes.InvalidArgument, "delta must be positive")
+		}
+		result := &pb.BatchResult{}
+		if err == nil {
+			var applied []string
+			result.Count, result.Duplicate, applied, err = apply(ctx, s, client, inc.Counter, delta, inc.Id)
+			ops = append(ops, applied...)
+		}
+		if err != nil {
+			st := status.Convert(err)
+			result.Code, result.Error = int32(st.Code()), st.Message()
+		}
+		results[i] = result
+	}
+	increment.PropagateIncrement(ctx, s, ops...)
+	return results, nil
+}
+
+// Get reads a counter, the default counter if counter is "".
+func Get(ctx context.Context, s *models.Server, counter string) (*pb.CounterValue, error) {
+	if ratelimit.Reserved(counter) {
+		return nil, reserved()
+	}
+	value := &pb.CounterValue{Counter: counter, Stale: s.IsPartitioned()}
+	switch {
+	case counter == "":
+		s.Mu.Lock()
+		value.Count = s.Counter
+		s.Mu.Unlock()
+
+	case s.Strong != nil && s.Strong.Strong(counter):
+		count, err := s.Strong.Get(ctx, counter)
+		if err != nil {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+		value.Count = count
+
+	case s.Bounded != nil && s.Bounded.Bounded(counter):
+		used, limit, err := s.Bounded.Get(ctx, counter)
+		if err != nil {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+		value.Count, value.Limit = used, limit
+
+	case s.Windowed != nil:
+		value.Count, _ = s.Windowed.Count(counter, s.Windowed.Retention())
+
+	default:
+		return nil, status.Error(codes.NotFound, "unknown counter "+counter)
+	}
+	return value, nil
+}
+
+// opID returns the op ID of the i-th increment of the default counter in a
+// call. Clients retrying a call send the same id, so each increment keeps
+// its op ID and the ones already applied are skipped.
+func opID(client string, id string, i int64) string {
+	if id == "" {
+		return uuid.New().String()
+	}
+	return models.ClientOpID(client, "", id, i)
+}
+
+// Service serves the client-facing CounterService.
+type Service struct {
+	pb.UnimplementedCounterServiceServer
+	s *models.Server
+}
+
+// NewService creates the service, giving s a hub watching counters at the
+// default rate if it has none.
+func NewService(s *models.Server) *Service {
+	if s.Watcher == nil {
+		s.Watcher = NewHub(s, 0)
+	}
+	return &Service{s: s}
+}
+
+func (svc *Service) Increment(ctx context.Context, req *pb.CounterIncrementRequest) (*pb.CounterIncrementResponse, error) {
+	return svc.increment(ctx, req, 1)
+}
+
+// Decrement subtracts delta from a strong counter.
+func (svc *Service) Decrement(ctx context.Context, req *pb.CounterIncrementRequest) (*pb.CounterIncrementResponse, error) {
+	return svc.increment(ctx, req, -1)
+}
+
+func (svc *Service) increment(ctx context.Context, req *pb.CounterIncrementRequest, sign int64) (*pb.CounterIncrementResponse, error) {
+	key, err := svc.authorize(ctx, apikey.Increment, req.Counter)
+	if err != nil {
+		return nil, err
+	}
+	delta := req.Delta
+	if delta == 0 {
+		delta = 1
+	}
+	if delta < 0 {
+		return nil, status.Error(codes.InvalidArgument, "delta must be positive")
+	}
+	count, duplicate, err := Increment(ctx, svc.s, apikey.Caller(ctx, key), req.Counter, sign*delta, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CounterIncrementResponse{Count: count, Duplicate: duplicate}, nil
+}
+
+func (svc *Service) Get(ctx context.Context, req *pb.GetRequest) (*pb.CounterValue, error) {
+	if _, err := svc.authorize(ctx, apikey.Read, req.Counter); err != nil {
+		return nil, err
+	}
+	return Get(ctx, svc.s, req.Counter)
+}
+
+// BatchIncrement applies several increments in one call. Each is
+// authorized and applied on its own, a failed one does not stop the others.
+func (svc *Service) BatchIncrement(ctx context.Context, req *pb.BatchIncrementRequest) (*pb.BatchIncrementResponse, error) {
+	// Every increment is sent with the same key, only the counters it
+	// allows differ.
+	key, err := svc.s.APIKeys.AuthorizeRPC(ctx, apikey.Increment, "")
+	if status.Code(err) == codes.Unauthenticated {
+		return nil, err
+	}
+	results, err := Batch(ctx, svc.s, apikey.Caller(ctx, key), req.Increments, func(counter string) error {
+		_, err := svc.authorize(ctx, apikey.Increment, counter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.BatchIncrementResponse{Results: results}, nil
+}
+
+// Watch sends the value of a counter, then its changes until the client
+// cancels. Changes are coalesced to the requested rate, and clients can
+// resume from the last value they saw.
+func (svc *Service) Watch(req *pb.WatchRequest, stream grpc.ServerStreamingServer[pb.CounterValue]) error {
+	ctx := stream.Context()
+	if _, err := svc.authorize(ctx, apikey.Read, req.Counter); err != nil {
+		return err
+	}
+	values, err := svc.s.Watcher.Subscribe(ctx, req.Counter, req.Epoch, req.SinceVersion, req.MaxRate)
+	if err != nil {
+		return err
+	}
+	for value := range values {
+		if err := stream.Send(value); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() == nil {
+		return status.Error(codes.Unavailable, "counter can no longer be read")
+	}
+	return nil
+}
+
+// ListNodes returns the addresses clients can reach the CounterService of
+// this node and its live peers at, which differ from the peer addresses
+// when nodes serve clients on a port of their own.
+func (svc *Service) ListNodes(ctx context.Context, _ *pb.Empty) (*pb.NodeList, error) {
+	if _, err := svc.authorize(ctx, apikey.Read, ""); err != nil {
+		return nil, err
+	}
+	return &pb.NodeList{Nodes: svc.s.ClientAddrs()}, nil
+}
+
+func (svc *Service) authorize(ctx context.Context, perm apikey.Permission, counter string) (*apikey.Key, error) {
+	key, err := svc.s.APIKeys.AuthorizeRPC(ctx, perm, counter)
+	if status.Code(err) == codes.PermissionDenied {
+		svc.s.Logger("api").Info("API key denied", "key", key.Name, "permission", perm.String(), "counter", counter)
+	}
+	return key, err
+}
+
+func reserved() error {
+	return status.Errorf(codes.InvalidArgument, "counter names starting with %s are reserved", ratelimit.Prefix)
+}
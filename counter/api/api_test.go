@@ -0,0 +1,111 @@
+package api_test
+
+import (
+	"context"
+	"discovery-service/lib/testnode"
+	"discovery-service/models"
+	"discovery-service/proto"
+	"discovery-service/security/apikey"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const keys = `[
+	{"name": "dashboard", "key": "read-key", "permission": "read"},
+	{"name": "app", "key": "app-key", "permission": "increment"}
+]`
+
+func withKey(ctx context.Context, key string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+key)
+}
+
+func TestCounterService(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "keys.json")
+	os.WriteFile(file, []byte(keys), 0o600)
+	store, err := apikey.Load(file)
+	if err != nil {
+		t.Fatalf("Failed to load keys: %v", err)
+	}
+	node := testnode.Start(t, nil, testnode.Windowed(time.Minute), func(s *models.Server, _ *grpc.Server) {
+		s.APIKeys = store
+	}, testnode.CounterService())
+
+	conn, err := grpc.NewClient(node.Id, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer conn.Close()
+	c := proto.NewCounterServiceClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	app := withKey(ctx, "app-key")
+
+	// Calls need a key with the right permission
+	if _, err := c.Get(ctx, &proto.GetRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Expected a call without key to fail, got %v", err)
+	}
+	if _, err := c.Increment(withKey(ctx, "read-key"), &proto.CounterIncrementRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("Expected a read key to be refused, got %v", err)
+	}
+
+	watch, err := c.Watch(withKey(ctx, "read-key"), &proto.WatchRequest{})
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	if value, err := watch.Recv(); err != nil || value.Count != 0 {
+		t.Fatalf("Expected the watch to start at 0, got %v %v", value, err)
+	}
+
+	if _, err := c.Increment(app, &proto.CounterIncrementRequest{Delta: 2}); err != nil {
+		t.Fatalf("Increment failed: %v", err)
+	}
+	if value, err := watch.Recv(); err != nil || value.Count != 2 {
+		t.Fatalf("Expected the watch to see 2, got %v %v", value, err)
+	}
+
+	// The default counter only grows
+	if _, err := c.Decrement(app, &proto.CounterIncrementRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected decrementing the default counter to fail, got %v", err)
+	}
+
+	batch, err := c.BatchIncrement(app, &proto.BatchIncrementRequest{Increments: []*proto.CounterIncrementRequest{
+		{Id: "a"},
+		{Id: "a"},
+		{Counter: "clicks", Delta: 3},
+		{Delta: -1},
+	}})
+	if err != nil {
+		t.Fatalf("BatchIncrement failed: %v", err)
+	}
+	r := batch.Results
+	if len(r) != 4 || r[0].Duplicate || !r[1].Duplicate || r[2].Count != 3 || codes.Code(r[3].Code) != codes.InvalidArgument {
+		t.Fatalf("Unexpected batch results %v", r)
+	}
+
+	// A batch adds at most 10000 to the default counter
+	_, err = c.BatchIncrement(app, &proto.BatchIncrementRequest{Increments: []*proto.CounterIncrementRequest{
+		{Delta: 6000},
+		{Delta: 6000},
+	}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected a batch adding 12000 to fail, got %v", err)
+	}
+
+	get := func() int64 {
+		value, err := c.Get(app, &proto.GetRequest{})
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		return value.Count
+	}
+	if !testnode.Eventually(time.Second, func() bool { return get() == 3 }) {
+		t.Fatalf("Expected the counter to be 3, got %d", get())
+	}
+}
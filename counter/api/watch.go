@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"discovery-service/models"
+	pb "discovery-service/proto"
+	"github.com/google/uuid"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Hub watches counters for changes on behalf of all clients watching them,
+// over gRPC or HTTP. A counter is read once per poll however many clients
+// watch it, and only while someone does. Every change gets a new version so
+// clients can resume; versions start over when the node restarts, which is
+// told apart by the epoch.
+type Hub struct {
+	s       *models.Server
+	epoch   string
+	maxRate float64
+	version atomic.Uint64 // Shared by all counters so nothing is kept of counters no one watches
+
+	mu     sync.Mutex
+	topics map[string]*topic
+}
+
+type topic struct {
+	mu          sync.Mutex
+	value       *pb.CounterValue
+	err         error
+	changed     chan struct{} // Closed and replaced on every change
+	subscribers int
+}
+
+// NewHub creates a hub sending every watcher at most maxRate updates per
+// second, changes in between are coalesced into the latest value. The
+// watch.max_rate setting is used if maxRate is not positive.
+func NewHub(s *models.Server, maxRate float64) *Hub {
+	return &Hub{s: s, epoch: uuid.New().String(), maxRate: maxRate, topics: map[string]*topic{}}
+}
+
+// Subscribe sends the value of counter, then its changes, until ctx is done
+// or the counter cannot be read anymore. Watchers resuming with the epoch
+// and version of the last value they saw only get newer values. maxRate
+// lowers the hub's rate for this watcher if it is positive.
+func (h *Hub) Subscribe(ctx context.Context, counter string, epoch string, since uint64, maxRate float64) (<-chan *pb.CounterValue, error) {
+	// Fail early for counters that do not exist.
+	if _, err := Get(ctx, h.s, counter); err != nil {
+		return nil, err
+	}
+	if epoch != h.epoch {
+		since = 0
+	}
+	limit := h.maxRate
+	if limit <= 0 {
+		limit = h.s.Config().Watch.MaxRate
+	}
+	if maxRate <= 0 || maxRate > limit {
+		maxRate = limit
+	}
+
+	t := h.join(counter)
+	out := make(chan *pb.CounterValue)
+	go func() {
+		defer close(out)
+		defer h.leave(t)
+
+		interval := time.Duration(float64(time.Second) / maxRate)
+		for {
+			t.mu.Lock()
+			value, err, changed := t.value, t.err, t.changed
+			t.mu.Unlock()
+			if err != nil {
+				return
+			}
+
+			if value != nil && value.Version > since {
+				select {
+				case out <- value:
+				case <-ctx.Done():
+					return
+				}
+				since = value.Version
+				// Changes until the next update are coalesced.
+				select {
+				case <-time.After(interval):
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (h *Hub) join(counter string) *topic {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	t, ok := h.topics[counter]
+	if !ok {
+		t = &topic{changed: make(chan struct{})}
+		h.topics[counter] = t
+		go h.poll(counter, t)
+	}
+	t.mu.Lock()
+	t.subscribers++
+	t.mu.Unlock()
+	return t
+}
+
+func (h *Hub) leave(t *topic) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.subscribers--
+}
+
+// poll reads counter every watch.poll_interval until its topic has no
+// subscribers left, publishing every change. Versions come from the hub, so
+// they keep increasing when the counter is watched again later.
+func (h *Hub) poll(counter string, t *topic) {
+	var last *pb.CounterValue
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		value, err := Get(ctx, h.s, counter)
+		cancel()
+
+		// The hub lock keeps join from picking the topic while it stops.
+		h.mu.Lock()
+		t.mu.Lock()
+		if (t.subscribers == 0 && t.value != nil) || err != nil {
+			delete(h.topics, counter)
+		}
+		h.mu.Unlock()
+
+		switch {
+		case t.subscribers == 0 && t.value != nil:
+			t.mu.Unlock()
+			return
+		case err != nil:
+			h.s.Logger("api").Warn("Failed to read watched counter", "counter", counter, "err", err)
+			t.err = err
+		case last == nil || value.Count != last.Count || value.Limit != last.Limit || value.Stale != last.Stale:
+			value.Version, value.Epoch = h.version.Add(1), h.epoch
+			last, t.value = value, value
+		default:
+			t.mu.Unlock()
+			time.Sleep(h.s.Config().Watch.PollInterval)
+			continue
+		}
+		close(t.changed)
+		t.changed = make(chan struct{})
+		t.mu.Unlock()
+		if err != nil {
+			return
+		}
+		time.Sleep(h.s.Config().Watch.PollInterval)
+	}
+}
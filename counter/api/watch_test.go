@@ -0,0 +1,103 @@
+package api_test
+
+import (
+	"bufio"
+	"context"
+	"discovery-service/counter/api"
+	"discovery-service/lib/testnode"
+	"discovery-service/models"
+	"discovery-service/proto"
+	"encoding/json"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	node := testnode.Start(t, nil, func(s *models.Server, _ *grpc.Server) {
+		s.Watcher = api.NewHub(s, 5)
+	}, testnode.CounterService())
+
+	conn, err := grpc.NewClient(node.Id, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer conn.Close()
+	c := proto.NewCounterServiceClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	watch, err := c.Watch(ctx, &proto.WatchRequest{})
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	first, err := watch.Recv()
+	if err != nil || first.Count != 0 || first.Version == 0 {
+		t.Fatalf("Expected a first versioned value of 0, got %v %v", first, err)
+	}
+
+	// A burst of increments is coalesced to the rate limit
+	for range 20 {
+		if _, err := c.Increment(ctx, &proto.CounterIncrementRequest{}); err != nil {
+			t.Fatalf("Increment failed: %v", err)
+		}
+		time.Sleep(25 * time.Millisecond)
+	}
+	var updates int
+	last := first
+	for last.Count < 20 {
+		if last, err = watch.Recv(); err != nil {
+			t.Fatalf("Watch failed: %v", err)
+		}
+		updates++
+	}
+	if updates > 5 {
+		t.Fatalf("Expected at most 5 updates at 5 per second, got %d", updates)
+	}
+
+	// Resuming from the last version only sends newer values
+	resumeCtx, resumeCancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	resumed, err := c.Watch(resumeCtx, &proto.WatchRequest{Epoch: last.Epoch, SinceVersion: last.Version})
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	if value, err := resumed.Recv(); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("Expected no value when resuming at the latest version, got %v %v", value, err)
+	}
+	resumeCancel()
+
+	// Server-Sent Events resume from Last-Event-ID
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, node.URL+"/count/events", nil)
+	req.Header.Set("Last-Event-ID", fmt.Sprintf("%s:%d", last.Epoch, last.Version))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open event stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if _, err := c.Increment(ctx, &proto.CounterIncrementRequest{}); err != nil {
+		t.Fatalf("Increment failed: %v", err)
+	}
+	lines := bufio.NewScanner(resp.Body)
+	for lines.Scan() {
+		data, ok := strings.CutPrefix(lines.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var event struct {
+			Count   int64  `json:"count"`
+			Version uint64 `json:"version"`
+		}
+		json.Unmarshal([]byte(data), &event)
+		if event.Count != 21 || event.Version <= last.Version {
+			t.Fatalf("Expected the event after the resumed version to count 21, got %s", data)
+		}
+		return
+	}
+	t.Fatalf("Event stream ended: %v", lines.Err())
+}
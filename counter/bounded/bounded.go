@@ -0,0 +1,220 @@
+package bounded
+
+import (
+	"context"
+	"discovery-service/lib/arrays"
+	"discovery-service/models"
+	pb "discovery-service/proto"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var ErrLimitReached = errors.New("counter limit reached")
+
+type counter struct {
+	limit     int64
+	allowance int64 // Escrow share this node may still consume
+	used      int64 // Consumed on this node
+	refill    sync.Mutex
+}
+
+// Counters enforces "at most N" counters without coordinating every
+// increment. The global allowance is held in per-node escrow shares, a node
+// only consumes its own share and asks peers for part of theirs when it runs
+// low. Allowance is only ever moved, so the cluster total never exceeds the
+// limit.
+type Counters struct {
+	pb.UnimplementedEscrowServer
+
+	s        *models.Server
+	logger   *slog.Logger
+	mu       sync.Mutex
+	counters map[string]*counter
+}
+
+// ParseLimits parses a comma-separated list of name:limit pairs.
+func ParseLimits(spec string) (map[string]int64, error) {
+	limits := map[string]int64{}
+	for _, item := range strings.Split(spec, ",") {
+		if item == "" {
+			continue
+		}
+		name, limit, ok := strings.Cut(item, ":")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid bounded counter %q, expected name:limit", item)
+		}
+		n, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid limit for bounded counter %q", name)
+		}
+		limits[name] = n
+	}
+	return limits, nil
+}
+
+// NewCounters creates the bounded counters. Only the node started with seed
+// set holds the whole allowance, every other node starts empty and borrows
+// from its peers. Seed must be set once on one node: a node seeded again
+// after a restart creates allowance and the cluster can exceed the limit.
+func NewCounters(s *models.Server, limits map[string]int64, seed bool) *Counters {
+	c := &Counters{s: s, logger: s.Logger("bounded"), counters: map[string]*counter{}}
+	for name, limit := range limits {
+		ctr := &counter{limit: limit}
+		if seed {
+			ctr.allowance = limit
+		}
+		c.counters[name] = ctr
+	}
+	if seed {
+		c.logger.Info("Holding the initial allowance of bounded counters", "node", s.Id, "counters", len(limits))
+	}
+	return c
+}
+
+// Bounded reports whether name is a bounded counter.
+func (c *Counters) Bounded(name string) bool {
+	_, ok := c.counters[name]
+	return ok
+}
+
+// Increment consumes delta from the local allowance, borrowing from peers
+// if needed, and returns the allowance left on this node.
+func (c *Counters) Increment(ctx context.Context, name string, delta int64) (int64, error) {
+	ctr, ok := c.counters[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown bounded counter %s", name)
+	}
+	if delta <= 0 {
+		return 0, fmt.Errorf("delta must be positive")
+	}
+
+	if remaining, ok := c.consume(ctr, delta); ok {
+		return remaining, nil
+	}
+
+	// Only one request per counter borrows at a time, the others retry
+	// against the refilled allowance.
+	ctr.refill.Lock()
+	defer ctr.refill.Unlock()
+	if remaining, ok := c.consume(ctr, delta); ok {
+		return remaining, nil
+	}
+	c.borrow(ctx, name, ctr, delta)
+	if remaining, ok := c.consume(ctr, delta); ok {
+		return remaining, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return ctr.allowance, ErrLimitReached
+}
+
+// Get returns the cluster-wide usage of a bounded counter, summed over the
+// peers that currently answer, and its limit.
+func (c *Counters) Get(ctx context.Context, name string) (int64, int64, error) {
+	ctr, ok := c.counters[name]
+	if !ok {
+		return 0, 0, fmt.Errorf("unknown bounded counter %s", name)
+	}
+
+	c.mu.Lock()
+	used := ctr.used
+	c.mu.Unlock()
+
+	for _, peer := range c.peers() {
+		resp, err := c.transfer(ctx, peer, name, 0)
+		if err != nil {
+			c.logger.Warn("Failed to read usage", "counter", name, "peer", peer, "err", err)
+			continue
+		}
+		used += resp.Used
+	}
+	return used, ctr.limit, nil
+}
+
+// Transfer hands part of this node's allowance to a peer that ran low. At
+// most half of the local allowance is given away so nodes do not keep
+// draining each other.
+func (c *Counters) Transfer(ctx context.Context, req *pb.EscrowRequest) (*pb.EscrowResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ctr, ok := c.counters[req.Counter]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown bounded counter %s", req.Counter)
+	}
+
+	var granted int64
+	if req.Amount > 0 {
+		granted = min(req.Amount, (ctr.allowance+1)/2)
+		ctr.allowance -= granted
+		if granted > 0 {
+			c.logger.Debug("Granted allowance", "counter", req.Counter, "amount", granted, "peer", req.Requester)
+		}
+	}
+	return &pb.EscrowResponse{Granted: granted, Allowance: ctr.allowance, Used: ctr.used}, nil
+}
+
+func (c *Counters) consume(ctr *counter, delta int64) (int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ctr.allowance < delta {
+		return ctr.allowance, false
+	}
+	ctr.allowance -= delta
+	ctr.used += delta
+	return ctr.allowance, true
+}
+
+// borrow asks live peers for allowance until delta is covered. It asks for
+// a batch larger than delta so the next increments stay local.
+func (c *Counters) borrow(ctx context.Context, name string, ctr *counter, delta int64) {
+	peers := c.peers()
+	if len(peers) == 0 {
+		return
+	}
+	batch := max(delta, ctr.limit/int64(4*(len(peers)+1)))
+
+	for _, peer := range peers {
+		c.mu.Lock()
+		need := delta - ctr.allowance
+		c.mu.Unlock()
+		if need <= 0 {
+			return
+		}
+
+		resp, err := c.transfer(ctx, peer, name, max(need, batch))
+		if err != nil {
+			// The donor may have given away allowance we never received, it
+			// is lost rather than risking the limit.
+			c.logger.Warn("Failed to borrow allowance", "counter", name, "peer", peer, "err", err)
+			continue
+		}
+
+		c.mu.Lock()
+		ctr.allowance += resp.Granted
+		c.mu.Unlock()
+	}
+}
+
+func (c *Counters) transfer(ctx context.Context, peer string, name string, amount int64) (*pb.EscrowResponse, error) {
+	conn := c.s.GetOrCreateConnection(peer)
+	if conn == nil {
+		return nil, fmt.Errorf("no connection to %s", peer)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.s.Config().RPC.Timeout)
+	defer cancel()
+	return pb.NewEscrowClient(conn).Transfer(ctx, &pb.EscrowRequest{Counter: name, Amount: amount, Requester: c.s.Id})
+}
+
+func (c *Counters) peers() []string {
+	c.s.Mu.Lock()
+	defer c.s.Mu.Unlock()
+	return arrays.Remove(c.s.Peers, c.s.Id)
+}
@@ -0,0 +1,90 @@
+package bounded_test
+
+import (
+	"discovery-service/lib/testnode"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBoundedCounterNeverExceedsLimit(t *testing.T) {
+	limits := map[string]int64{"quota": 10}
+	seed := testnode.Start(t, nil, testnode.Bounded(limits, true))
+	joined := testnode.Start(t, []string{seed.Id}, testnode.Bounded(limits, false))
+	if !testnode.Eventually(10*time.Second, func() bool { return seed.Knows(joined) && joined.Knows(seed) }) {
+		t.Fatalf("Expected the nodes to discover each other")
+	}
+
+	// The joining node starts without allowance and has to borrow it
+	for i := 0; i < 3; i++ {
+		if status := joined.Post(t, "/counters/quota/increment"); status != http.StatusOK {
+			t.Fatalf("Expected borrowed increment to succeed, got %d", status)
+		}
+	}
+
+	const numIncrements = 30
+	allowed, rejected := int64(3), int64(0)
+	done := make(chan struct{})
+	for i := 0; i < numIncrements; i++ {
+		node := seed
+		if i%2 == 0 {
+			node = joined
+		}
+		go func() {
+			defer func() { done <- struct{}{} }()
+			resp, err := http.Post(node.URL+"/counters/quota/increment", "", nil)
+			if err != nil {
+				t.Errorf("Failed to call increment API: %v", err)
+				return
+			}
+			resp.Body.Close()
+			switch resp.StatusCode {
+			case http.StatusOK:
+				atomic.AddInt64(&allowed, 1)
+			case http.StatusConflict:
+				atomic.AddInt64(&rejected, 1)
+			default:
+				t.Errorf("Unexpected status %d", resp.StatusCode)
+			}
+		}()
+	}
+	for i := 0; i < numIncrements; i++ {
+		<-done
+	}
+
+	if allowed > 10 {
+		t.Fatalf("Expected at most 10 allowed increments, got %d", allowed)
+	}
+	if allowed+rejected != numIncrements+3 {
+		t.Fatalf("Expected %d answered increments, got %d", numIncrements+3, allowed+rejected)
+	}
+
+	// Both APIs answer a used up allowance as a conflict, not a rate limit
+	resp, err := http.Post(joined.URL+"/v1/counters/quota/increment", "", nil)
+	if err != nil {
+		t.Fatalf("Failed to call increment API: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict || resp.Header.Get("Retry-After") != "" {
+		t.Fatalf("Expected an exhausted counter to answer 409, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(joined.URL + "/counters/quota")
+	if err != nil {
+		t.Fatalf("Failed to call counter API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Count int64 `json:"count"`
+		Limit int64 `json:"limit"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode counter response: %v", err)
+	}
+	if result.Count != allowed || result.Limit != 10 {
+		t.Fatalf("Expected count %d of limit 10, got %d of %d", allowed, result.Count, result.Limit)
+	}
+}
@@ -0,0 +1,210 @@
+package hll
+
+import (
+	"context"
+	"discovery-service/lib/arrays"
+	"discovery-service/models"
+	pb "discovery-service/proto"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Counters are distinct counters backed by HyperLogLog sketches. Sketches
+// changed locally are pushed to peers every flush interval and merged
+// register by register, joining nodes pull the sketches of their peers.
+type Counters struct {
+	pb.UnimplementedDistinctServer
+
+	s         *models.Server
+	logger    *slog.Logger
+	precision int
+
+	mu        sync.Mutex
+	sketches  map[string]*Sketch
+	dirty     map[string]bool // Sketches changed since the last flush
+	needsFull map[string]bool // Peers that missed a flush
+}
+
+func NewCounters(s *models.Server, precision int) (*Counters, error) {
+	if _, err := NewSketch(precision); err != nil {
+		return nil, err
+	}
+	return &Counters{
+		s:         s,
+		logger:    s.Logger("hll"),
+		precision: precision,
+		sketches:  map[string]*Sketch{},
+		dirty:     map[string]bool{},
+		needsFull: map[string]bool{},
+	}, nil
+}
+
+// Start pulls the sketches of all known peers and starts gossiping local
+// changes.
+func (c *Counters) Start() {
+	for _, peer := range c.peers() {
+		c.syncFromPeer(peer)
+	}
+
+	go func() {
+		for {
+			time.Sleep(c.s.Config().Counters.FlushInterval)
+			c.flush()
+		}
+	}()
+}
+
+// Add adds item to the distinct counter name.
+func (c *Counters) Add(name string, item string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sketch(name).Add(item) {
+		c.dirty[name] = true
+	}
+}
+
+// Cardinality returns the estimated number of distinct items added to name
+// anywhere in the cluster, and the relative standard error of the estimate.
+func (c *Counters) Cardinality(name string) (uint64, float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.sketches[name]
+	if !ok {
+		return 0, 0, false
+	}
+	return h.Estimate(), h.StdError(), true
+}
+
+func (c *Counters) Precision() int {
+	return c.precision
+}
+
+// MergeSketches merges sketches pushed by a peer.
+func (c *Counters) MergeSketches(ctx context.Context, req *pb.Sketches) (*pb.Empty, error) {
+	c.merge(req.Sketches)
+	return &pb.Empty{}, nil
+}
+
+// GetSketches returns all sketches, used by joining nodes to catch up.
+func (c *Counters) GetSketches(ctx context.Context, _ *pb.Empty) (*pb.Sketches, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resp := &pb.Sketches{}
+	for name, h := range c.sketches {
+		resp.Sketches = append(resp.Sketches, toProto(name, h))
+	}
+	return resp, nil
+}
+
+// Execute pushes all sketches to a healed peer. It is registered as a
+// heartbeat recovery action.
+func (c *Counters) Execute(s *models.Server, peer string) {
+	if s != c.s {
+		return
+	}
+	c.mu.Lock()
+	c.needsFull[peer] = true
+	c.mu.Unlock()
+}
+
+func (c *Counters) sketch(name string) *Sketch {
+	h, ok := c.sketches[name]
+	if !ok {
+		h, _ = NewSketch(c.precision)
+		c.sketches[name] = h
+	}
+	return h
+}
+
+func (c *Counters) merge(sketches []*pb.Sketch) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, sk := range sketches {
+		if int(sk.Precision) != c.precision {
+			c.logger.Warn("Ignoring sketch with another precision", "counter", sk.Counter, "precision", sk.Precision, "expected", c.precision)
+			continue
+		}
+		// Check the length before c.sketch adds the counter, so a bad
+		// sketch does not leave an empty one behind.
+		if len(sk.Registers) != 1<<c.precision {
+			c.logger.Warn("Ignoring sketch with a wrong number of registers", "counter", sk.Counter, "registers", len(sk.Registers), "expected", 1<<c.precision)
+			continue
+		}
+		// Changes learned from peers are not pushed again, every node
+		// pushes its own changes to all of its peers.
+		if _, err := c.sketch(sk.Counter).Merge(sk.Registers); err != nil {
+			c.logger.Warn("Failed to merge sketch", "counter", sk.Counter, "err", err)
+		}
+	}
+}
+
+func (c *Counters) flush() {
+	c.mu.Lock()
+	var changed, all []*pb.Sketch
+	for name, h := range c.sketches {
+		if len(c.needsFull) > 0 {
+			all = append(all, toProto(name, h))
+		}
+		if c.dirty[name] {
+			changed = append(changed, toProto(name, h))
+		}
+	}
+	c.dirty = map[string]bool{}
+	needsFull := c.needsFull
+	c.needsFull = map[string]bool{}
+	c.mu.Unlock()
+
+	for _, peer := range c.peers() {
+		batch := changed
+		if needsFull[peer] {
+			batch = all
+		}
+		if len(batch) == 0 {
+			continue
+		}
+		if err := c.push(peer, batch); err != nil {
+			c.logger.Warn("Failed to push sketches", "peer", peer, "err", err)
+			c.mu.Lock()
+			c.needsFull[peer] = true
+			c.mu.Unlock()
+		}
+	}
+}
+
+func (c *Counters) push(peer string, sketches []*pb.Sketch) error {
+	conn := c.s.GetOrCreateConnection(peer)
+	if conn == nil {
+		return fmt.Errorf("no connection to %s", peer)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.s.Config().RPC.Timeout)
+	defer cancel()
+	_, err := pb.NewDistinctClient(conn).MergeSketches(ctx, &pb.Sketches{Sketches: sketches})
+	return err
+}
+
+func (c *Counters) syncFromPeer(peer string) {
+	conn := c.s.GetOrCreateConnection(peer)
+	if conn == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.s.Config().RPC.Timeout)
+	resp, err := pb.NewDistinctClient(conn).GetSketches(ctx, &pb.Empty{})
+	cancel()
+	if err != nil {
+		c.logger.Warn("Failed to sync sketches", "peer", peer, "err", err)
+		return
+	}
+	c.merge(resp.Sketches)
+}
+
+func (c *Counters) peers() []string {
+	c.s.Mu.Lock()
+	defer c.s.Mu.Unlock()
+	return arrays.Remove(c.s.Peers, c.s.Id)
+}
+
+func toProto(name string, h *Sketch) *pb.Sketch {
+	return &pb.Sketch{Counter: name, Precision: int32(h.precision), Registers: h.Registers()}
+}
@@ -0,0 +1,23 @@
+package hll_test
+
+import (
+	"context"
+	"discovery-service/counter/hll"
+	"discovery-service/models"
+	"discovery-service/proto"
+	"testing"
+)
+
+func TestMergeIgnoresSketchOfWrongLength(t *testing.T) {
+	counters, err := hll.NewCounters(models.NewServer("localhost:1"), 10)
+	if err != nil {
+		t.Fatalf("NewCounters failed: %v", err)
+	}
+	sketch := &proto.Sketch{Counter: "visitors", Precision: 10, Registers: make([]uint8, 10)}
+	if _, err := counters.MergeSketches(context.Background(), &proto.Sketches{Sketches: []*proto.Sketch{sketch}}); err != nil {
+		t.Fatalf("MergeSketches failed: %v", err)
+	}
+	if _, _, ok := counters.Cardinality("visitors"); ok {
+		t.Fatalf("Expected a sketch of the wrong length not to create the counter")
+	}
+}
@@ -0,0 +1,113 @@
+package hll
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+const (
+	MinPrecision = 4
+	MaxPrecision = 16
+)
+
+// Sketch is a HyperLogLog sketch with 2^precision registers.
+type Sketch struct {
+	precision uint8
+	registers []uint8
+}
+
+func NewSketch(precision int) (*Sketch, error) {
+	if precision < MinPrecision || precision > MaxPrecision {
+		return nil, fmt.Errorf("precision must be between %d and %d", MinPrecision, MaxPrecision)
+	}
+	return &Sketch{precision: uint8(precision), registers: make([]uint8, 1<<precision)}, nil
+}
+
+// Add adds an item and reports whether a register changed.
+func (h *Sketch) Add(item string) bool {
+	x := hash(item)
+	idx := x >> (64 - h.precision)
+	rank := uint8(bits.LeadingZeros64(x<<h.precision|1<<(h.precision-1))) + 1
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+		return true
+	}
+	return false
+}
+
+// Merge takes the register-wise maximum with registers of the same
+// precision and reports whether any register changed.
+func (h *Sketch) Merge(registers []uint8) (bool, error) {
+	if len(registers) != len(h.registers) {
+		return false, fmt.Errorf("cannot merge %d registers into a sketch of %d", len(registers), len(h.registers))
+	}
+	changed := false
+	for i, r := range registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// Estimate returns the estimated number of distinct items.
+func (h *Sketch) Estimate() uint64 {
+	m := float64(len(h.registers))
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := alpha(m) * m * m / sum
+
+	// Linear counting is more accurate for small cardinalities.
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// StdError is the relative standard error of the estimate.
+func (h *Sketch) StdError() float64 {
+	return 1.04 / math.Sqrt(float64(len(h.registers)))
+}
+
+func (h *Sketch) Precision() int {
+	return int(h.precision)
+}
+
+func (h *Sketch) Registers() []uint8 {
+	return append([]uint8{}, h.registers...)
+}
+
+func alpha(m float64) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+	return 0.7213 / (1 + 1.079/m)
+}
+
+// hash must be identical on every node, so it cannot use a seeded hash.
+func hash(item string) uint64 {
+	f := fnv.New64a()
+	f.Write([]byte(item))
+	// splitmix64 finalizer to spread FNV's weak low bits.
+	x := f.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
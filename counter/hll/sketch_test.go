@@ -0,0 +1,53 @@
+package hll_test
+
+import (
+	"discovery-service/counter/hll"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestSketchEstimate(t *testing.T) {
+	for _, n := range []int{10, 1000, 100000} {
+		h, err := hll.NewSketch(14)
+		if err != nil {
+			t.Fatalf("NewSketch failed: %v", err)
+		}
+		for i := 0; i < n; i++ {
+			h.Add(fmt.Sprintf("user-%d", i))
+			h.Add(fmt.Sprintf("user-%d", i)) // Duplicates must not count
+		}
+
+		estimate := float64(h.Estimate())
+		if diff := math.Abs(estimate-float64(n)) / float64(n); diff > 3*h.StdError() {
+			t.Fatalf("Expected about %d distinct items, got %.0f", n, estimate)
+		}
+	}
+}
+
+func TestSketchMerge(t *testing.T) {
+	a, _ := hll.NewSketch(12)
+	b, _ := hll.NewSketch(12)
+	for i := 0; i < 5000; i++ {
+		a.Add(fmt.Sprintf("item-%d", i))
+		b.Add(fmt.Sprintf("item-%d", i+2500))
+	}
+
+	if _, err := a.Merge(b.Registers()); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	// Merging again must not change anything
+	if changed, _ := a.Merge(b.Registers()); changed {
+		t.Fatalf("Expected merge to be idempotent")
+	}
+
+	estimate := float64(a.Estimate())
+	if diff := math.Abs(estimate-7500) / 7500; diff > 3*a.StdError() {
+		t.Fatalf("Expected about 7500 distinct items after merge, got %.0f", estimate)
+	}
+
+	c, _ := hll.NewSketch(10)
+	if _, err := a.Merge(c.Registers()); err == nil {
+		t.Fatalf("Expected merging sketches of different precision to fail")
+	}
+}
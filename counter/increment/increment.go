@@ -2,13 +2,23 @@ package increment
 
 import (
 	"context"
+	"discovery-service/lib/tracing"
 	"discovery-service/models"
 	pb "discovery-service/proto"
-	"log"
-	"time"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func PropagateIncrement(s *models.Server, opID string) {
+// PropagateIncrement sends increments to every peer in the background, all
+// of them in one message per peer, queueing them for a resend to peers that
+// cannot be reached. ctx only carries the trace, propagation outlives the
+// request.
+func PropagateIncrement(ctx context.Context, s *models.Server, opIDs ...string) {
+	if len(opIDs) == 0 {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
 	peers := append([]string{}, s.Peers...)
 
 	for _, peer := range peers {
@@ -19,23 +29,36 @@ func PropagateIncrement(s *models.Server, opID string) {
 		go func(p string) {
 			conn := s.GetOrCreateConnection(peer)
 			client := pb.NewDiscoveryClient(conn)
-			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			ctx, span := tracing.Start(ctx, "increment.propagate", trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attribute.String("op.id", opIDs[0]), attribute.Int("op.count", len(opIDs)), attribute.String("peer", p)))
+			defer span.End()
+			ctx, cancel := context.WithTimeout(tracing.Inject(ctx), s.Config().RPC.Timeout)
 			defer cancel()
 
-			_, err := client.PropagateIncrement(ctx, &pb.IncrementRequest{Id: opID})
+			_, err := client.PropagateIncrement(ctx, &pb.IncrementRequest{Id: opIDs[0], MoreIds: opIDs[1:]})
 			if err != nil {
-				log.Printf("Failed to propagate increment to %s: %v", p, err)
-				queueMissedOp(s, p, opID) // <<< ADD THIS
+				span.SetStatus(codes.Error, err.Error())
+				s.Logger("increment").Warn("Failed to propagate increment", "peer", p, "ops", len(opIDs), "err", err)
+				s.Metrics.PropagationFailures.Inc(p)
+				for _, opID := range opIDs {
+					queueMissedOp(s, p, opID, span.SpanContext())
+				}
 			}
 		}(peer)
 	}
 }
 
-func queueMissedOp(s *models.Server, peer string, opID string) {
+func queueMissedOp(s *models.Server, peer string, opID string, sc trace.SpanContext) {
 	s.Mu.Lock()
 	defer s.Mu.Unlock()
 	if s.MissedOps == nil {
 		s.MissedOps = make(map[string][]string)
 	}
 	s.MissedOps[peer] = append(s.MissedOps[peer], opID)
+	if sc.IsValid() {
+		if s.MissedOpTraces == nil {
+			s.MissedOpTraces = make(map[string]trace.SpanContext)
+		}
+		s.MissedOpTraces[peer+"/"+opID] = sc
+	}
 }
@@ -1,89 +1,51 @@
 package increment_test
 
 import (
-	"discovery-service/discovery/client"
-	"discovery-service/models"
-	"discovery-service/proto"
-	"discovery-service/web"
-	"encoding/json"
-	"google.golang.org/grpc"
-	"log"
-	"net"
+	"discovery-service/lib/testnode"
 	"net/http"
 	"testing"
 	"time"
 )
 
-func startTestNode(t *testing.T, port string, initialPeers []string) {
+func startPair(t *testing.T) (*testnode.Node, *testnode.Node) {
 	t.Helper()
-
-	nodeID := "localhost:" + port
-	s := models.NewServer(nodeID)
-	client.StartClient(s, initialPeers)
-
-	lis, err := net.Listen("tcp", ":"+port)
-	if err != nil {
-		log.Fatalf("Failed to listen: %v", err)
+	node1 := testnode.Start(t, nil)
+	node2 := testnode.Start(t, []string{node1.Id})
+	if !testnode.Eventually(10*time.Second, func() bool { return node1.Knows(node2) && node2.Knows(node1) }) {
+		t.Fatalf("Expected the nodes to discover each other")
 	}
-
-	grpcServer := grpc.NewServer()
-	proto.RegisterDiscoveryServer(grpcServer, s)
-
-	log.Printf("Node %s is running...", nodeID)
-	web.StartHTTPServer(s, port)
-	grpcServer.Serve(lis)
+	return node1, node2
 }
 
 func TestRealIncrementPropagation(t *testing.T) {
-	go startTestNode(t, "8080", []string{})
-	go startTestNode(t, "8081", []string{"localhost:8080"})
-
-	// Give time for discovery and setup
-	time.Sleep(10 * time.Second)
+	node1, node2 := startPair(t)
 
 	// Make an increment request on node1
-	resp, err := http.Get("http://localhost:9080/increment")
+	resp, err := http.Post(node1.URL+"/increment", "", nil)
 	if err != nil {
 		t.Fatalf("Failed to call increment API on node1: %v", err)
 	}
 	resp.Body.Close()
 
-	resp, err = http.Get("http://localhost:9080/increment")
+	resp, err = http.Post(node1.URL+"/increment", "", nil)
 	if err != nil {
 		t.Fatalf("Failed to call increment API on node1: %v", err)
 	}
 	resp.Body.Close()
 
-	resp, err = http.Get("http://localhost:9080/increment")
+	resp, err = http.Post(node1.URL+"/increment", "", nil)
 	if err != nil {
 		t.Fatalf("Failed to call increment API on node1: %v", err)
 	}
 	resp.Body.Close()
 
-	resp, err = http.Get("http://localhost:9081/count")
-	if err != nil {
-		t.Fatalf("Failed to call count API on node2: %v", err)
-	}
-	defer resp.Body.Close()
-
-	var result struct {
-		Count int64 `json:"count"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		t.Fatalf("Failed to decode count response: %v", err)
-	}
-
-	if result.Count != 3 {
-		t.Fatalf("Expected node2 count to be 3, got %d", result.Count)
+	if !testnode.Eventually(5*time.Second, func() bool { return node2.Count(t, "/count") == 3 }) {
+		t.Fatalf("Expected node2 count to be 3, got %d", node2.Count(t, "/count"))
 	}
 }
 
 func TestParallelIncrementPropagation(t *testing.T) {
-	go startTestNode(t, "8080", []string{})
-	go startTestNode(t, "8081", []string{"localhost:8080"})
-
-	// Give time for discovery and setup
-	time.Sleep(10 * time.Second)
+	node1, node2 := startPair(t)
 
 	// Number of parallel increments you want
 	const numIncrements = 50
@@ -92,7 +54,7 @@ func TestParallelIncrementPropagation(t *testing.T) {
 	done := make(chan struct{})
 	for i := 0; i < numIncrements; i++ {
 		go func() {
-			resp, err := http.Get("http://localhost:9080/increment")
+			resp, err := http.Post(node1.URL+"/increment", "", nil)
 			if err != nil {
 				t.Errorf("Failed to call increment API: %v", err)
 				done <- struct{}{}
@@ -108,24 +70,7 @@ func TestParallelIncrementPropagation(t *testing.T) {
 		<-done
 	}
 
-	// Give a little extra time for propagation
-	time.Sleep(500 * time.Millisecond)
-
-	// Now check the count on node2
-	resp, err := http.Get("http://localhost:9081/count")
-	if err != nil {
-		t.Fatalf("Failed to call count API on node2: %v", err)
-	}
-	defer resp.Body.Close()
-
-	var result struct {
-		Count int64 `json:"count"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		t.Fatalf("Failed to decode count response: %v", err)
-	}
-
-	if result.Count != numIncrements {
-		t.Fatalf("Expected node2 count to be %d, got %d", numIncrements, result.Count)
+	if !testnode.Eventually(5*time.Second, func() bool { return node2.Count(t, "/count") == numIncrements }) {
+		t.Fatalf("Expected node2 count to be %d, got %d", numIncrements, node2.Count(t, "/count"))
 	}
 }
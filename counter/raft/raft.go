@@ -0,0 +1,794 @@
+package raft
+
+import (
+	"context"
+	"discovery-service/lib/arrays"
+	"discovery-service/models"
+	pb "discovery-service/proto"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotLeader = errors.New("not the raft leader")
+	ErrNoLeader  = errors.New("no raft leader elected")
+	ErrTimeout   = errors.New("timed out waiting for raft commit")
+)
+
+type role int
+
+const (
+	follower role = iota
+	candidate
+	leader
+)
+
+// Node replicates the strong counters through a Raft log across the peers
+// discovered by client.StartClient. The term, vote and log are saved to
+// storage before the node answers for them, the rest is rebuilt from the
+// log after a restart.
+type Node struct {
+	pb.UnimplementedRaftServer
+
+	s       *models.Server
+	logger  *slog.Logger
+	storage *storage
+	counter map[string]bool // Names of the counters replicated through raft
+
+	// Timing from the configuration the node was created with
+	heartbeatInterval time.Duration
+	electionTimeout   time.Duration // Randomized up to twice this
+	proposeTimeout    time.Duration
+	snapshotThreshold int // Compact the log once it holds this many entries
+
+	mu          sync.Mutex
+	role        role
+	term        int64
+	votedFor    string
+	leader      string
+	lastContact time.Time
+	timeout     time.Duration
+
+	log          []*pb.LogEntry // Entries after the snapshot
+	snapIndex    int64
+	snapTerm     int64
+	snapCounters map[string]int64
+	snapMembers  []string
+	snapOpIDs    []string
+
+	commitIndex int64
+	lastApplied int64
+	counters    map[string]int64
+	applied     map[string]bool // Op IDs of the applied increments, like Server.SeenOps
+
+	nextIndex  map[string]int64
+	matchIndex map[string]int64
+	inflight   map[string]bool
+	waiters    map[int64]chan result
+}
+
+type result struct {
+	value int64
+	err   error
+}
+
+// NewNode creates a raft node for the given strong counter names, restoring
+// the state saved in the configured raft directory. Only a node started
+// with bootstrap set and without saved state creates a cluster, of itself.
+// Every other node waits to be added by the leader.
+func NewNode(s *models.Server, counters []string, bootstrap bool) (*Node, error) {
+	n := &Node{
+		s:            s,
+		logger:       s.Logger("raft"),
+		counter:      map[string]bool{},
+		counters:     map[string]int64{},
+		applied:      map[string]bool{},
+		snapCounters: map[string]int64{},
+		nextIndex:    map[string]int64{},
+		matchIndex:   map[string]int64{},
+		inflight:     map[string]bool{},
+		waiters:      map[int64]chan result{},
+		lastContact:  time.Now(),
+	}
+	cfg := s.Config().Counters
+	n.heartbeatInterval, n.electionTimeout, n.proposeTimeout = cfg.RaftHeartbeat, cfg.RaftElection, cfg.RaftPropose
+	n.snapshotThreshold = cfg.RaftSnapshot
+	n.timeout = n.randomTimeout()
+	for _, c := range counters {
+		if c != "" {
+			n.counter[c] = true
+		}
+	}
+
+	st, err := newStorage(cfg.RaftDir, s.Id)
+	if err != nil {
+		return nil, err
+	}
+	n.storage = st
+	state, err := st.load()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case state != nil:
+		n.restore(state)
+		n.logger.Info("Restored raft state", "term", n.term, "entries", n.lastIndex())
+	case bootstrap:
+		n.logger.Info("Bootstrapping raft cluster", "node", s.Id)
+		n.snapMembers = []string{s.Id}
+		if err := n.persist(); err != nil {
+			return nil, fmt.Errorf("save raft state: %w", err)
+		}
+	}
+	return n, nil
+}
+
+// restore takes over saved state. Entries after the snapshot are applied
+// again once the node learns they are committed.
+func (n *Node) restore(state *pb.RaftState) {
+	n.term, n.votedFor, n.log = state.Term, state.VotedFor, state.Log
+	if snap := state.Snapshot; snap != nil {
+		n.snapIndex, n.snapTerm = snap.LastIncludedIndex, snap.LastIncludedTerm
+		n.snapCounters = copyCounters(snap.Counters)
+		n.snapMembers = snap.Members
+		n.counters = copyCounters(snap.Counters)
+		n.restoreOpIDs(snap.OpIds)
+	}
+	n.commitIndex, n.lastApplied = n.snapIndex, n.snapIndex
+}
+
+// persist saves the term, vote and log. It is called with n.mu held after
+// they change, before the node answers or sends anything based on them.
+func (n *Node) persist() error {
+	return n.storage.save(&pb.RaftState{
+		Term:     n.term,
+		VotedFor: n.votedFor,
+		Snapshot: &pb.SnapshotRequest{
+			LastIncludedIndex: n.snapIndex,
+			LastIncludedTerm:  n.snapTerm,
+			Counters:          n.snapCounters,
+			Members:           n.snapMembers,
+			OpIds:             n.snapOpIDs,
+		},
+		Log: n.log,
+	})
+}
+
+// Start runs the election and replication loop.
+func (n *Node) Start() {
+	go func() {
+		ticker := time.NewTicker(n.heartbeatInterval / 4)
+		defer ticker.Stop()
+		lastBeat := time.Time{}
+		for range ticker.C {
+			n.mu.Lock()
+			switch n.role {
+			case leader:
+				if time.Since(lastBeat) >= n.heartbeatInterval {
+					lastBeat = time.Now()
+					n.reconcileMembers()
+					n.broadcast()
+				}
+			default:
+				if time.Since(n.lastContact) >= n.timeout && arrays.Contains(n.members(), n.s.Id) {
+					n.startElection()
+				}
+			}
+			n.mu.Unlock()
+		}
+	}()
+}
+
+// Strong reports whether the named counter is replicated through raft.
+func (n *Node) Strong(name string) bool {
+	return n.counter[name]
+}
+
+// Increment adds delta to a strong counter and returns its committed value.
+// Followers forward the request to the leader, and retry it while there is
+// no leader or it changes. Retries carry the same op ID, so an increment the
+// old leader appended before losing its leadership is applied once.
+func (n *Node) Increment(ctx context.Context, name string, delta int64) (int64, error) {
+	opID := uuid.New().String()
+	deadline := time.Now().Add(n.proposeTimeout)
+	for {
+		n.mu.Lock()
+		if n.role == leader {
+			ch := n.appendLocked(&pb.LogEntry{Type: pb.EntryType_ENTRY_INCREMENT, Counter: name, Delta: delta, OpId: opID})
+			n.mu.Unlock()
+			return n.wait(ctx, ch)
+		}
+		leaderID := n.leader
+		n.mu.Unlock()
+
+		resp, err := n.forward(ctx, leaderID, func(c pb.RaftClient) (*pb.ProposeResponse, error) {
+			return c.Propose(ctx, &pb.ProposeRequest{Counter: name, Delta: delta, OpId: opID})
+		})
+		if err == nil {
+			return resp.Value, nil
+		}
+		retry := errors.Is(err, ErrNoLeader) || status.Code(err) == codes.Unavailable
+		if !retry || !time.Now().Before(deadline) {
+			return 0, err
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(n.heartbeatInterval):
+		}
+	}
+}
+
+// Get returns a linearizable read of a strong counter. The leader confirms it
+// still holds a majority before answering, followers forward to the leader.
+func (n *Node) Get(ctx context.Context, name string) (int64, error) {
+	n.mu.Lock()
+	if n.role != leader {
+		leaderID := n.leader
+		n.mu.Unlock()
+		resp, err := n.forward(ctx, leaderID, func(c pb.RaftClient) (*pb.ProposeResponse, error) {
+			return c.ReadCounter(ctx, &pb.ReadRequest{Counter: name})
+		})
+		if err != nil {
+			return 0, err
+		}
+		return resp.Value, nil
+	}
+	n.mu.Unlock()
+
+	deadline := time.Now().Add(n.proposeTimeout)
+	readIndex, err := n.readIndex(ctx, deadline)
+	if err != nil {
+		return 0, err
+	}
+	if !n.confirmLeadership() {
+		return 0, ErrNotLeader
+	}
+
+	for time.Now().Before(deadline) {
+		n.mu.Lock()
+		if n.lastApplied >= readIndex {
+			v := n.counters[name]
+			n.mu.Unlock()
+			return v, nil
+		}
+		n.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return 0, ErrTimeout
+}
+
+// readIndex returns the commit index a read must wait for. A new leader only
+// knows which entries are committed once an entry of its own term is, the
+// no-op it appends when elected, so reads wait for that first.
+func (n *Node) readIndex(ctx context.Context, deadline time.Time) (int64, error) {
+	for {
+		n.mu.Lock()
+		if n.role != leader {
+			n.mu.Unlock()
+			return 0, ErrNotLeader
+		}
+		if n.termAt(n.commitIndex) == n.term {
+			index := n.commitIndex
+			n.mu.Unlock()
+			return index, nil
+		}
+		n.mu.Unlock()
+		if !time.Now().Before(deadline) {
+			return 0, ErrTimeout
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// Leader returns the id of the current leader, if known.
+func (n *Node) Leader() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.leader
+}
+
+// Members returns the current raft membership.
+func (n *Node) Members() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string{}, n.members()...)
+}
+
+func (n *Node) forward(ctx context.Context, leaderID string, call func(pb.RaftClient) (*pb.ProposeResponse, error)) (*pb.ProposeResponse, error) {
+	if leaderID == "" {
+		return nil, ErrNoLeader
+	}
+	conn := n.s.GetOrCreateConnection(leaderID)
+	if conn == nil {
+		return nil, fmt.Errorf("connect to leader %s: %w", leaderID, ErrNoLeader)
+	}
+	return call(pb.NewRaftClient(conn))
+}
+
+func (n *Node) wait(ctx context.Context, ch chan result) (int64, error) {
+	select {
+	case r := <-ch:
+		return r.value, r.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-time.After(n.proposeTimeout):
+		return 0, ErrTimeout
+	}
+}
+
+// appendLocked appends an entry to the leader's log and returns a channel
+// that receives the result once the entry is applied.
+func (n *Node) appendLocked(e *pb.LogEntry) chan result {
+	e.Term = n.term
+	e.Index = n.lastIndex() + 1
+	n.log = append(n.log, e)
+	ch := make(chan result, 1)
+	if err := n.persist(); err != nil {
+		n.logger.Error("Failed to save raft log", "err", err)
+		n.log = n.log[:len(n.log)-1]
+		ch <- result{err: fmt.Errorf("save raft log: %w", err)}
+		return ch
+	}
+	n.matchIndex[n.s.Id] = e.Index
+
+	n.waiters[e.Index] = ch
+	n.advanceCommit()
+	n.broadcast()
+	return ch
+}
+
+func (n *Node) startElection() {
+	n.role = candidate
+	n.term++
+	n.votedFor = n.s.Id
+	n.leader = ""
+	n.lastContact = time.Now()
+	n.timeout = n.randomTimeout()
+	if err := n.persist(); err != nil {
+		// Without the vote on disk a restart could vote again in this term.
+		n.logger.Error("Failed to save raft term, not starting an election", "err", err)
+		n.role = follower
+		return
+	}
+
+	term := n.term
+	members := append([]string{}, n.members()...)
+	req := &pb.VoteRequest{
+		Term:         term,
+		CandidateId:  n.s.Id,
+		LastLogIndex: n.lastIndex(),
+		LastLogTerm:  n.termAt(n.lastIndex()),
+	}
+	n.logger.Info("Starting raft election", "term", term)
+
+	votes := 1
+	if len(members) == 1 {
+		n.becomeLeader()
+		return
+	}
+	for _, peer := range members {
+		if peer == n.s.Id {
+			continue
+		}
+		go func(p string) {
+			conn := n.s.GetOrCreateConnection(p)
+			if conn == nil {
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), n.heartbeatInterval*2)
+			resp, err := pb.NewRaftClient(conn).RequestVote(ctx, req)
+			cancel()
+			if err != nil {
+				return
+			}
+
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			if resp.Term > n.term {
+				n.stepDown(resp.Term)
+				return
+			}
+			if n.role != candidate || n.term != term || !resp.VoteGranted {
+				return
+			}
+			votes++
+			if votes > len(members)/2 {
+				n.becomeLeader()
+			}
+		}(peer)
+	}
+}
+
+func (n *Node) becomeLeader() {
+	n.logger.Info("Became raft leader", "node", n.s.Id, "term", n.term)
+	n.role = leader
+	n.leader = n.s.Id
+	for _, p := range n.members() {
+		n.nextIndex[p] = n.lastIndex() + 1
+		n.matchIndex[p] = 0
+	}
+	// Commit a no-op so entries from earlier terms become committed and
+	// reads can be served.
+	n.appendLocked(&pb.LogEntry{Type: pb.EntryType_ENTRY_NOOP})
+}
+
+func (n *Node) stepDown(term int64) {
+	if term > n.term {
+		n.term = term
+		n.votedFor = ""
+		if err := n.persist(); err != nil {
+			n.logger.Error("Failed to save raft term", "err", err)
+		}
+	}
+	if n.role == leader {
+		n.logger.Info("Stepping down as raft leader", "node", n.s.Id)
+		n.failWaiters(ErrNotLeader)
+	}
+	n.role = follower
+	n.lastContact = time.Now()
+}
+
+func (n *Node) failWaiters(err error) {
+	for idx, ch := range n.waiters {
+		ch <- result{err: err}
+		delete(n.waiters, idx)
+	}
+}
+
+// reconcileMembers follows the discovery layer: live peers that are not yet
+// members are added and members marked dead by the heartbeat monitor are
+// removed, one change at a time.
+func (n *Node) reconcileMembers() {
+	for _, e := range n.log {
+		if e.Index > n.commitIndex && e.Type == pb.EntryType_ENTRY_MEMBERSHIP {
+			return
+		}
+	}
+
+	n.s.Mu.Lock()
+	live := append([]string{}, n.s.Peers...)
+	dead := append([]string{}, n.s.DeadPeers...)
+	n.s.Mu.Unlock()
+
+	members := n.members()
+	for _, p := range live {
+		if !arrays.Contains(members, p) {
+			n.logger.Info("Adding peer to raft membership", "peer", p)
+			n.nextIndex[p] = n.lastIndex() + 1
+			n.appendLocked(&pb.LogEntry{Type: pb.EntryType_ENTRY_MEMBERSHIP, Members: append(append([]string{}, members...), p)})
+			return
+		}
+	}
+	for _, p := range members {
+		if p != n.s.Id && arrays.Contains(dead, p) && !arrays.Contains(live, p) {
+			n.logger.Info("Removing peer from raft membership", "peer", p)
+			n.appendLocked(&pb.LogEntry{Type: pb.EntryType_ENTRY_MEMBERSHIP, Members: arrays.Remove(members, p)})
+			return
+		}
+	}
+}
+
+// confirmLeadership sends a round of heartbeats and reports whether a
+// majority of members still accepts this node as leader.
+func (n *Node) confirmLeadership() bool {
+	n.mu.Lock()
+	members := append([]string{}, n.members()...)
+	term := n.term
+	n.mu.Unlock()
+
+	acks := make(chan bool, len(members))
+	for _, peer := range members {
+		if peer == n.s.Id {
+			acks <- true
+			continue
+		}
+		go func(p string) {
+			n.mu.Lock()
+			req := n.appendRequest(p)
+			n.mu.Unlock()
+			if req == nil {
+				acks <- false
+				return
+			}
+			// An empty append at index zero only checks the term.
+			req.Entries = nil
+			req.PrevLogIndex, req.PrevLogTerm, req.LeaderCommit = 0, 0, 0
+			resp, err := n.sendAppend(p, req)
+			acks <- err == nil && resp.Term == term
+		}(peer)
+	}
+
+	granted := 0
+	for range members {
+		if <-acks {
+			granted++
+		}
+		if granted > len(members)/2 {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Node) broadcast() {
+	for _, peer := range n.members() {
+		if peer == n.s.Id || n.inflight[peer] {
+			continue
+		}
+		n.inflight[peer] = true
+		go n.replicate(peer)
+	}
+}
+
+func (n *Node) replicate(peer string) {
+	defer func() {
+		n.mu.Lock()
+		n.inflight[peer] = false
+		n.mu.Unlock()
+	}()
+
+	n.mu.Lock()
+	if n.role != leader {
+		n.mu.Unlock()
+		return
+	}
+	if n.nextIndex[peer] <= n.snapIndex {
+		req := &pb.SnapshotRequest{
+			Term:              n.term,
+			LeaderId:          n.s.Id,
+			LastIncludedIndex: n.snapIndex,
+			LastIncludedTerm:  n.snapTerm,
+			Counters:          copyCounters(n.snapCounters),
+			Members:           append([]string{}, n.snapMembers...),
+			OpIds:             n.snapOpIDs,
+		}
+		n.mu.Unlock()
+		n.sendSnapshot(peer, req)
+		return
+	}
+	req := n.appendRequest(peer)
+	n.mu.Unlock()
+
+	resp, err := n.sendAppend(peer, req)
+	if err != nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if resp.Term > n.term {
+		n.stepDown(resp.Term)
+		return
+	}
+	if n.role != leader || n.term != req.Term {
+		return
+	}
+	if resp.Success {
+		match := req.PrevLogIndex + int64(len(req.Entries))
+		if match > n.matchIndex[peer] {
+			n.matchIndex[peer] = match
+		}
+		n.nextIndex[peer] = match + 1
+		n.advanceCommit()
+		return
+	}
+	next := resp.ConflictIndex
+	if next < 1 {
+		next = 1
+	}
+	n.nextIndex[peer] = next
+}
+
+func (n *Node) appendRequest(peer string) *pb.AppendEntriesRequest {
+	next := n.nextIndex[peer]
+	if next == 0 {
+		next = n.lastIndex() + 1
+		n.nextIndex[peer] = next
+	}
+	if next <= n.snapIndex {
+		return nil
+	}
+	prev := next - 1
+	var entries []*pb.LogEntry
+	for _, e := range n.log {
+		if e.Index >= next {
+			entries = append(entries, e)
+		}
+	}
+	return &pb.AppendEntriesRequest{
+		Term:         n.term,
+		LeaderId:     n.s.Id,
+		PrevLogIndex: prev,
+		PrevLogTerm:  n.termAt(prev),
+		Entries:      entries,
+		LeaderCommit: n.commitIndex,
+	}
+}
+
+func (n *Node) sendAppend(peer string, req *pb.AppendEntriesRequest) (*pb.AppendEntriesResponse, error) {
+	conn := n.s.GetOrCreateConnection(peer)
+	if conn == nil {
+		return nil, fmt.Errorf("no connection to %s", peer)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), n.s.Config().RPC.Timeout)
+	defer cancel()
+	return pb.NewRaftClient(conn).AppendEntries(ctx, req)
+}
+
+func (n *Node) sendSnapshot(peer string, req *pb.SnapshotRequest) {
+	conn := n.s.GetOrCreateConnection(peer)
+	if conn == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), n.s.Config().RPC.Timeout)
+	resp, err := pb.NewRaftClient(conn).InstallSnapshot(ctx, req)
+	cancel()
+	if err != nil {
+		n.logger.Warn("Failed to send raft snapshot", "peer", peer, "err", err)
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if resp.Term > n.term {
+		n.stepDown(resp.Term)
+		return
+	}
+	n.matchIndex[peer] = req.LastIncludedIndex
+	n.nextIndex[peer] = req.LastIncludedIndex + 1
+}
+
+// advanceCommit moves the commit index to the highest entry of the current
+// term stored on a majority of members and applies it.
+func (n *Node) advanceCommit() {
+	members := n.members()
+	for idx := n.lastIndex(); idx > n.commitIndex; idx-- {
+		if n.termAt(idx) != n.term {
+			break
+		}
+		count := 0
+		for _, p := range members {
+			if p == n.s.Id || n.matchIndex[p] >= idx {
+				count++
+			}
+		}
+		if count > len(members)/2 {
+			n.commitIndex = idx
+			break
+		}
+	}
+	n.applyCommitted()
+}
+
+func (n *Node) applyCommitted() {
+	for n.lastApplied < n.commitIndex {
+		n.lastApplied++
+		e := n.entryAt(n.lastApplied)
+		if e == nil {
+			continue
+		}
+		var value int64
+		if e.Type == pb.EntryType_ENTRY_INCREMENT {
+			// A retried increment answers with the count, like the first.
+			if e.OpId == "" || !n.applied[e.OpId] {
+				n.counters[e.Counter] += e.Delta
+			}
+			if e.OpId != "" {
+				n.applied[e.OpId] = true
+			}
+			value = n.counters[e.Counter]
+		}
+		if ch, ok := n.waiters[e.Index]; ok {
+			ch <- result{value: value}
+			delete(n.waiters, e.Index)
+		}
+	}
+	if len(n.log) >= n.snapshotThreshold {
+		n.compact()
+	}
+}
+
+// compact replaces the applied prefix of the log with a snapshot of the
+// counters.
+func (n *Node) compact() {
+	members := n.membersAt(n.lastApplied)
+	n.snapTerm = n.termAt(n.lastApplied)
+	n.snapCounters = copyCounters(n.counters)
+	n.snapMembers = append([]string{}, members...)
+	n.snapOpIDs = make([]string, 0, len(n.applied))
+	for id := range n.applied {
+		n.snapOpIDs = append(n.snapOpIDs, id)
+	}
+
+	var rest []*pb.LogEntry
+	for _, e := range n.log {
+		if e.Index > n.lastApplied {
+			rest = append(rest, e)
+		}
+	}
+	n.log = rest
+	n.snapIndex = n.lastApplied
+	// The saved state without the compaction is still valid, only longer.
+	if err := n.persist(); err != nil {
+		n.logger.Warn("Failed to save compacted raft log", "err", err)
+	}
+	n.logger.Debug("Compacted raft log", "index", n.snapIndex)
+}
+
+func (n *Node) lastIndex() int64 {
+	if len(n.log) == 0 {
+		return n.snapIndex
+	}
+	return n.log[len(n.log)-1].Index
+}
+
+func (n *Node) entryAt(idx int64) *pb.LogEntry {
+	pos := idx - n.snapIndex - 1
+	if pos < 0 || pos >= int64(len(n.log)) {
+		return nil
+	}
+	return n.log[pos]
+}
+
+func (n *Node) termAt(idx int64) int64 {
+	if idx == n.snapIndex {
+		return n.snapTerm
+	}
+	if e := n.entryAt(idx); e != nil {
+		return e.Term
+	}
+	return 0
+}
+
+// members returns the latest membership in the log, which takes effect as
+// soon as it is appended.
+func (n *Node) members() []string {
+	return n.membersAt(n.lastIndex())
+}
+
+func (n *Node) membersAt(idx int64) []string {
+	for i := len(n.log) - 1; i >= 0; i-- {
+		e := n.log[i]
+		if e.Index <= idx && e.Type == pb.EntryType_ENTRY_MEMBERSHIP {
+			return e.Members
+		}
+	}
+	return n.snapMembers
+}
+
+func (n *Node) randomTimeout() time.Duration {
+	return n.electionTimeout + time.Duration(rand.Int63n(int64(n.electionTimeout)))
+}
+
+// restoreOpIDs replaces the applied op IDs with those of a snapshot.
+func (n *Node) restoreOpIDs(ids []string) {
+	n.snapOpIDs = ids
+	n.applied = make(map[string]bool, len(ids))
+	for _, id := range ids {
+		n.applied[id] = true
+	}
+}
+
+func copyCounters(m map[string]int64) map[string]int64 {
+	out := make(map[string]int64, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,118 @@
+package raft_test
+
+import (
+	"context"
+	"discovery-service/counter/raft"
+	"discovery-service/lib/testnode"
+	"discovery-service/models"
+	pb "discovery-service/proto"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStrongCounterReplication(t *testing.T) {
+	first := testnode.Start(t, nil, testnode.Raft(t, t.TempDir(), []string{"ids"}, true))
+	leader := first.Strong.(*raft.Node)
+	if !testnode.Eventually(5*time.Second, func() bool { return leader.Leader() == first.Id }) {
+		t.Fatalf("Expected the bootstrapped node to become leader")
+	}
+	second := testnode.Start(t, []string{first.Id}, testnode.Raft(t, t.TempDir(), []string{"ids"}, false))
+	third := testnode.Start(t, []string{first.Id}, testnode.Raft(t, t.TempDir(), []string{"ids"}, false))
+
+	// The leader adds both followers
+	if !testnode.Eventually(10*time.Second, func() bool { return len(leader.Members()) == 3 }) {
+		t.Fatalf("Expected the leader to add both followers, got %v", leader.Members())
+	}
+
+	// Increments on followers are forwarded to the leader
+	for _, node := range []*testnode.Node{first, second, third, second} {
+		if status := node.Post(t, "/counters/ids/increment"); status != http.StatusOK {
+			t.Fatalf("Expected the increment on %s to succeed, got %d", node.Id, status)
+		}
+	}
+
+	for _, node := range []*testnode.Node{first, second, third} {
+		if count := node.Count(t, "/counters/ids"); count != 4 {
+			t.Fatalf("Expected node %s to read 4, got %d", node.Id, count)
+		}
+	}
+
+	if status := second.Post(t, "/counters/other/increment"); status != http.StatusNotFound {
+		t.Fatalf("Expected 404 for a counter not replicated through raft, got %d", status)
+	}
+}
+
+func TestRaftStateSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	newNode := func(bootstrap bool) *raft.Node {
+		s := models.NewServer("localhost:1")
+		cfg := *s.Config()
+		cfg.Counters.RaftDir = dir
+		s.SetConfig(&cfg)
+		node, err := raft.NewNode(s, []string{"ids"}, bootstrap)
+		if err != nil {
+			t.Fatalf("Failed to create raft node: %v", err)
+		}
+		return node
+	}
+
+	first := newNode(true)
+	first.Start()
+	if !testnode.Eventually(5*time.Second, func() bool { return first.Leader() == "localhost:1" }) {
+		t.Fatalf("Expected the bootstrapped node to become leader")
+	}
+	ctx := context.Background()
+	for range 3 {
+		if _, err := first.Increment(ctx, "ids", 1); err != nil {
+			t.Fatalf("Increment failed: %v", err)
+		}
+	}
+
+	// The node voted for itself in term 1, so after a restart it must not
+	// vote for anyone else in that term.
+	restarted := newNode(false)
+	vote, err := restarted.RequestVote(ctx, &pb.VoteRequest{Term: 1, CandidateId: "localhost:2", LastLogIndex: 100, LastLogTerm: 1})
+	if err != nil || vote.VoteGranted {
+		t.Fatalf("Expected a second vote in term 1 to be refused, got %v %v", vote, err)
+	}
+
+	// Without --raft-bootstrap it is still the only member and leads again
+	// with the increments committed before the restart.
+	restarted.Start()
+	if !testnode.Eventually(5*time.Second, func() bool { return restarted.Leader() == "localhost:1" }) {
+		t.Fatalf("Expected the restarted node to lead again")
+	}
+	if count, err := restarted.Get(ctx, "ids"); err != nil || count != 3 {
+		t.Fatalf("Expected the restarted node to read 3, got %d %v", count, err)
+	}
+}
+
+func TestProposeAppliesOpOnce(t *testing.T) {
+	s := models.NewServer("localhost:1")
+	cfg := *s.Config()
+	cfg.Counters.RaftDir = t.TempDir()
+	s.SetConfig(&cfg)
+	node, err := raft.NewNode(s, []string{"ids"}, true)
+	if err != nil {
+		t.Fatalf("Failed to create raft node: %v", err)
+	}
+	node.Start()
+	if !testnode.Eventually(5*time.Second, func() bool { return node.Leader() == "localhost:1" }) {
+		t.Fatalf("Expected the bootstrapped node to become leader")
+	}
+
+	// A follower retries a forwarded increment with the same op ID when the
+	// answer is lost, which must not count it twice.
+	ctx := context.Background()
+	req := &pb.ProposeRequest{Counter: "ids", Delta: 1, OpId: "op-1"}
+	for range 2 {
+		resp, err := node.Propose(ctx, req)
+		if err != nil || resp.Value != 1 {
+			t.Fatalf("Expected the proposal to answer 1, got %v %v", resp, err)
+		}
+	}
+	if count, err := node.Get(ctx, "ids"); err != nil || count != 1 {
+		t.Fatalf("Expected the retried increment to count once, got %d %v", count, err)
+	}
+}
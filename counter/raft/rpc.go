@@ -0,0 +1,191 @@
+package raft
+
+import (
+	"context"
+	pb "discovery-service/proto"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+// RequestVote grants a vote if the candidate's log is at least as up to date
+// as ours and we have not voted for anyone else this term.
+func (n *Node) RequestVote(ctx context.Context, req *pb.VoteRequest) (*pb.VoteResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if req.Term < n.term {
+		return &pb.VoteResponse{Term: n.term}, nil
+	}
+	// Ignore candidates while we still hear from a leader, so a member that
+	// was cut off cannot force a healthy leader to step down.
+	if n.role == leader || (n.leader != "" && time.Since(n.lastContact) < n.electionTimeout) {
+		return &pb.VoteResponse{Term: n.term}, nil
+	}
+	if req.Term > n.term {
+		n.stepDown(req.Term)
+		n.leader = ""
+	}
+
+	lastTerm := n.termAt(n.lastIndex())
+	upToDate := req.LastLogTerm > lastTerm || (req.LastLogTerm == lastTerm && req.LastLogIndex >= n.lastIndex())
+	if (n.votedFor == "" || n.votedFor == req.CandidateId) && upToDate {
+		n.votedFor = req.CandidateId
+		if err := n.persist(); err != nil {
+			n.votedFor = ""
+			return nil, status.Errorf(codes.Unavailable, "save raft vote: %v", err)
+		}
+		n.lastContact = time.Now()
+		return &pb.VoteResponse{Term: n.term, VoteGranted: true}, nil
+	}
+	return &pb.VoteResponse{Term: n.term}, nil
+}
+
+// AppendEntries replicates leader entries into the local log.
+func (n *Node) AppendEntries(ctx context.Context, req *pb.AppendEntriesRequest) (*pb.AppendEntriesResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if req.Term < n.term {
+		return &pb.AppendEntriesResponse{Term: n.term}, nil
+	}
+	if req.Term > n.term || n.role != follower {
+		n.stepDown(req.Term)
+	}
+	if n.leader != req.LeaderId {
+		n.logger.Info("Following raft leader", "leader", req.LeaderId, "term", req.Term)
+	}
+	n.leader = req.LeaderId
+	n.lastContact = time.Now()
+
+	if req.PrevLogIndex > n.lastIndex() {
+		return &pb.AppendEntriesResponse{Term: n.term, ConflictIndex: n.lastIndex() + 1}, nil
+	}
+	if req.PrevLogIndex >= n.snapIndex && n.termAt(req.PrevLogIndex) != req.PrevLogTerm {
+		// Skip back over the whole conflicting term.
+		conflictTerm := n.termAt(req.PrevLogIndex)
+		idx := req.PrevLogIndex
+		for idx > n.snapIndex+1 && n.termAt(idx-1) == conflictTerm {
+			idx--
+		}
+		return &pb.AppendEntriesResponse{Term: n.term, ConflictIndex: idx}, nil
+	}
+
+	changed := false
+	for _, e := range req.Entries {
+		if e.Index <= n.snapIndex {
+			continue
+		}
+		if existing := n.entryAt(e.Index); existing != nil {
+			if existing.Term == e.Term {
+				continue
+			}
+			n.log = n.log[:e.Index-n.snapIndex-1]
+		}
+		n.log = append(n.log, e)
+		changed = true
+	}
+	if changed {
+		if err := n.persist(); err != nil {
+			return nil, status.Errorf(codes.Unavailable, "save raft log: %v", err)
+		}
+	}
+
+	lastNew := req.PrevLogIndex + int64(len(req.Entries))
+	if commit := min(req.LeaderCommit, lastNew); commit > n.commitIndex {
+		n.commitIndex = commit
+		n.applyCommitted()
+	}
+	return &pb.AppendEntriesResponse{Term: n.term, Success: true}, nil
+}
+
+// InstallSnapshot replaces the local state with the leader's snapshot.
+func (n *Node) InstallSnapshot(ctx context.Context, req *pb.SnapshotRequest) (*pb.SnapshotResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if req.Term < n.term {
+		return &pb.SnapshotResponse{Term: n.term}, nil
+	}
+	if req.Term > n.term || n.role != follower {
+		n.stepDown(req.Term)
+	}
+	n.leader = req.LeaderId
+	n.lastContact = time.Now()
+
+	if req.LastIncludedIndex <= n.snapIndex {
+		return &pb.SnapshotResponse{Term: n.term}, nil
+	}
+
+	// Keep any entries following the snapshot if our log agrees with it.
+	var rest []*pb.LogEntry
+	if e := n.entryAt(req.LastIncludedIndex); e != nil && e.Term == req.LastIncludedTerm {
+		for _, e := range n.log {
+			if e.Index > req.LastIncludedIndex {
+				rest = append(rest, e)
+			}
+		}
+	}
+	n.log = rest
+	n.snapIndex = req.LastIncludedIndex
+	n.snapTerm = req.LastIncludedTerm
+	n.snapCounters = copyCounters(req.Counters)
+	n.snapMembers = append([]string{}, req.Members...)
+	n.counters = copyCounters(req.Counters)
+	n.restoreOpIDs(req.OpIds)
+	n.commitIndex = max(n.commitIndex, req.LastIncludedIndex)
+	n.lastApplied = req.LastIncludedIndex
+	if err := n.persist(); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "save raft snapshot: %v", err)
+	}
+	n.applyCommitted()
+
+	n.logger.Info("Installed raft snapshot", "index", req.LastIncludedIndex, "leader", req.LeaderId)
+	return &pb.SnapshotResponse{Term: n.term}, nil
+}
+
+// Propose applies an increment forwarded by a follower. An increment with
+// an op ID that was applied already is not applied again.
+func (n *Node) Propose(ctx context.Context, req *pb.ProposeRequest) (*pb.ProposeResponse, error) {
+	n.mu.Lock()
+	if n.role != leader {
+		leaderID := n.leader
+		n.mu.Unlock()
+		return nil, status.Errorf(codes.Unavailable, "%v (leader is %q)", ErrNotLeader, leaderID)
+	}
+	ch := n.appendLocked(&pb.LogEntry{Type: pb.EntryType_ENTRY_INCREMENT, Counter: req.Counter, Delta: req.Delta, OpId: req.OpId})
+	n.mu.Unlock()
+
+	value, err := n.wait(ctx, ch)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ProposeResponse{Value: value, Leader: n.s.Id}, nil
+}
+
+// ReadCounter serves a linearizable read forwarded by a follower.
+func (n *Node) ReadCounter(ctx context.Context, req *pb.ReadRequest) (*pb.ProposeResponse, error) {
+	n.mu.Lock()
+	isLeader := n.role == leader
+	leaderID := n.leader
+	n.mu.Unlock()
+	if !isLeader {
+		return nil, status.Errorf(codes.Unavailable, "%v (leader is %q)", ErrNotLeader, leaderID)
+	}
+
+	value, err := n.Get(ctx, req.Counter)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ProposeResponse{Value: value, Leader: n.s.Id}, nil
+}
+
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+}
@@ -0,0 +1,77 @@
+package raft
+
+import (
+	pb "discovery-service/proto"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// storage keeps the state raft needs across restarts in one file: the term
+// and vote, so a restarted node cannot vote twice in a term, and the log.
+// The file is replaced as a whole, which is cheap as the log is compacted
+// at counters.raft_snapshot entries.
+type storage struct {
+	file string
+}
+
+func newStorage(dir string, id string) (*storage, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create raft directory: %w", err)
+	}
+	name := strings.NewReplacer(":", "_", "/", "_").Replace(id)
+	return &storage{file: filepath.Join(dir, "raft-"+name+".state")}, nil
+}
+
+// load returns the saved state, nil if there is none.
+func (st *storage) load() (*pb.RaftState, error) {
+	data, err := os.ReadFile(st.file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := &pb.RaftState{}
+	if err := proto.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", st.file, err)
+	}
+	return state, nil
+}
+
+// save writes state and syncs it to disk, so it survives a crash once save
+// returns.
+func (st *storage) save(state *pb.RaftState) error {
+	data, err := proto.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp := st.file + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, st.file); err != nil {
+		return err
+	}
+	dir, err := os.Open(filepath.Dir(st.file))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"context"
+	"discovery-service/models"
+	pb "discovery-service/proto"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prefix keeps rate limit keys apart from the windowed counters served under
+// /counters.
+const Prefix = "ratelimit:"
+
+// Reserved reports whether name is the counter of a rate limit key, which
+// clients may only count through Check so they cannot raise or read the
+// limits of others.
+func Reserved(name string) bool {
+	return strings.HasPrefix(name, Prefix)
+}
+
+var ErrDisabled = errors.New("windowed counters are disabled")
+
+// Result is the outcome of a rate limit check.
+type Result struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	Reset      time.Duration // Until the oldest counted request leaves the window
+	RetryAfter time.Duration // Until a denied request would be allowed
+}
+
+// checkMu makes check-and-count atomic on this node. Across nodes the counts
+// are only as fresh as the last bucket gossip, so a cluster can briefly admit
+// more than limit requests.
+var checkMu sync.Mutex
+
+// Check counts cost against key if the cluster-wide count over the sliding
+// window leaves room for it.
+func Check(c models.WindowedCounters, key string, limit int64, window time.Duration, cost int64) (Result, error) {
+	if c == nil {
+		return Result{}, ErrDisabled
+	}
+	if key == "" {
+		return Result{}, fmt.Errorf("key is required")
+	}
+	if limit <= 0 {
+		return Result{}, fmt.Errorf("limit must be positive")
+	}
+	if cost <= 0 || cost > limit {
+		return Result{}, fmt.Errorf("cost must be between 1 and the limit")
+	}
+
+	checkMu.Lock()
+	defer checkMu.Unlock()
+
+	first, counts, err := c.Buckets(Prefix+key, window)
+	if err != nil {
+		return Result{}, err
+	}
+	var used int64
+	for _, n := range counts {
+		used += n
+	}
+
+	now := time.Now()
+	// Bucket i stops counting once the window no longer covers its start.
+	expires := func(i int) time.Duration {
+		return max(0, first.Add(time.Duration(i)*time.Second+window).Sub(now))
+	}
+
+	res := Result{Limit: limit}
+	if used+cost <= limit {
+		c.Increment(Prefix+key, cost)
+		used += cost
+		res.Allowed = true
+	} else {
+		freed := int64(0)
+		for i, n := range counts {
+			freed += n
+			if used-freed+cost <= limit {
+				res.RetryAfter = expires(i)
+				break
+			}
+		}
+	}
+	res.Remaining = max(0, limit-used)
+
+	for i, n := range counts {
+		if n > 0 {
+			res.Reset = expires(i)
+			break
+		}
+	}
+	if res.Allowed && res.Reset == 0 {
+		res.Reset = expires(len(counts) - 1)
+	}
+	return res, nil
+}
+
+// Service serves rate limit checks over gRPC.
+type Service struct {
+	pb.UnimplementedRateLimiterServer
+	s *models.Server
+}
+
+func NewService(s *models.Server) *Service {
+	return &Service{s: s}
+}
+
+func (svc *Service) CheckRateLimit(ctx context.Context, req *pb.RateLimitRequest) (*pb.RateLimitResponse, error) {
+	cost := req.Cost
+	if cost == 0 {
+		cost = 1
+	}
+	res, err := Check(svc.s.Windowed, req.Key, req.Limit, time.Duration(req.WindowSeconds)*time.Second, cost)
+	if errors.Is(err, ErrDisabled) {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &pb.RateLimitResponse{
+		Allowed:           res.Allowed,
+		Limit:             res.Limit,
+		Remaining:         res.Remaining,
+		ResetSeconds:      Seconds(res.Reset),
+		RetryAfterSeconds: Seconds(res.RetryAfter),
+	}, nil
+}
+
+// Seconds rounds d up to whole seconds, as used by the RateLimit headers.
+func Seconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,45 @@
+package ratelimit_test
+
+import (
+	"discovery-service/counter/ratelimit"
+	"discovery-service/counter/window"
+	"discovery-service/models"
+	"testing"
+	"time"
+)
+
+func TestCheckDeniesOverLimit(t *testing.T) {
+	s := models.NewServer("localhost:8097")
+	s.Windowed = window.NewCounters(s, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		res, err := ratelimit.Check(s.Windowed, "tenant", 3, 10*time.Second, 1)
+		if err != nil {
+			t.Fatalf("Check failed: %v", err)
+		}
+		if !res.Allowed || res.Remaining != int64(2-i) {
+			t.Fatalf("Expected request %d to be allowed with %d remaining, got %+v", i, 2-i, res)
+		}
+		if res.Reset <= 0 || res.Reset > 10*time.Second {
+			t.Fatalf("Expected reset within the window, got %s", res.Reset)
+		}
+	}
+
+	res, err := ratelimit.Check(s.Windowed, "tenant", 3, 10*time.Second, 1)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if res.Allowed || res.Remaining != 0 || res.RetryAfter <= 0 {
+		t.Fatalf("Expected request over the limit to be denied with a retry hint, got %+v", res)
+	}
+
+	// Other keys have their own budget
+	res, err = ratelimit.Check(s.Windowed, "other", 3, 10*time.Second, 1)
+	if err != nil || !res.Allowed {
+		t.Fatalf("Expected other key to be allowed, got %+v, %v", res, err)
+	}
+
+	if _, err := ratelimit.Check(s.Windowed, "tenant", 3, 10*time.Second, 4); err == nil {
+		t.Fatalf("Expected a cost above the limit to be rejected")
+	}
+}
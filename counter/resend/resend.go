@@ -3,10 +3,12 @@ package resend
 import (
 	"context"
 	"discovery-service/lib/arrays"
+	"discovery-service/lib/tracing"
 	"discovery-service/models"
 	"discovery-service/proto"
-	"log"
-	"time"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Resend struct {
@@ -21,24 +23,40 @@ func (r Resend) Execute(s *models.Server, peer string) {
 		return
 	}
 
-	log.Printf("Resending %d missed ops to %s", len(opIDs), peer)
+	s.Logger("resend").Info("Resending missed ops", "peer", peer, "ops", len(opIDs))
 
 	conn := s.GetOrCreateConnection(peer)
 	client := proto.NewDiscoveryClient(conn)
 
 	for _, opID := range opIDs {
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		// Replays start a new trace linked to the one of the missed
+		// propagation.
+		s.Mu.Lock()
+		origin := s.MissedOpTraces[peer+"/"+opID]
+		s.Mu.Unlock()
+		opts := []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.String("op.id", opID), attribute.String("peer", peer))}
+		if origin.IsValid() {
+			opts = append(opts, trace.WithLinks(trace.Link{SpanContext: origin}))
+		}
+		ctx, span := tracing.Start(context.Background(), "increment.replay", opts...)
+
+		ctx, cancel := context.WithTimeout(tracing.Inject(ctx), s.Config().RPC.Timeout)
 		_, err := client.PropagateIncrement(ctx, &proto.IncrementRequest{Id: opID})
 		cancel()
 
 		if err != nil {
-			log.Printf("Failed to resend opID %s to %s: %v", opID, peer, err)
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
+			s.Logger("resend").Warn("Failed to resend op", "op", opID, "peer", peer, "err", err)
 			continue
 		}
+		span.End()
 
 		// On success, remove opID
 		s.Mu.Lock()
 		s.MissedOps[peer] = arrays.Remove(s.MissedOps[peer], opID)
+		delete(s.MissedOpTraces, peer+"/"+opID)
 		s.Mu.Unlock()
 	}
 }
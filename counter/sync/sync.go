@@ -4,18 +4,16 @@ import (
 	"context"
 	"discovery-service/models"
 	"discovery-service/proto"
-	"log"
-	"time"
 )
 
 func SyncCounterFromPeer(s *models.Server, client proto.DiscoveryClient, peer string) {
-	log.Printf("Syncing counter from peer: %s", peer)
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	s.Logger("sync").Info("Syncing counter from peer", "peer", peer)
+	ctx, cancel := context.WithTimeout(context.Background(), s.Config().RPC.Timeout)
 	resp, err := client.GetCounter(ctx, &proto.Empty{})
 	cancel()
 
 	if err != nil {
-		log.Printf("Failed to get counter from %s: %v", peer, err)
+		s.Logger("sync").Warn("Failed to get counter", "peer", peer, "err", err)
 		return
 	}
 
@@ -25,6 +23,6 @@ func SyncCounterFromPeer(s *models.Server, client proto.DiscoveryClient, peer st
 	// Take the maximum of local counter and the counter from the peer
 	if resp.Counter > s.Counter {
 		s.Counter = resp.Counter
-		log.Printf("Updated counter to %d after syncing with %s", s.Counter, peer)
+		s.Logger("sync").Info("Updated counter after sync", "peer", peer, "counter", s.Counter)
 	}
 }
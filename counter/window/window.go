@@ -0,0 +1,246 @@
+package window
+
+import (
+	"context"
+	"discovery-service/lib/arrays"
+	"discovery-service/models"
+	pb "discovery-service/proto"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const BucketSize = time.Second
+
+type bucketKey struct {
+	counter string
+	node    string
+	start   int64
+}
+
+// Counters are windowed rate counters. Every node counts its own increments
+// in one second buckets and gossips them to its peers, which keep the
+// maximum they have seen per node and bucket, so merging is idempotent like
+// the sync of the main counter. Buckets older than the retention are dropped.
+type Counters struct {
+	pb.UnimplementedWindowServer
+
+	s         *models.Server
+	logger    *slog.Logger
+	retention time.Duration
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]int64
+	dirty     map[bucketKey]bool // Own buckets changed since the last flush
+	needsFull map[string]bool    // Peers that missed a flush
+}
+
+// NewCounters creates windowed counters keeping retention worth of buckets.
+func NewCounters(s *models.Server, retention time.Duration) *Counters {
+	return &Counters{
+		s:         s,
+		logger:    s.Logger("window"),
+		retention: retention,
+		buckets:   map[bucketKey]int64{},
+		dirty:     map[bucketKey]bool{},
+		needsFull: map[string]bool{},
+	}
+}
+
+// Start pulls the buckets of all known peers and starts gossiping local
+// increments.
+func (c *Counters) Start() {
+	for _, peer := range c.peers() {
+		c.syncFromPeer(peer)
+	}
+
+	go func() {
+		for {
+			time.Sleep(c.s.Config().Counters.FlushInterval)
+			c.flush()
+		}
+	}()
+}
+
+// Retention is the largest window that can be queried.
+func (c *Counters) Retention() time.Duration {
+	return c.retention
+}
+
+// Increment records delta in the current bucket of this node.
+func (c *Counters) Increment(name string, delta int64) {
+	key := bucketKey{counter: name, node: c.s.Id, start: bucketStart(time.Now())}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.buckets[key] += delta
+	c.dirty[key] = true
+}
+
+// Count returns the cluster-wide count of name over the last window.
+func (c *Counters) Count(name string, window time.Duration) (int64, error) {
+	if window < BucketSize || window > c.retention {
+		return 0, fmt.Errorf("window must be between %s and %s", BucketSize, c.retention)
+	}
+	since := bucketStart(time.Now().Add(-window)) + 1
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var total int64
+	for key, count := range c.buckets {
+		if key.counter == name && key.start >= since {
+			total += count
+		}
+	}
+	return total, nil
+}
+
+// Buckets returns the cluster-wide counts of name for every bucket in the
+// last window, oldest first, and the start of the oldest bucket.
+func (c *Counters) Buckets(name string, window time.Duration) (time.Time, []int64, error) {
+	if window < BucketSize || window > c.retention {
+		return time.Time{}, nil, fmt.Errorf("window must be between %s and %s", BucketSize, c.retention)
+	}
+	n := int64((window + BucketSize - 1) / BucketSize)
+	first := bucketStart(time.Now()) - n + 1
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := make([]int64, n)
+	for key, count := range c.buckets {
+		// Peers with a clock ahead of ours can report future buckets.
+		if key.counter == name && key.start >= first && key.start < first+n {
+			counts[key.start-first] += count
+		}
+	}
+	return time.Unix(first, 0), counts, nil
+}
+
+// MergeBuckets merges buckets pushed by a peer.
+func (c *Counters) MergeBuckets(ctx context.Context, req *pb.RateBuckets) (*pb.Empty, error) {
+	c.merge(req.Buckets)
+	return &pb.Empty{}, nil
+}
+
+// GetBuckets returns every bucket this node knows about, used by joining
+// nodes to catch up.
+func (c *Counters) GetBuckets(ctx context.Context, _ *pb.Empty) (*pb.RateBuckets, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resp := &pb.RateBuckets{}
+	for key, count := range c.buckets {
+		resp.Buckets = append(resp.Buckets, toProto(key, count))
+	}
+	return resp, nil
+}
+
+// Execute pushes all local buckets to a healed peer. It is registered as a
+// heartbeat recovery action.
+func (c *Counters) Execute(s *models.Server, peer string) {
+	if s != c.s {
+		return
+	}
+	c.mu.Lock()
+	c.needsFull[peer] = true
+	c.mu.Unlock()
+}
+
+func (c *Counters) merge(buckets []*pb.RateBucket) {
+	oldest := bucketStart(time.Now().Add(-c.retention))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, b := range buckets {
+		key := bucketKey{counter: b.Counter, node: b.Node, start: b.Start}
+		if key.start <= oldest || key.node == c.s.Id {
+			continue
+		}
+		if b.Count > c.buckets[key] {
+			c.buckets[key] = b.Count
+		}
+	}
+}
+
+// flush expires old buckets and pushes changed local buckets to every peer,
+// or all local buckets to peers that missed an earlier push.
+func (c *Counters) flush() {
+	oldest := bucketStart(time.Now().Add(-c.retention))
+
+	c.mu.Lock()
+	var changed, own []*pb.RateBucket
+	for key, count := range c.buckets {
+		if key.start <= oldest {
+			delete(c.buckets, key)
+			delete(c.dirty, key)
+			continue
+		}
+		if key.node != c.s.Id {
+			continue
+		}
+		own = append(own, toProto(key, count))
+		if c.dirty[key] {
+			changed = append(changed, toProto(key, count))
+		}
+	}
+	c.dirty = map[bucketKey]bool{}
+	needsFull := c.needsFull
+	c.needsFull = map[string]bool{}
+	c.mu.Unlock()
+
+	for _, peer := range c.peers() {
+		batch := changed
+		if needsFull[peer] {
+			batch = own
+		}
+		if len(batch) == 0 {
+			continue
+		}
+		if err := c.push(peer, batch); err != nil {
+			c.logger.Warn("Failed to push rate buckets", "peer", peer, "err", err)
+			c.mu.Lock()
+			c.needsFull[peer] = true
+			c.mu.Unlock()
+		}
+	}
+}
+
+func (c *Counters) push(peer string, buckets []*pb.RateBucket) error {
+	conn := c.s.GetOrCreateConnection(peer)
+	if conn == nil {
+		return fmt.Errorf("no connection to %s", peer)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.s.Config().RPC.Timeout)
+	defer cancel()
+	_, err := pb.NewWindowClient(conn).MergeBuckets(ctx, &pb.RateBuckets{Buckets: buckets})
+	return err
+}
+
+func (c *Counters) syncFromPeer(peer string) {
+	conn := c.s.GetOrCreateConnection(peer)
+	if conn == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.s.Config().RPC.Timeout)
+	resp, err := pb.NewWindowClient(conn).GetBuckets(ctx, &pb.Empty{})
+	cancel()
+	if err != nil {
+		c.logger.Warn("Failed to sync rate buckets", "peer", peer, "err", err)
+		return
+	}
+	c.merge(resp.Buckets)
+}
+
+func (c *Counters) peers() []string {
+	c.s.Mu.Lock()
+	defer c.s.Mu.Unlock()
+	return arrays.Remove(c.s.Peers, c.s.Id)
+}
+
+func bucketStart(t time.Time) int64 {
+	return t.Truncate(BucketSize).Unix()
+}
+
+func toProto(key bucketKey, count int64) *pb.RateBucket {
+	return &pb.RateBucket{Counter: key.counter, Node: key.node, Start: key.start, Count: count}
+}
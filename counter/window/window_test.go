@@ -0,0 +1,63 @@
+package window_test
+
+import (
+	"discovery-service/counter/window"
+	"discovery-service/lib/testnode"
+	"discovery-service/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWindowedCountsMergeAcrossPeers(t *testing.T) {
+	node1 := testnode.Start(t, nil, testnode.Windowed(time.Minute))
+	node2 := testnode.Start(t, []string{node1.Id}, testnode.Windowed(time.Minute))
+	if !testnode.Eventually(10*time.Second, func() bool { return node1.Knows(node2) && node2.Knows(node1) }) {
+		t.Fatalf("Expected the nodes to discover each other")
+	}
+
+	for i := 0; i < 5; i++ {
+		node := node1
+		if i >= 3 {
+			node = node2
+		}
+		if status := node.Post(t, "/counters/api/increment"); status != http.StatusOK {
+			t.Fatalf("Expected the increment on %s to succeed, got %d", node.Id, status)
+		}
+	}
+
+	for _, delta := range []string{"0", "-5"} {
+		if status := node2.Post(t, "/counters/api/increment?delta="+delta); status != http.StatusBadRequest {
+			t.Fatalf("Expected delta %s to be refused, got %d", delta, status)
+		}
+	}
+
+	// The buckets are gossiped
+	for _, node := range []*testnode.Node{node1, node2} {
+		path := "/counters/api/rate?window=30s"
+		if !testnode.Eventually(10*time.Second, func() bool { return node.Count(t, path) == 5 }) {
+			t.Fatalf("Expected node %s to count 5 increments, got %d", node.Id, node.Count(t, path))
+		}
+	}
+
+	// Let the increments age out of a one second window
+	time.Sleep(2 * time.Second)
+	if count := node1.Count(t, "/counters/api/rate?window=1s"); count != 0 {
+		t.Fatalf("Expected no increments in the last second, got %d", count)
+	}
+}
+
+func TestWindowOutsideBucketsAndRetentionIsRefused(t *testing.T) {
+	counters := window.NewCounters(models.NewServer("localhost:1"), time.Minute)
+	for _, w := range []time.Duration{time.Nanosecond, window.BucketSize - time.Millisecond, 2 * time.Minute} {
+		if _, err := counters.Count("api", w); err == nil {
+			t.Errorf("Expected Count to refuse a %s window", w)
+		}
+		if _, _, err := counters.Buckets("api", w); err == nil {
+			t.Errorf("Expected Buckets to refuse a %s window", w)
+		}
+	}
+	if _, err := counters.Count("api", window.BucketSize); err != nil {
+		t.Errorf("Expected a one bucket window to be allowed, got %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package admin
+
+import (
+	"context"
+	"discovery-service/counter/api"
+	"discovery-service/counter/resend"
+	"discovery-service/counter/sync"
+	"discovery-service/discovery/heartbeat"
+	"discovery-service/models"
+	pb "discovery-service/proto"
+	"discovery-service/security/apikey"
+	"discovery-service/security/audit"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Service serves the Admin API used by counterctl.
+type Service struct {
+	pb.UnimplementedAdminServer
+	s *models.Server
+}
+
+func NewService(s *models.Server) *Service {
+	return &Service{s: s}
+}
+
+// Increment adds delta to a counter like CounterService does, also allowing
+// decrements of strong counters through the same call.
+func (svc *Service) Increment(ctx context.Context, req *pb.AdminIncrementRequest) (*pb.AdminIncrementResponse, error) {
+	key, err := svc.authorize(ctx, apikey.Increment, req.Counter)
+	if err != nil {
+		return nil, err
+	}
+	count, duplicate, err := api.Increment(ctx, svc.s, apikey.Caller(ctx, key), req.Counter, req.Delta, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.AdminIncrementResponse{Count: count, Duplicate: duplicate}, nil
+}
+
+// SyncFrom raises the counter of this node to the one of a peer if it is
+// higher, as done when a node joins.
+func (svc *Service) SyncFrom(ctx context.Context, req *pb.PeerRequest) (*pb.CounterResponse, error) {
+	if _, err := svc.authorize(ctx, apikey.Admin, ""); err != nil {
+		return nil, err
+	}
+	conn := svc.s.GetOrCreateConnection(req.Peer)
+	if conn == nil {
+		return nil, status.Error(codes.Unavailable, "cannot connect to "+req.Peer)
+	}
+	sync.SyncCounterFromPeer(svc.s, pb.NewDiscoveryClient(conn), req.Peer)
+	svc.s.Mu.Lock()
+	defer svc.s.Mu.Unlock()
+	return &pb.CounterResponse{Counter: svc.s.Counter}, nil
+}
+
+// Resend replays the ops a peer missed now instead of waiting for it to be
+// seen alive again.
+func (svc *Service) Resend(ctx context.Context, req *pb.PeerRequest) (*pb.ResendResponse, error) {
+	if _, err := svc.authorize(ctx, apikey.Admin, ""); err != nil {
+		return nil, err
+	}
+	before := svc.missed(req.Peer)
+	resend.Resend{}.Execute(svc.s, req.Peer)
+	after := svc.missed(req.Peer)
+	return &pb.ResendResponse{Sent: int64(before - after), Remaining: int64(after)}, nil
+}
+
+// RemovePeer force-removes a peer from the membership of this node.
+func (svc *Service) RemovePeer(ctx context.Context, req *pb.PeerRequest) (*pb.Empty, error) {
+	key, err := svc.authorize(ctx, apikey.Admin, "")
+	if err != nil {
+		return nil, err
+	}
+	if !heartbeat.Forget(svc.s, req.Peer) {
+		return nil, status.Error(codes.NotFound, "unknown peer "+req.Peer)
+	}
+	svc.s.Audit.Record(audit.PeerRemoved, apikey.Caller(ctx, key), req.Peer, "")
+	return &pb.Empty{}, nil
+}
+
+// GetQueues returns the ops waiting to be resent to each peer.
+func (svc *Service) GetQueues(ctx context.Context, _ *pb.Empty) (*pb.Queues, error) {
+	if _, err := svc.authorize(ctx, apikey.Read, ""); err != nil {
+		return nil, err
+	}
+	s := svc.s
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	queues := &pb.Queues{MissedOps: map[string]*pb.OpIDs{}, SeenOps: int64(len(s.SeenOps))}
+	for p, ops := range s.MissedOps {
+		if len(ops) > 0 {
+			queues.MissedOps[p] = &pb.OpIDs{Ids: append([]string{}, ops...)}
+		}
+	}
+	return queues, nil
+}
+
+func (svc *Service) missed(peer string) int {
+	svc.s.Mu.Lock()
+	defer svc.s.Mu.Unlock()
+	return len(svc.s.MissedOps[peer])
+}
+
+// authorize checks the API key of the call like the HTTP API does.
+func (svc *Service) authorize(ctx context.Context, perm apikey.Permission, counter string) (*apikey.Key, error) {
+	key, err := svc.s.APIKeys.AuthorizeRPC(ctx, perm, counter)
+	if status.Code(err) == codes.PermissionDenied {
+		svc.s.Logger("admin").Info("API key denied", "key", key.Name, "permission", perm.String())
+	}
+	return key, err
+}
@@ -0,0 +1,93 @@
+package admin_test
+
+import (
+	"context"
+	"discovery-service/lib/testnode"
+	"discovery-service/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAdmin(t *testing.T) {
+	node := testnode.Start(t, nil, testnode.Admin())
+
+	conn, err := grpc.NewClient(node.Id, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer conn.Close()
+	c := proto.NewAdminClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := c.Increment(ctx, &proto.AdminIncrementRequest{Delta: 3})
+	if err != nil {
+		t.Fatalf("Increment failed: %v", err)
+	}
+	// Increments are applied in the background
+	if !testnode.Eventually(time.Second, func() bool { return node.Counter(t) == 3 }) || resp.Count > 3 {
+		t.Fatalf("Expected the counter to be 3, got %d (reported %d)", node.Counter(t), resp.Count)
+	}
+
+	// The default counter only grows
+	if _, err := c.Increment(ctx, &proto.AdminIncrementRequest{Delta: -1}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected decrementing the default counter to fail, got %v", err)
+	}
+
+	if _, err := c.RemovePeer(ctx, &proto.PeerRequest{Peer: "localhost:5001"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected removing an unknown peer to fail, got %v", err)
+	}
+
+	queues, err := c.GetQueues(ctx, &proto.Empty{})
+	if err != nil {
+		t.Fatalf("GetQueues failed: %v", err)
+	}
+	if queues.SeenOps != 3 || len(queues.MissedOps) != 0 {
+		t.Fatalf("Expected 3 seen ops and no missed ops, got %+v", queues)
+	}
+
+	// Retries with the same op ID or Idempotency-Key are applied once
+	for i, want := range []bool{false, true} {
+		resp, err := c.Increment(ctx, &proto.AdminIncrementRequest{Delta: 2, Id: "retried"})
+		if err != nil {
+			t.Fatalf("Increment %d failed: %v", i, err)
+		}
+		if resp.Duplicate != want {
+			t.Fatalf("Expected increment %d to report duplicate %v", i, want)
+		}
+	}
+	for i, want := range []string{"", "true"} {
+		req, _ := http.NewRequest(http.MethodPost, node.URL+"/increment", nil)
+		req.Header.Set("Idempotency-Key", "http-retried")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Increment %d failed: %v", i, err)
+		}
+		resp.Body.Close()
+		if got := resp.Header.Get("Idempotent-Replayed"); resp.StatusCode != http.StatusOK || got != want {
+			t.Fatalf("Expected increment %d to succeed with Idempotent-Replayed %q, got %d %q", i, want, resp.StatusCode, got)
+		}
+	}
+	// Keys are hashed into op IDs, so they cannot name an op of another key
+	req, _ := http.NewRequest(http.MethodPost, node.URL+"/increment", nil)
+	req.Header.Set("Idempotency-Key", "retried/1")
+	httpResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Increment failed: %v", err)
+	}
+	httpResp.Body.Close()
+	if got := httpResp.Header.Get("Idempotent-Replayed"); got != "" {
+		t.Fatalf("Expected a new key not to be replayed, got %q", got)
+	}
+	if _, err := c.Increment(ctx, &proto.AdminIncrementRequest{Counter: "named", Delta: 1, Id: "retried"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected an id on a named counter to be refused, got %v", err)
+	}
+	if !testnode.Eventually(time.Second, func() bool { return node.Counter(t) == 7 }) {
+		t.Fatalf("Expected the counter to be 7, got %d", node.Counter(t))
+	}
+}
@@ -9,15 +9,33 @@ import (
 	"discovery-service/lib/arrays"
 	"discovery-service/models"
 	"discovery-service/proto"
-	"fmt"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-	"log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func StartClient(s *models.Server, initialPeers []string) {
-	visited := map[string]bool{}
 	s.ConnPool = map[string]*grpc.ClientConn{}
+	Join(s, initialPeers)
+
+	s.MarkSynced()
+
+	// Register recovery actions for heartbeat
+	heartbeat.RegisterRecoveryAction(reconnect.Reconnect{})
+	heartbeat.RegisterRecoveryAction(resend.Resend{})
+	heartbeat.MonitorHeartbeats(s)
+}
+
+// Join registers with peers and the peers they know, syncing the counter
+// from them. Peers s already knows are skipped.
+func Join(s *models.Server, peers []string) {
+	visited := map[string]bool{}
+	s.Mu.Lock()
+	for _, p := range append(s.Peers, s.DeadPeers...) {
+		visited[p] = true
+	}
+	s.Mu.Unlock()
+
 	var connectAndRegister func(addr string)
 	connectAndRegister = func(addr string) {
 		if addr == s.Id || visited[addr] || addr == "" {
@@ -26,9 +44,9 @@ func StartClient(s *models.Server, initialPeers []string) {
 		visited[addr] = true
 
 		// Corrected the connection creation using grpc.Dial
-		conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		conn, err := grpc.Dial(addr, s.DialOptions()...)
 		if err != nil {
-			log.Println("Could not connect to peer:", addr)
+			s.Logger("discovery").Warn("Could not connect to peer", "peer", addr, "err", err)
 			return
 		}
 		defer conn.Close()
@@ -36,9 +54,17 @@ func StartClient(s *models.Server, initialPeers []string) {
 		client := proto.NewDiscoveryClient(conn)
 
 		// Register with the peer
-		resp, err := client.Register(context.Background(), &proto.RegisterRequest{Id: s.Id})
+		var resp *proto.RegisterResponse
+		req, err := s.NewRegisterRequest(context.Background(), client)
+		if err == nil {
+			resp, err = client.Register(context.Background(), req)
+		}
+		if status.Code(err) == codes.FailedPrecondition {
+			s.Logger("discovery").Error("Peer belongs to another cluster", "peer", addr, "err", err)
+			return
+		}
 		if err != nil {
-			fmt.Println("Error registering:", err)
+			s.Logger("discovery").Warn("Failed to register with peer", "peer", addr, "err", err)
 			return
 		}
 
@@ -61,13 +87,7 @@ func StartClient(s *models.Server, initialPeers []string) {
 		}
 	}
 
-	// Start connecting to initial peers
-	for _, addr := range initialPeers {
+	for _, addr := range peers {
 		connectAndRegister(addr)
 	}
-
-	// Register recovery actions for heartbeat
-	heartbeat.RegisterRecoveryAction(reconnect.Reconnect{})
-	heartbeat.RegisterRecoveryAction(resend.Resend{})
-	heartbeat.MonitorHeartbeats(s)
 }
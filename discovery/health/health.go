@@ -0,0 +1,34 @@
+package health
+
+import (
+	"discovery-service/models"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"time"
+)
+
+// Register adds the standard gRPC health service to grpcServer and keeps it
+// in step with the readiness of s. The overall status ("") and the
+// Discovery service report SERVING only while the node is ready.
+func Register(grpcServer *grpc.Server, s *models.Server) {
+	server := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, server)
+
+	go func() {
+		status := healthpb.HealthCheckResponse_UNKNOWN
+		for {
+			next := healthpb.HealthCheckResponse_NOT_SERVING
+			if models.Healthy(s.Readiness()) {
+				next = healthpb.HealthCheckResponse_SERVING
+			}
+			if next != status {
+				s.Logger("health").Info("Readiness changed", "status", next.String())
+				status = next
+				server.SetServingStatus("", status)
+				server.SetServingStatus("discovery.Discovery", status)
+			}
+			time.Sleep(s.Config().Health.CheckInterval)
+		}
+	}()
+}
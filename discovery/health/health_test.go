@@ -0,0 +1,79 @@
+package health_test
+
+import (
+	"context"
+	"discovery-service/lib/testnode"
+	"discovery-service/models"
+	"encoding/json"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func readyz(t *testing.T, url string) (int, map[string]string) {
+	t.Helper()
+	resp, err := http.Get(url + "/readyz")
+	if err != nil {
+		t.Fatalf("Failed to call readyz: %v", err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Checks map[string]string `json:"checks"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result.Checks
+}
+
+func TestReadiness(t *testing.T) {
+	// A node whose gRPC listener is not up is live but not ready
+	node := testnode.StartHTTP(t, func(s *models.Server, _ *grpc.Server) { s.MarkSynced() })
+	s := node.Server
+	resp, err := http.Get(node.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("Failed to call healthz: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected healthz to succeed, got %d", resp.StatusCode)
+	}
+	if code, checks := readyz(t, node.URL); code != http.StatusServiceUnavailable || checks["grpc_listener"] == "ok" {
+		t.Fatalf("Expected readyz to fail on the listener, got %d %v", code, checks)
+	}
+
+	// A node that lost most of its peers is not ready either
+	s.MarkListening()
+	s.Mu.Lock()
+	s.DeadPeers = []string{"localhost:5001", "localhost:5002"}
+	s.Mu.Unlock()
+	s.CheckPartition() // As the heartbeat round does
+	if code, checks := readyz(t, node.URL); code != http.StatusServiceUnavailable || checks["majority"] == "ok" {
+		t.Fatalf("Expected readyz to fail on the majority check, got %d %v", code, checks)
+	}
+
+	started := testnode.Start(t, nil, testnode.Health())
+	if code, checks := readyz(t, started.URL); code != http.StatusOK {
+		t.Fatalf("Expected a started node to be ready, got %d %v", code, checks)
+	}
+
+	conn, err := grpc.NewClient(started.Id, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	// The gRPC health status follows readiness on the next check
+	check := func() healthpb.HealthCheckResponse_ServingStatus {
+		status, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatalf("Health check failed: %v", err)
+		}
+		return status.Status
+	}
+	if !testnode.Eventually(2*time.Second, func() bool { return check() == healthpb.HealthCheckResponse_SERVING }) {
+		t.Fatalf("Expected SERVING, got %s", check())
+	}
+}
@@ -0,0 +1,65 @@
+package health_test
+
+import (
+	"discovery-service/lib/testnode"
+	"discovery-service/models"
+	"discovery-service/security/audit"
+	"google.golang.org/grpc"
+	"net/http"
+	"testing"
+)
+
+func TestMinorityPartition(t *testing.T) {
+	node := testnode.StartHTTP(t, func(s *models.Server, _ *grpc.Server) {
+		s.Audit, _ = audit.New(s.Id, "", s.Logger("audit"))
+		s.ReadOnlyInMinority = true
+	})
+	s, url := node.Server, node.URL
+
+	s.Mu.Lock()
+	s.DeadPeers = []string{"localhost:5001", "localhost:5002"}
+	s.Mu.Unlock()
+	if !s.CheckPartition() {
+		t.Fatalf("Expected 1 of 3 reachable nodes to be a minority")
+	}
+
+	resp, err := http.Post(url+"/increment", "", nil)
+	if err != nil {
+		t.Fatalf("Failed to call increment API: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected writes to be refused in a minority, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(url + "/count")
+	if err != nil {
+		t.Fatalf("Failed to call count API: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Warning") == "" {
+		t.Fatalf("Expected a stale read warning, got %d %q", resp.StatusCode, resp.Header.Get("Warning"))
+	}
+
+	// Two of three nodes are a majority again
+	s.Mu.Lock()
+	s.Peers = append(s.Peers, "localhost:5001")
+	s.DeadPeers = []string{"localhost:5002"}
+	s.Mu.Unlock()
+	if s.CheckPartition() {
+		t.Fatalf("Expected 2 of 3 reachable nodes to be a majority")
+	}
+	resp, err = http.Get(url + "/count")
+	if err != nil {
+		t.Fatalf("Failed to call count API: %v", err)
+	}
+	resp.Body.Close()
+	if resp.Header.Get("Warning") != "" {
+		t.Fatalf("Expected no stale read warning after healing")
+	}
+
+	entries := s.Audit.Query(audit.Filter{Action: "partition."})
+	if len(entries) != 2 || entries[0].Action != audit.PartitionHealed || entries[1].Action != audit.PartitionEntered {
+		t.Fatalf("Expected the partition and its healing to be audited, got %+v", entries)
+	}
+}
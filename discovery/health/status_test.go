@@ -0,0 +1,57 @@
+package health_test
+
+import (
+	"discovery-service/lib/testnode"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClusterStatus(t *testing.T) {
+	first := testnode.Start(t, nil)
+	second := testnode.Start(t, []string{first.Id})
+	if !testnode.Eventually(10*time.Second, func() bool { return first.Knows(second) && second.Knows(first) }) {
+		t.Fatalf("Expected the nodes to discover each other")
+	}
+
+	resp, err := http.Get(first.URL + "/cluster/status")
+	if err != nil {
+		t.Fatalf("Failed to get cluster status: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", resp.StatusCode)
+	}
+	var result struct {
+		Nodes []struct {
+			ID        string   `json:"id"`
+			Reachable bool     `json:"reachable"`
+			Live      []string `json:"live"`
+			Diverged  bool     `json:"diverged"`
+		} `json:"nodes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode cluster status: %v", err)
+	}
+	if len(result.Nodes) != 2 {
+		t.Fatalf("Expected both nodes, got %+v", result.Nodes)
+	}
+	for _, node := range result.Nodes {
+		if !node.Reachable || node.Diverged {
+			t.Fatalf("Expected %s to be reachable and in agreement, got %+v", node.ID, node)
+		}
+	}
+	if result.Nodes[1].ID != second.Id || len(result.Nodes[1].Live) == 0 {
+		t.Fatalf("Expected %s to report its peers, got %+v", second.Id, result.Nodes[1])
+	}
+
+	resp, err = http.Get(first.URL + "/dashboard")
+	if err != nil {
+		t.Fatalf("Failed to get dashboard: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 for the dashboard, got %d", resp.StatusCode)
+	}
+}
@@ -5,18 +5,13 @@ import (
 	"discovery-service/lib/arrays"
 	"discovery-service/models"
 	"discovery-service/proto"
+	"discovery-service/security/audit"
+	"fmt"
 	"google.golang.org/grpc"
-	"log"
 	"sync"
 	"time"
 )
 
-const (
-	MaxRetries      = 5
-	BaseBackoff     = time.Second
-	heartbeatPeriod = 5 * time.Second
-)
-
 var (
 	mu              sync.Mutex
 	peersState      = make(map[string]*peerState)
@@ -41,7 +36,7 @@ func RegisterRecoveryAction(action RecoveryAction) {
 func MonitorHeartbeats(s *models.Server) {
 	go func() {
 		for {
-			time.Sleep(heartbeatPeriod)
+			time.Sleep(s.Config().Heartbeat.Period)
 			// 1. Get a snapshot of current live peers
 			currentPeers := append([]string{}, s.Peers...)
 			currentPeers = append(currentPeers, s.DeadPeers...)
@@ -64,78 +59,118 @@ func MonitorHeartbeats(s *models.Server) {
 				}
 				checkHeartbeat(s, peer)
 			}
+			s.CheckPartition()
 
 		}
 	}()
 }
 
 func checkHeartbeat(s *models.Server, peer string) {
-	backoff := BaseBackoff
+	cfg := s.Config().Heartbeat
+	backoff := cfg.BaseBackoff
 	success := false
 
 	// Reuse existing connection if available
 	conn := s.GetOrCreateConnection(peer)
 
 	if conn == nil {
-		log.Printf("Failed to establish connection to %s after %d retries", peer, MaxRetries)
+		s.Logger("heartbeat").Warn("Failed to establish connection", "peer", peer, "retries", cfg.MaxRetries)
 		return
 	}
 
 	client := proto.NewDiscoveryClient(conn)
 
 	// Retry heartbeat with exponential backoff
-	for attempt := 0; attempt < MaxRetries; attempt++ {
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		_, err := client.Heartbeat(ctx, &proto.HeartbeatRequest{Id: s.Id})
+	for attempt := 0; attempt < cfg.MaxRetries; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+		start := time.Now()
+		req, err := s.NewHeartbeatRequest(ctx, client)
+		var resp *proto.HeartbeatResponse
+		if err == nil {
+			resp, err = client.Heartbeat(ctx, req)
+		}
 		cancel()
+		result := "ok"
+		if err != nil {
+			result = "error"
+		}
+		s.Metrics.HeartbeatDuration.Observe(time.Since(start).Seconds(), peer, result)
+		s.RecordHeartbeat(peer, time.Since(start), resp.GetClientPort(), err == nil)
 
 		if err == nil {
 			success = true
 			break
 		}
 
-		log.Printf("Heartbeat to %s failed (attempt %d): %v", peer, attempt+1, err)
+		s.Logger("heartbeat").Debug("Heartbeat failed", "peer", peer, "attempt", attempt+1, "err", err)
 		time.Sleep(backoff)
 		backoff *= 2 // Exponential backoff
 	}
 
-	handleHeartbeatResult(s, peer, success, conn)
+	handleHeartbeatResult(s, peer, success, conn, cfg.MaxRetries)
 }
 
-func handleHeartbeatResult(s *models.Server, peer string, success bool, conn *grpc.ClientConn) {
+func handleHeartbeatResult(s *models.Server, peer string, success bool, conn *grpc.ClientConn, attempts int) {
 	state := peersState[peer]
 
 	if success {
-		log.Printf("Heartbeat to %s succeeded", peer)
+		s.Logger("heartbeat").Debug("Heartbeat succeeded", "peer", peer)
 		state.failures = 0
 		if state.dead {
-			log.Printf("Peer %s healed", peer)
+			s.Logger("heartbeat").Info("Peer healed", "peer", peer)
 			state.dead = false
 			s.Mu.Lock()
 			s.Peers = append(s.Peers, peer)
 			s.DeadPeers = arrays.Remove(s.DeadPeers, peer)
 			s.Mu.Unlock()
+			s.Audit.Record(audit.PeerHealed, s.Id, peer, "")
 			// Execute all registered recovery actions
 			for _, action := range recoveryActions {
 				action.Execute(s, peer)
 			}
 		}
 	} else {
-		log.Printf("Peer %s failed heartbeat after %d attempts", peer, MaxRetries)
+		s.Logger("heartbeat").Warn("Peer failed heartbeat", "peer", peer, "attempts", attempts)
 		state.failures++
 		if !state.dead {
-			log.Printf("Marking %s as dead", peer)
+			s.Logger("heartbeat").Warn("Marking peer as dead", "peer", peer)
 			state.dead = true
 			s.Mu.Lock()
 			s.Peers = arrays.Remove(s.Peers, peer)
 			s.DeadPeers = append(s.DeadPeers, peer)
 			s.Mu.Unlock()
+			s.Audit.Record(audit.PeerDead, s.Id, peer, fmt.Sprintf("failed %d heartbeats", attempts))
 			// Close the connection as the peer is dead
 			closeConnection(s, peer, conn)
 		}
 	}
 }
 
+// Forget removes peer from the membership of s, whether it is alive or
+// dead, and stops checking it. A live peer rejoins when it registers again.
+func Forget(s *models.Server, peer string) bool {
+	mu.Lock()
+	if state, ok := peersState[peer]; ok {
+		// Checks still in flight may find the state, so reset it rather
+		// than deleting it.
+		state.dead = false
+		state.failures = 0
+	}
+	mu.Unlock()
+
+	s.Mu.Lock()
+	known := arrays.Contains(s.Peers, peer) || arrays.Contains(s.DeadPeers, peer)
+	s.Peers = arrays.Remove(s.Peers, peer)
+	s.DeadPeers = arrays.Remove(s.DeadPeers, peer)
+	conn := s.ConnPool[peer]
+	s.Mu.Unlock()
+
+	if known {
+		closeConnection(s, peer, conn)
+	}
+	return known
+}
+
 func closeConnection(s *models.Server, peer string, conn *grpc.ClientConn) {
 	mu.Lock()
 	if conn != nil {
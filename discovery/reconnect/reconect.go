@@ -4,7 +4,6 @@ import (
 	"context"
 	"discovery-service/models"
 	"discovery-service/proto"
-	"log"
 )
 
 type Reconnect struct {
@@ -16,14 +15,20 @@ func (r Reconnect) Execute(s *models.Server, peer string) {
 	client := proto.NewDiscoveryClient(conn)
 
 	// Send a heartbeat or any other message to verify the connection
-	_, err := client.Heartbeat(context.Background(), &proto.HeartbeatRequest{Id: s.Id})
+	hbReq, err := s.NewHeartbeatRequest(context.Background(), client)
+	if err == nil {
+		_, err = client.Heartbeat(context.Background(), hbReq)
+	}
 	if err != nil {
-		log.Printf("failed to send heartbeat: %v", err)
+		s.Logger("discovery").Warn("Failed to send heartbeat", "peer", peer, "err", err)
 	}
 
 	// If successful, re-register with the peer and synchronize state
-	_, err = client.Register(context.Background(), &proto.RegisterRequest{Id: s.Id})
+	regReq, err := s.NewRegisterRequest(context.Background(), client)
+	if err == nil {
+		_, err = client.Register(context.Background(), regReq)
+	}
 	if err != nil {
-		log.Printf("failed to register with peer: %v", err)
+		s.Logger("discovery").Warn("Failed to register with peer", "peer", peer, "err", err)
 	}
 }
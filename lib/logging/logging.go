@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"strings"
+	"sync"
+)
+
+// Logging creates the loggers of all subsystems. Every subsystem logs at the
+// default level unless it has its own level, and levels can be changed at
+// runtime.
+type Logging struct {
+	handler slog.Handler
+
+	mu        sync.RWMutex
+	level     slog.Level
+	overrides map[string]slog.Level
+}
+
+// New writes logs to w as "text" or "json" at level.
+func New(w io.Writer, format string, level slog.Level) (*Logging, error) {
+	l := &Logging{level: level, overrides: map[string]slog.Level{}}
+	// The handler lets everything through, loggers filter by subsystem.
+	opts := &slog.HandlerOptions{Level: slog.Level(-100)}
+	switch format {
+	case "text":
+		l.handler = slog.NewTextHandler(w, opts)
+	case "json":
+		l.handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q, expected text or json", format)
+	}
+	return l, nil
+}
+
+// Logger returns the logger of subsystem.
+func (l *Logging) Logger(subsystem string) *slog.Logger {
+	return slog.New(&filter{Handler: l.handler.WithAttrs([]slog.Attr{slog.String("subsystem", subsystem)}), l: l, subsystem: subsystem})
+}
+
+// Level returns the level subsystem logs at.
+func (l *Logging) Level(subsystem string) slog.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if level, ok := l.overrides[subsystem]; ok {
+		return level
+	}
+	return l.level
+}
+
+// SetLevel sets the level of subsystem, or the default level if subsystem
+// is "".
+func (l *Logging) SetLevel(subsystem string, level slog.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if subsystem == "" {
+		l.level = level
+		return
+	}
+	l.overrides[subsystem] = level
+}
+
+// ResetLevel makes subsystem log at the default level again.
+func (l *Logging) ResetLevel(subsystem string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.overrides, subsystem)
+}
+
+// Levels returns the default level and the subsystems with their own level.
+func (l *Logging) Levels() (slog.Level, map[string]slog.Level) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.level, maps.Clone(l.overrides)
+}
+
+// Configure applies a level spec such as "info,raft=debug,heartbeat=warn":
+// a bare level sets the default, subsystem=level pairs override it.
+func (l *Logging) Configure(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		subsystem, value, ok := strings.Cut(item, "=")
+		if !ok {
+			subsystem, value = "", item
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("invalid log level %q", item)
+		}
+		l.SetLevel(subsystem, level)
+	}
+	return nil
+}
+
+type filter struct {
+	slog.Handler
+	l         *Logging
+	subsystem string
+}
+
+func (f *filter) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= f.l.Level(f.subsystem)
+}
+
+func (f *filter) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &filter{Handler: f.Handler.WithAttrs(attrs), l: f.l, subsystem: f.subsystem}
+}
+
+func (f *filter) WithGroup(name string) slog.Handler {
+	return &filter{Handler: f.Handler.WithGroup(name), l: f.l, subsystem: f.subsystem}
+}
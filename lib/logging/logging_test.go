@@ -0,0 +1,50 @@
+package logging_test
+
+import (
+	"bytes"
+	"discovery-service/lib/logging"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSubsystemLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logs, err := logging.New(&buf, "json", slog.LevelInfo)
+	if err != nil {
+		t.Fatalf("Failed to create logging: %v", err)
+	}
+	if err := logs.Configure("warn,raft=debug"); err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+
+	logs.Logger("heartbeat").Info("hidden")
+	logs.Logger("raft").Debug("shown", "term", 3)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected only the raft debug line, got %q", buf.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("Expected JSON output: %v", err)
+	}
+	if entry["subsystem"] != "raft" || entry["msg"] != "shown" || entry["term"] != float64(3) {
+		t.Fatalf("Unexpected log entry %v", entry)
+	}
+
+	// Levels change at runtime for loggers created earlier
+	heartbeat := logs.Logger("heartbeat")
+	buf.Reset()
+	logs.SetLevel("heartbeat", slog.LevelDebug)
+	heartbeat.Debug("now shown")
+	logs.ResetLevel("heartbeat")
+	heartbeat.Info("hidden again")
+	if strings.Count(buf.String(), "\n") != 1 || !strings.Contains(buf.String(), "now shown") {
+		t.Fatalf("Expected the runtime level change to apply, got %q", buf.String())
+	}
+
+	if err := logs.Configure("raft=loud"); err == nil {
+		t.Fatalf("Expected an invalid level to be rejected")
+	}
+}
@@ -0,0 +1,255 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are histogram buckets in seconds suited to RPC latencies.
+var DefaultBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}
+
+// Registry holds metrics and writes them in the Prometheus text exposition
+// format, in the order they were registered.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+	names   map[string]bool
+}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{names: map[string]bool{}}
+}
+
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.names[name] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteText writes all metrics to w.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	return bw.Flush()
+}
+
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d desc) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.kind)
+}
+
+// series formats a sample line of name with the label values and extra
+// label pairs appended.
+func (d desc) series(w *bufio.Writer, name string, values []string, extra []string, v float64) {
+	w.WriteString(name)
+	pairs := make([]string, 0, len(values)+len(extra)/2)
+	for i, value := range values {
+		pairs = append(pairs, d.labels[i]+`="`+escape(value)+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escape(extra[i+1])+`"`)
+	}
+	if len(pairs) > 0 {
+		w.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+	w.WriteString(" " + formatFloat(v) + "\n")
+}
+
+func (d desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// Counter is a monotonically increasing value, optionally partitioned by
+// labels.
+type Counter struct {
+	desc
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewCounter registers a counter with the given label names.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{desc: desc{name: name, help: help, kind: "counter", labels: labels}, values: map[string]float64{}}
+	r.register(name, c)
+	return c
+}
+
+// Inc adds one to the series with the given label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the series with the given
+// label values.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	key := c.key(labelValues)
+	c.mu.Lock()
+	c.values[key] += v
+	c.mu.Unlock()
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.header(w)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.labels) == 0 && len(c.values) == 0 {
+		c.series(w, c.name, nil, nil, 0)
+	}
+	for _, key := range sortedKeys(c.values) {
+		c.series(w, c.name, split(key, len(c.labels)), nil, c.values[key])
+	}
+}
+
+// GaugeFunc is a gauge whose series are read when metrics are scraped.
+type GaugeFunc struct {
+	desc
+	fn func() map[string]float64
+}
+
+// NewGaugeFunc registers a gauge without labels read from fn.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	g := &GaugeFunc{desc: desc{name: name, help: help, kind: "gauge"}, fn: func() map[string]float64 {
+		return map[string]float64{"": fn()}
+	}}
+	r.register(name, g)
+}
+
+// NewGaugeVecFunc registers a gauge with one label, fn returns the value of
+// every series by label value.
+func (r *Registry) NewGaugeVecFunc(name, help string, label string, fn func() map[string]float64) {
+	r.register(name, &GaugeFunc{desc: desc{name: name, help: help, kind: "gauge", labels: []string{label}}, fn: fn})
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	g.header(w)
+	values := g.fn()
+	for _, key := range sortedKeys(values) {
+		g.series(w, g.name, split(key, len(g.labels)), nil, values[key])
+	}
+}
+
+// Histogram counts observations in cumulative buckets, optionally
+// partitioned by labels.
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	data    map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram registers a histogram with the given upper bounds, which
+// must be sorted, and label names.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{desc: desc{name: name, help: help, kind: "histogram", labels: labels}, buckets: buckets, data: map[string]*histogramSeries{}}
+	r.register(name, h)
+	return h
+}
+
+// Observe records v in the series with the given label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.data[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.data[key] = s
+	}
+	for i, bound := range h.buckets {
+		if v <= bound {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.header(w)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	keys := make([]string, 0, len(h.data))
+	for key := range h.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := h.data[key]
+		values := split(key, len(h.labels))
+		for i, bound := range h.buckets {
+			h.series(w, h.name+"_bucket", values, []string{"le", formatFloat(bound)}, float64(s.counts[i]))
+		}
+		h.series(w, h.name+"_bucket", values, []string{"le", "+Inf"}, float64(s.count))
+		h.series(w, h.name+"_sum", values, nil, s.sum)
+		h.series(w, h.name+"_count", values, nil, float64(s.count))
+	}
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func split(key string, n int) []string {
+	if n == 0 {
+		return nil
+	}
+	return strings.Split(key, "\xff")
+}
+
+func escape(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,46 @@
+package metrics_test
+
+import (
+	"bytes"
+	"discovery-service/lib/metrics"
+	"testing"
+)
+
+func TestTextExposition(t *testing.T) {
+	r := metrics.NewRegistry()
+	failures := r.NewCounter("failures_total", "Failed calls.", "peer")
+	latency := r.NewHistogram("latency_seconds", "Call latency.", []float64{0.1, 1}, "peer")
+	r.NewGaugeFunc("queue_depth", "Queued items.", func() float64 { return 7 })
+	r.NewCounter("idle_total", "Never incremented.")
+
+	failures.Inc(`b"1`)
+	failures.Add(2, "a")
+	latency.Observe(0.05, "a")
+	latency.Observe(0.5, "a")
+
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	want := `# HELP failures_total Failed calls.
+# TYPE failures_total counter
+failures_total{peer="a"} 2
+failures_total{peer="b\"1"} 1
+# HELP latency_seconds Call latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{peer="a",le="0.1"} 1
+latency_seconds_bucket{peer="a",le="1"} 2
+latency_seconds_bucket{peer="a",le="+Inf"} 2
+latency_seconds_sum{peer="a"} 0.55
+latency_seconds_count{peer="a"} 2
+# HELP queue_depth Queued items.
+# TYPE queue_depth gauge
+queue_depth 7
+# HELP idle_total Never incremented.
+# TYPE idle_total counter
+idle_total 0
+`
+	if buf.String() != want {
+		t.Fatalf("Unexpected exposition:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
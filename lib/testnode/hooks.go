@@ -0,0 +1,92 @@
+package testnode
+
+import (
+	"discovery-service/counter/api"
+	"discovery-service/counter/bounded"
+	"discovery-service/counter/raft"
+	"discovery-service/counter/window"
+	"discovery-service/discovery/admin"
+	"discovery-service/discovery/health"
+	"discovery-service/models"
+	"discovery-service/proto"
+	"google.golang.org/grpc"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Raft replicates counters through raft, keeping its state in dir and
+// bootstrapping the cluster if bootstrap is set.
+func Raft(t *testing.T, dir string, counters []string, bootstrap bool) Hook {
+	return func(s *models.Server, g *grpc.Server) {
+		cfg := *s.Config()
+		cfg.Counters.RaftDir = dir
+		s.SetConfig(&cfg)
+		node, err := raft.NewNode(s, counters, bootstrap)
+		if err != nil {
+			t.Fatalf("Failed to create raft node: %v", err)
+		}
+		proto.RegisterRaftServer(g, node)
+		s.Strong = node
+		node.Start()
+	}
+}
+
+// Bounded serves bounded counters with limits, giving the node their
+// allowance if seed is set.
+func Bounded(limits map[string]int64, seed bool) Hook {
+	return func(s *models.Server, g *grpc.Server) {
+		counters := bounded.NewCounters(s, limits, seed)
+		proto.RegisterEscrowServer(g, counters)
+		s.Bounded = counters
+	}
+}
+
+// Windowed serves windowed counters keeping retention, gossiping their
+// buckets with the peers.
+func Windowed(retention time.Duration) Hook {
+	return func(s *models.Server, g *grpc.Server) {
+		windowed := window.NewCounters(s, retention)
+		proto.RegisterWindowServer(g, windowed)
+		s.Windowed = windowed
+		windowed.Start()
+	}
+}
+
+// CounterService serves the client-facing CounterService.
+func CounterService() Hook {
+	return func(s *models.Server, g *grpc.Server) {
+		proto.RegisterCounterServiceServer(g, api.NewService(s))
+	}
+}
+
+// ClientPort serves the client-facing CounterService on a port of its own,
+// like --client-port, instead of with the peer RPCs.
+func ClientPort(t *testing.T) Hook {
+	return func(s *models.Server, g *grpc.Server) {
+		lis, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			t.Fatalf("Failed to listen for clients: %v", err)
+		}
+		s.ClientPort = strconv.Itoa(lis.Addr().(*net.TCPAddr).Port)
+		clients := grpc.NewServer()
+		proto.RegisterCounterServiceServer(clients, api.NewService(s))
+		go clients.Serve(lis)
+		t.Cleanup(clients.Stop)
+	}
+}
+
+// Admin serves the Admin service.
+func Admin() Hook {
+	return func(s *models.Server, g *grpc.Server) {
+		proto.RegisterAdminServer(g, admin.NewService(s))
+	}
+}
+
+// Health serves the gRPC health service.
+func Health() Hook {
+	return func(s *models.Server, g *grpc.Server) {
+		health.Register(g, s)
+	}
+}
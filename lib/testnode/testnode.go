@@ -0,0 +1,179 @@
+// Package testnode starts nodes for tests on free ports, so tests do not
+// depend on fixed ports or on sleeping long enough for nodes to come up.
+package testnode
+
+import (
+	"context"
+	"discovery-service/discovery/client"
+	"discovery-service/models"
+	"discovery-service/proto"
+	"discovery-service/web"
+	"encoding/json"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Hook sets up the server of a test node and registers the services it
+// needs, before the node serves.
+type Hook func(s *models.Server, g *grpc.Server)
+
+// Node is a node started by Start.
+type Node struct {
+	*models.Server
+	GRPC *grpc.Server
+	Port string // The gRPC port
+	URL  string // The base URL of the HTTP API
+}
+
+// newNode creates the server of a node listening on a free gRPC port. The
+// HTTP port offset is set so the HTTP port was free as well.
+func newNode(t *testing.T) (*Node, net.Listener) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	httpLis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	// The HTTP server listens on its own, so the port is only reserved.
+	httpLis.Close()
+	t.Cleanup(func() { lis.Close() })
+
+	port := lis.Addr().(*net.TCPAddr).Port
+	s := models.NewServer("localhost:" + strconv.Itoa(port))
+	cfg := *s.Config()
+	cfg.HTTP.PortOffset = httpLis.Addr().(*net.TCPAddr).Port - port
+	s.SetConfig(&cfg)
+	return &Node{Server: s, GRPC: grpc.NewServer(), Port: strconv.Itoa(port)}, lis
+}
+
+// Start starts a node joining peers, with the HTTP API, the discovery
+// service and what hooks set up. Start returns once the HTTP API answers,
+// and the node is stopped when the test ends.
+func Start(t *testing.T, peers []string, hooks ...Hook) *Node {
+	t.Helper()
+	n, lis := newNode(t)
+	proto.RegisterDiscoveryServer(n.GRPC, n.Server)
+	for _, hook := range hooks {
+		hook(n.Server, n.GRPC)
+	}
+
+	client.StartClient(n.Server, peers)
+	n.MarkListening()
+	go n.GRPC.Serve(lis)
+	t.Cleanup(n.GRPC.Stop)
+	n.startHTTP(t)
+	return n
+}
+
+// StartHTTP starts only the HTTP API of a node, which is not listening for
+// gRPC and has no peers.
+func StartHTTP(t *testing.T, hooks ...Hook) *Node {
+	t.Helper()
+	n, _ := newNode(t)
+	for _, hook := range hooks {
+		hook(n.Server, n.GRPC)
+	}
+	n.startHTTP(t)
+	return n
+}
+
+func (n *Node) startHTTP(t *testing.T) {
+	t.Helper()
+	web.StartHTTPServer(n.Server, n.Port)
+	httpPort, _ := n.Config().HTTPPort(n.Port)
+	n.URL = "http://localhost" + httpPort
+	up := Eventually(5*time.Second, func() bool {
+		resp, err := http.Get(n.URL + "/healthz")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return true
+	})
+	if !up {
+		t.Fatalf("The HTTP API of %s did not come up", n.Id)
+	}
+}
+
+// Knows reports whether the node has all of nodes as peers.
+func (n *Node) Knows(nodes ...*Node) bool {
+	n.Mu.Lock()
+	defer n.Mu.Unlock()
+	for _, other := range nodes {
+		if !slices.Contains(n.Peers, other.Id) {
+			return false
+		}
+	}
+	return true
+}
+
+// Count reads the count the HTTP API answers at path.
+func (n *Node) Count(t *testing.T, path string) int64 {
+	t.Helper()
+	resp, err := http.Get(n.URL + path)
+	if err != nil {
+		t.Fatalf("Failed to call %s on %s: %v", path, n.Id, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected status from %s on %s: %d", path, n.Id, resp.StatusCode)
+	}
+
+	var result struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode the response from %s: %v", path, err)
+	}
+	return result.Count
+}
+
+// Post posts an empty body to path on the HTTP API and returns the status.
+func (n *Node) Post(t *testing.T, path string) int {
+	t.Helper()
+	resp, err := http.Post(n.URL+path, "", nil)
+	if err != nil {
+		t.Fatalf("Failed to call %s on %s: %v", path, n.Id, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// Counter reads the default counter of the node over gRPC.
+func (n *Node) Counter(t *testing.T) int64 {
+	t.Helper()
+	conn, err := grpc.NewClient(n.Id, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	resp, err := proto.NewDiscoveryClient(conn).GetCounter(ctx, &proto.Empty{})
+	if err != nil {
+		t.Fatalf("GetCounter on %s failed: %v", n.Id, err)
+	}
+	return resp.Counter
+}
+
+// Eventually polls cond until it holds and reports whether it did within
+// timeout.
+func Eventually(timeout time.Duration, cond func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return true
+}
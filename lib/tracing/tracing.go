@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+const instrumentation = "discovery-service"
+
+func init() {
+	// Trace context is passed on even when this node exports nothing, so
+	// traces of other nodes stay connected.
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+}
+
+// NewExporter creates a span exporter: "otlp" sends spans over OTLP/gRPC to
+// endpoint (the OTEL_EXPORTER_OTLP_ENDPOINT default if ""), "stdout" prints
+// them and "none" returns nil.
+func NewExporter(ctx context.Context, kind string, endpoint string, insecure bool) (sdktrace.SpanExporter, error) {
+	switch kind {
+	case "none", "":
+		return nil, nil
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "otlp":
+		var opts []otlptracegrpc.Option
+		if endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(endpoint))
+		}
+		if insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q, expected none, stdout or otlp", kind)
+	}
+}
+
+// Setup installs a tracer provider exporting to exporter in batches as the
+// global provider. Callers shut it down to flush the last spans.
+func Setup(exporter sdktrace.SpanExporter, node string) *sdktrace.TracerProvider {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(sdkresource.NewSchemaless(
+			attribute.String("service.name", instrumentation),
+			attribute.String("service.instance.id", node),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider
+}
+
+// Start starts a span of the node's tracer. Without Setup spans are not
+// recorded.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, opts...)
+}
+
+// Inject adds the trace context of ctx to the outgoing gRPC metadata.
+func Inject(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// Extract returns ctx with the trace context sent in the incoming gRPC
+// metadata.
+func Extract(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+}
+
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,54 @@
+package tracing_test
+
+import (
+	"context"
+	"discovery-service/lib/testnode"
+	"discovery-service/lib/tracing"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIncrementTraceSpansNodes(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.Setup(exporter, "test")
+
+	node1 := testnode.Start(t, nil)
+	node2 := testnode.Start(t, []string{node1.Id})
+	if !testnode.Eventually(10*time.Second, func() bool { return node1.Knows(node2) }) {
+		t.Fatalf("Expected node1 to discover node2")
+	}
+
+	resp, err := http.Post(node1.URL+"/increment", "", nil)
+	if err != nil {
+		t.Fatalf("Failed to call increment API: %v", err)
+	}
+	resp.Body.Close()
+
+	spans := map[string]tracetest.SpanStub{}
+	testnode.Eventually(5*time.Second, func() bool {
+		provider.ForceFlush(context.Background())
+		for _, span := range exporter.GetSpans() {
+			spans[span.Name] = span
+		}
+		_, ok := spans["PropagateIncrement"]
+		return ok
+	})
+	root, ok := spans["increment"]
+	if !ok {
+		t.Fatalf("Expected an increment span, got %v", spans)
+	}
+	for _, name := range []string{"increment.queue", "increment.apply", "increment.propagate", "PropagateIncrement"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Fatalf("Expected a %s span", name)
+		}
+		if span.SpanContext.TraceID() != root.SpanContext.TraceID() {
+			t.Fatalf("Expected %s to be part of the increment trace", name)
+		}
+	}
+	if spans["PropagateIncrement"].Parent.SpanID() != spans["increment.propagate"].SpanContext.SpanID() {
+		t.Fatalf("Expected the peer span to be a child of the propagation span")
+	}
+}
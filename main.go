@@ -1,37 +1,196 @@
 package main
 
 import (
+	"context"
+	"discovery-service/config"
+	"discovery-service/config/reload"
+	"discovery-service/counter/api"
+	"discovery-service/counter/bounded"
+	"discovery-service/counter/hll"
+	"discovery-service/counter/raft"
+	"discovery-service/counter/ratelimit"
+	"discovery-service/counter/window"
+	"discovery-service/discovery/admin"
 	"discovery-service/discovery/client"
+	"discovery-service/discovery/health"
+	"discovery-service/discovery/heartbeat"
+	"discovery-service/lib/logging"
+	"discovery-service/lib/tracing"
 	"discovery-service/models"
 	"discovery-service/proto"
+	"discovery-service/security/apikey"
+	"discovery-service/security/audit"
+	"discovery-service/security/jointoken"
+	"discovery-service/security/mtls"
+	"discovery-service/security/throttle"
 	"discovery-service/web"
 	"flag"
+	"fmt"
 	"google.golang.org/grpc"
-	"log"
+	"log/slog"
 	"net"
+	"os"
 	"strings"
 )
 
 func main() {
-	port := flag.String("port", "8080", "port to listen on")
-	peers := flag.String("peers", "", "comma-separated list of initial peers")
-	flag.Parse()
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-	nodeID := "localhost:" + *port
-	initialPeers := strings.Split(*peers, ",")
+	logs, err := logging.New(os.Stderr, cfg.Log.Format, slog.LevelInfo)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := logs.Configure(cfg.Log.Level); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logs.Logger("main"))
+
+	nodeID := "localhost:" + cfg.Node.Port
 
 	s := models.NewServer(nodeID)
-	client.StartClient(s, initialPeers)
+	s.SetConfig(cfg)
+	s.Log = logs
+	exporter, err := tracing.NewExporter(context.Background(), cfg.Tracing.Exporter, cfg.Tracing.OTLPEndpoint, cfg.Tracing.OTLPInsecure)
+	if err != nil {
+		fatal("Failed to create trace exporter", "err", err)
+	}
+	if exporter != nil {
+		provider := tracing.Setup(exporter, nodeID)
+		defer provider.Shutdown(context.Background())
+	}
+	s.Auth = jointoken.New(cfg.Node.Cluster, cfg.Node.JoinToken)
+	s.Auth.SetTTL(cfg.Node.JoinProofTTL)
+	auditLog, err := audit.New(nodeID, cfg.Audit.File, s.Logger("audit"))
+	if err != nil {
+		fatal("Failed to open audit log", "err", err)
+	}
+	s.Audit = auditLog
+	s.HTTPLimit = throttle.New(cfg.HTTP.Rate, cfg.HTTP.Burst, cfg.RPC.RateIdle)
+	s.MaxRequestBytes = cfg.HTTP.MaxRequestBytes
+	s.ReadOnlyInMinority = cfg.Node.MinorityReadOnly
+	grpcLimit := throttle.New(cfg.RPC.Rate, cfg.RPC.Burst, cfg.RPC.RateIdle)
+	serverOpts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(cfg.RPC.MaxMessageBytes),
+		grpc.ChainUnaryInterceptor(throttle.UnaryServerInterceptor(grpcLimit)),
+		grpc.ChainStreamInterceptor(throttle.StreamServerInterceptor(grpcLimit)),
+		grpc.ChainUnaryInterceptor(s.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(s.StreamServerInterceptor()),
+	}
+	if cfg.TLS.CA != "" {
+		reloader, err := mtls.Load(cfg.TLS.CA, cfg.TLS.Cert, cfg.TLS.Key)
+		if err != nil {
+			fatal("Failed to load TLS certificates", "err", err)
+		}
+		reloader.SetLogger(s.Logger("mtls"))
+		reloader.SetReloadInterval(cfg.TLS.ReloadInterval)
+		s.TLS = reloader
+		serverOpts = append(serverOpts, grpc.Creds(reloader.ServerCredentials()))
+	}
+	if cfg.HTTP.APIKeys != "" {
+		keys, err := apikey.Load(cfg.HTTP.APIKeys)
+		if err != nil {
+			fatal("Failed to load API keys", "err", err)
+		}
+		s.APIKeys = keys
+	}
+	s.Watcher = api.NewHub(s, 0)
+	reloader := reload.New(s, os.Args[1:], grpcLimit)
+	s.Reloader = reloader
+	reloader.OnSignal()
+	client.StartClient(s, cfg.Node.Peers)
 
-	lis, err := net.Listen("tcp", ":"+*port)
+	lis, err := net.Listen("tcp", ":"+cfg.Node.Port)
 	if err != nil {
-		log.Fatalf("Failed to listen: %v", err)
+		fatal("Failed to listen", "err", err)
 	}
 
-	grpcServer := grpc.NewServer()
+	s.MarkListening()
+
+	grpcServer := grpc.NewServer(serverOpts...)
 	proto.RegisterDiscoveryServer(grpcServer, s)
+	health.Register(grpcServer, s)
+	proto.RegisterAdminServer(grpcServer, admin.NewService(s))
+	if cfg.Node.ClientPort == "" {
+		proto.RegisterCounterServiceServer(grpcServer, api.NewService(s))
+	} else {
+		s.ClientPort = cfg.Node.ClientPort
+		clientLis, err := net.Listen("tcp", ":"+cfg.Node.ClientPort)
+		if err != nil {
+			fatal("Failed to listen for clients", "err", err)
+		}
+		// Clients are limited on their own and authenticate with API keys,
+		// not certificates.
+		s.ClientLimit = throttle.New(cfg.RPC.ClientRate, cfg.RPC.ClientBurst, cfg.RPC.RateIdle)
+		clientOpts := []grpc.ServerOption{
+			grpc.MaxRecvMsgSize(cfg.RPC.MaxMessageBytes),
+			grpc.ChainUnaryInterceptor(throttle.UnaryServerInterceptor(s.ClientLimit)),
+			grpc.ChainStreamInterceptor(throttle.StreamServerInterceptor(s.ClientLimit)),
+		}
+		if s.TLS != nil {
+			clientOpts = append(clientOpts, grpc.Creds(s.TLS.ClientAPICredentials()))
+		}
+		clientServer := grpc.NewServer(clientOpts...)
+		proto.RegisterCounterServiceServer(clientServer, api.NewService(s))
+		health.Register(clientServer, s)
+		go func() {
+			slog.Info("Client API listening", "port", cfg.Node.ClientPort)
+			if err := clientServer.Serve(clientLis); err != nil {
+				fatal("Client API failed", "err", err)
+			}
+		}()
+	}
+
+	if len(cfg.Counters.Strong) > 0 {
+		node, err := raft.NewNode(s, cfg.Counters.Strong, cfg.Counters.RaftBootstrap)
+		if err != nil {
+			fatal("Failed to start raft", "err", err)
+		}
+		proto.RegisterRaftServer(grpcServer, node)
+		s.Strong = node
+		node.Start()
+	}
 
-	log.Printf("Node %s is running...", nodeID)
-	web.StartHTTPServer(s, *port)
+	if len(cfg.Counters.Bounded) > 0 {
+		parsed, err := bounded.ParseLimits(strings.Join(cfg.Counters.Bounded, ","))
+		if err != nil {
+			fatal("Invalid --bounded", "err", err)
+		}
+		counters := bounded.NewCounters(s, parsed, cfg.Counters.BoundedSeed)
+		proto.RegisterEscrowServer(grpcServer, counters)
+		s.Bounded = counters
+	}
+
+	if cfg.Counters.RateWindow < window.BucketSize {
+		fatal("Invalid --rate-window", "minimum", window.BucketSize)
+	}
+	windowed := window.NewCounters(s, cfg.Counters.RateWindow)
+	proto.RegisterWindowServer(grpcServer, windowed)
+	heartbeat.RegisterRecoveryAction(windowed)
+	s.Windowed = windowed
+	windowed.Start()
+	proto.RegisterRateLimiterServer(grpcServer, ratelimit.NewService(s))
+
+	distinct, err := hll.NewCounters(s, cfg.Counters.HLLPrecision)
+	if err != nil {
+		fatal("Invalid --hll-precision", "err", err)
+	}
+	proto.RegisterDistinctServer(grpcServer, distinct)
+	heartbeat.RegisterRecoveryAction(distinct)
+	s.Distinct = distinct
+	distinct.Start()
+
+	slog.Info("Node is running", "node", nodeID)
+	web.StartHTTPServer(s, cfg.Node.Port)
 	grpcServer.Serve(lis)
 }
+
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
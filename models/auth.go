@@ -0,0 +1,167 @@
+package models
+
+import (
+	"context"
+	pb "discovery-service/proto"
+	"discovery-service/security/apikey"
+	"discovery-service/security/audit"
+	"discovery-service/security/jointoken"
+	"discovery-service/security/mtls"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"slices"
+	"strings"
+)
+
+// Challenge issues a join challenge for a node about to register or send a
+// heartbeat.
+func (s *Server) Challenge(ctx context.Context, req *pb.ChallengeRequest) (*pb.ChallengeResponse, error) {
+	if s.Auth == nil {
+		return &pb.ChallengeResponse{}, nil
+	}
+	return &pb.ChallengeResponse{Cluster: s.Auth.Cluster(), Nonce: s.Auth.Challenge()}, nil
+}
+
+// NewRegisterRequest builds a Register request for the peer behind client,
+// answering its join challenge.
+func (s *Server) NewRegisterRequest(ctx context.Context, client pb.DiscoveryClient) (*pb.RegisterRequest, error) {
+	cluster, nonce, proof, err := s.answerChallenge(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.RegisterRequest{Id: s.Id, Cluster: cluster, Nonce: nonce, Proof: proof}, nil
+}
+
+// NewHeartbeatRequest builds a Heartbeat request for the peer behind client,
+// answering its join challenge.
+func (s *Server) NewHeartbeatRequest(ctx context.Context, client pb.DiscoveryClient) (*pb.HeartbeatRequest, error) {
+	cluster, nonce, proof, err := s.answerChallenge(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.HeartbeatRequest{Id: s.Id, Cluster: cluster, Nonce: nonce, Proof: proof}, nil
+}
+
+func (s *Server) answerChallenge(ctx context.Context, client pb.DiscoveryClient) (string, string, []byte, error) {
+	if s.Auth == nil {
+		return "", "", nil, nil
+	}
+	resp, err := client.Challenge(ctx, &pb.ChallengeRequest{Id: s.Id})
+	if err != nil {
+		return "", "", nil, err
+	}
+	return s.Auth.Cluster(), resp.Nonce, s.Auth.Proof(s.Id, resp.Nonce), nil
+}
+
+// authenticate checks the caller's certificate and cluster membership.
+// Nodes of another cluster get FailedPrecondition so they can tell a
+// misconfiguration apart from a bad token.
+func (s *Server) authenticate(ctx context.Context, action string, id string, cluster string, nonce string, proof []byte) error {
+	if err := mtls.VerifyPeerID(ctx, id); err != nil {
+		return s.reject(action, id, status.Error(codes.PermissionDenied, err.Error()))
+	}
+	if s.Auth == nil {
+		return nil
+	}
+	if err := s.Auth.Verify(cluster, id, nonce, proof); err != nil {
+		return s.reject(action, id, err)
+	}
+	return nil
+}
+
+// peerServices are the gRPC services only nodes of the cluster may call.
+var peerServices = []string{"/discovery.Discovery/", "/discovery.Raft/", "/discovery.Escrow/", "/discovery.Window/", "/discovery.Distinct/"}
+
+// challenged methods check the join challenge they carry themselves, as the
+// caller is not a member yet. The clientReadable ones also serve counterctl,
+// which proves itself with a read API key instead.
+var (
+	challenged = map[string]bool{
+		"/discovery.Discovery/Challenge": true,
+		"/discovery.Discovery/Register":  true,
+		"/discovery.Discovery/Heartbeat": true,
+	}
+	clientReadable = map[string]bool{
+		"/discovery.Discovery/GetPeers":  true,
+		"/discovery.Discovery/GetStatus": true,
+	}
+)
+
+// authorizeCall checks that the caller of a peer service method proved its
+// cluster membership with jointoken.Credentials, and that its certificate
+// matches the node it claims to be.
+func (s *Server) authorizeCall(ctx context.Context, method string) error {
+	if s.Auth == nil || challenged[method] || !slices.ContainsFunc(peerServices, func(service string) bool {
+		return strings.HasPrefix(method, service)
+	}) {
+		return nil
+	}
+	id, err := s.Auth.VerifyCall(ctx)
+	if err == nil {
+		if err = mtls.VerifyPeerID(ctx, id); err != nil {
+			err = status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+	if err == nil {
+		return nil
+	}
+	if clientReadable[method] {
+		if _, keyErr := s.APIKeys.AuthorizeRPC(ctx, apikey.Read, ""); keyErr == nil {
+			return nil
+		}
+	}
+	return s.reject(method, id, err)
+}
+
+// reject logs and audits a refused peer and returns the status error for
+// err. Nodes of another cluster get FailedPrecondition so they can tell a
+// misconfiguration apart from a bad token.
+func (s *Server) reject(action string, id string, err error) error {
+	attrs := []any{"action", action, "peer", id, "err", err}
+	if s.Auth != nil {
+		attrs = append(attrs, "rejected", s.Auth.Rejected())
+	}
+	s.Logger("discovery").Warn("Rejected peer", attrs...)
+	s.Audit.Record(audit.PeerRejected, id, id, err.Error())
+	switch {
+	case status.Code(err) != codes.Unknown:
+		return err
+	case errors.Is(err, jointoken.ErrWrongCluster):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+}
+
+// UnaryServerInterceptor refuses calls to the peer services from callers
+// that are not members of the cluster.
+func (s *Server) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := s.authorizeCall(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming calls.
+func (s *Server) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := s.authorizeCall(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// DialOptions are the options nodes dial each other with: the transport
+// credentials and, with cluster authentication, the proof sent on every call.
+func (s *Server) DialOptions() []grpc.DialOption {
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(s.TransportCredentials())}
+	if s.Auth != nil {
+		opts = append(opts, grpc.WithPerRPCCredentials(s.Auth.Credentials(s.Id)))
+	}
+	return opts
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// health tracks the startup milestones readiness depends on.
+type health struct {
+	synced    atomic.Bool
+	listening atomic.Bool
+	applying  atomic.Bool
+}
+
+// MarkSynced records that initial discovery and counter sync finished.
+func (s *Server) MarkSynced() {
+	s.health.synced.Store(true)
+}
+
+// MarkListening records that the gRPC listener accepts connections.
+func (s *Server) MarkListening() {
+	s.health.listening.Store(true)
+}
+
+// Liveness checks that the process can make progress. Each check maps to
+// nil or the reason it failed.
+func (s *Server) Liveness() map[string]error {
+	checks := map[string]error{"increment_consumer": nil}
+	if !s.health.applying.Load() {
+		checks["increment_consumer"] = errors.New("increment consumer is not running")
+	}
+	return checks
+}
+
+// Readiness checks that the node can serve consistent answers. The majority
+// check reads the partition state of the last heartbeat round, so probes do
+// not change it.
+func (s *Server) Readiness() map[string]error {
+	checks := s.Liveness()
+	checks["discovery"] = nil
+	if !s.health.synced.Load() {
+		checks["discovery"] = errors.New("initial discovery and counter sync are not complete")
+	}
+	checks["grpc_listener"] = nil
+	if !s.health.listening.Load() {
+		checks["grpc_listener"] = errors.New("gRPC listener is not up")
+	}
+	checks["majority"] = nil
+	if s.IsPartitioned() {
+		checks["majority"] = errors.New("partitioned from the majority of the cluster")
+	}
+	return checks
+}
+
+// Healthy reports whether all checks passed.
+func Healthy(checks map[string]error) bool {
+	for _, err := range checks {
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"discovery-service/lib/tracing"
+	"encoding/hex"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// IncrementOp is an increment waiting in IncrementChan to be applied.
+type IncrementOp struct {
+	ID     string
+	Ctx    context.Context // Trace of the request that caused the increment
+	queued trace.Span
+}
+
+// QueueIncrement hands an increment to ApplyIncrements, tracing the time it
+// waits in IncrementChan.
+func (s *Server) QueueIncrement(ctx context.Context, opID string) {
+	ctx, span := tracing.Start(ctx, "increment.queue", trace.WithAttributes(attribute.String("op.id", opID)))
+	s.IncrementChan <- IncrementOp{ID: opID, Ctx: ctx, queued: span}
+}
+
+// ClientOpID returns the op ID of the i-th increment a client sent with an
+// idempotency key. Keys are only unique per client and counter, so both are
+// part of the op ID, which is hashed to a format server op IDs never have.
+func ClientOpID(client string, counter string, key string, i int64) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%d:%s%d:%s%d:%s%d", len(client), client, len(counter), counter, len(key), key, i))
+	return "client-" + hex.EncodeToString(sum[:16])
+}
+
+// ClaimOp marks opID as seen before it is queued and reports whether it is
+// new, so an op sent twice, e.g. by a client retrying, is applied once.
+func (s *Server) ClaimOp(opID string) bool {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	if s.SeenOps == nil {
+		s.SeenOps = make(map[string]bool)
+	}
+	if s.SeenOps[opID] {
+		return false
+	}
+	s.SeenOps[opID] = true
+	return true
+}
+
+// ApplyIncrements applies queued increments to the counter until
+// IncrementChan is closed.
+func (s *Server) ApplyIncrements() {
+	s.health.applying.Store(true)
+	defer s.health.applying.Store(false)
+	for op := range s.IncrementChan {
+		if op.queued != nil {
+			op.queued.End()
+		}
+		ctx := op.Ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		_, span := tracing.Start(ctx, "increment.apply", trace.WithAttributes(attribute.String("op.id", op.ID)))
+		s.Mu.Lock()
+		s.SeenOps[op.ID] = true
+		s.Counter++
+		s.Mu.Unlock()
+		s.Metrics.IncrementsApplied.Inc()
+		span.End()
+	}
+}
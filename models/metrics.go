@@ -0,0 +1,68 @@
+package models
+
+import (
+	"discovery-service/lib/metrics"
+)
+
+// Metrics are the metrics of a node, served on /metrics. Values that are
+// part of the server state are read when metrics are scraped, events are
+// counted where they happen.
+type Metrics struct {
+	Registry *metrics.Registry
+
+	IncrementsApplied      *metrics.Counter
+	IncrementsDeduplicated *metrics.Counter
+	PropagationFailures    *metrics.Counter   // By peer
+	HeartbeatDuration      *metrics.Histogram // By peer and result
+	PartitionTransitions   *metrics.Counter
+}
+
+func newMetrics(s *Server) *Metrics {
+	r := metrics.NewRegistry()
+	m := &Metrics{
+		Registry:               r,
+		IncrementsApplied:      r.NewCounter("discovery_increments_applied_total", "Increments applied to the counter of this node."),
+		IncrementsDeduplicated: r.NewCounter("discovery_increments_deduplicated_total", "Propagated increments ignored because they were already applied."),
+		PropagationFailures:    r.NewCounter("discovery_propagation_failures_total", "Increments that could not be propagated to a peer.", "peer"),
+		HeartbeatDuration:      r.NewHistogram("discovery_heartbeat_duration_seconds", "Duration of heartbeats sent to peers.", metrics.DefaultBuckets, "peer", "result"),
+		PartitionTransitions:   r.NewCounter("discovery_partition_transitions_total", "Times this node lost or regained the majority of the cluster."),
+	}
+
+	r.NewGaugeFunc("discovery_counter_value", "Value of the counter on this node.", func() float64 {
+		s.Mu.Lock()
+		defer s.Mu.Unlock()
+		return float64(s.Counter)
+	})
+	r.NewGaugeVecFunc("discovery_peers", "Known peers by state, including this node.", "state", func() map[string]float64 {
+		s.Mu.Lock()
+		defer s.Mu.Unlock()
+		return map[string]float64{"live": float64(len(s.Peers)), "dead": float64(len(s.DeadPeers))}
+	})
+	r.NewGaugeVecFunc("discovery_missed_ops", "Increments waiting to be resent to a peer.", "peer", func() map[string]float64 {
+		s.Mu.Lock()
+		defer s.Mu.Unlock()
+		depth := map[string]float64{}
+		for peer, ops := range s.MissedOps {
+			depth[peer] = float64(len(ops))
+		}
+		return depth
+	})
+	r.NewGaugeFunc("discovery_seen_ops", "Operation ids remembered for deduplication.", func() float64 {
+		s.Mu.Lock()
+		defer s.Mu.Unlock()
+		return float64(len(s.SeenOps))
+	})
+	r.NewGaugeFunc("discovery_partitioned", "1 while this node cannot reach the majority of the cluster.", func() float64 {
+		if s.IsPartitioned() {
+			return 1
+		}
+		return 0
+	})
+	r.NewGaugeFunc("discovery_join_rejections", "Join attempts and heartbeats rejected by the cluster membership check.", func() float64 {
+		if s.Auth == nil {
+			return 0
+		}
+		return float64(s.Auth.Rejected())
+	})
+	return m
+}
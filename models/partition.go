@@ -0,0 +1,40 @@
+package models
+
+import (
+	"discovery-service/security/audit"
+	"fmt"
+)
+
+// CheckPartition updates Partitioned from the current membership and
+// returns it. A node is partitioned when it cannot reach a strict majority
+// of the nodes it knows about, live or dead, itself included. Transitions
+// are logged, audited and counted.
+func (s *Server) CheckPartition() bool {
+	s.Mu.Lock()
+	live, known := len(s.Peers), len(s.Peers)+len(s.DeadPeers)
+	partitioned := live*2 <= known
+	changed := partitioned != s.Partitioned
+	s.Partitioned = partitioned
+	s.Mu.Unlock()
+
+	if !changed {
+		return partitioned
+	}
+	detail := fmt.Sprintf("%d of %d known nodes reachable", live, known)
+	s.Metrics.PartitionTransitions.Inc()
+	if partitioned {
+		s.Logger("discovery").Warn("Lost contact with the majority of the cluster", "live", live, "known", known)
+		s.Audit.Record(audit.PartitionEntered, s.Id, s.Id, detail)
+	} else {
+		s.Logger("discovery").Info("Reached the majority of the cluster again", "live", live, "known", known)
+		s.Audit.Record(audit.PartitionHealed, s.Id, s.Id, detail)
+	}
+	return partitioned
+}
+
+// IsPartitioned returns the partition state found by the last check.
+func (s *Server) IsPartitioned() bool {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	return s.Partitioned
+}
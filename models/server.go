@@ -2,31 +2,111 @@ package models
 
 import (
 	"context"
+	"discovery-service/config"
 	"discovery-service/lib/arrays"
+	"discovery-service/lib/logging"
+	"discovery-service/lib/tracing"
 	pb "discovery-service/proto"
+	"discovery-service/security/apikey"
+	"discovery-service/security/audit"
+	"discovery-service/security/jointoken"
+	"discovery-service/security/mtls"
+	"discovery-service/security/throttle"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
-	"log"
+	"log/slog"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Server struct {
 	pb.UnimplementedDiscoveryServer
-	Id            string
-	Peers         []string
-	DeadPeers     []string
-	Mu            sync.Mutex
-	Counter       int64
-	MissedOps     map[string][]string // new field
-	Partitioned   bool
-	SeenOps       map[string]bool             // For deduplication
-	ConnPool      map[string]*grpc.ClientConn // Pool for active peer connections
-	IncrementChan chan string
+	Id                 string
+	Peers              []string
+	DeadPeers          []string
+	Mu                 sync.Mutex
+	Counter            int64
+	MissedOps          map[string][]string          // new field
+	MissedOpTraces     map[string]trace.SpanContext // Trace of each missed op, by peer and op id
+	Partitioned        bool                         // Set by CheckPartition when the majority is unreachable
+	ReadOnlyInMinority bool                         // Refuse writes while partitioned
+	SeenOps            map[string]bool              // For deduplication
+	ConnPool           map[string]*grpc.ClientConn  // Pool for active peer connections
+	PeerHealth         map[string]*PeerHealth       // Latest heartbeat results by peer
+	IncrementChan      chan IncrementOp
+	Strong             StrongCounters  // Raft backed counters, nil unless enabled
+	Bounded            BoundedCounters // Escrow limited counters, nil unless enabled
+	Windowed           WindowedCounters
+	Distinct           DistinctCounters
+	Watcher            CounterWatcher           // Streams counter changes to clients
+	ClientPort         string                   // Port of the CounterService if not served with the peer RPCs
+	Reloader           ConfigReloader           // Reloads the configuration, nil if it cannot be reloaded
+	TLS                *mtls.Reloader           // Certificates for mutual TLS, nil means plaintext
+	Auth               *jointoken.Authenticator // Cluster membership check, nil admits any node
+	APIKeys            *apikey.Store            // HTTP API keys, nil leaves the API open
+	HTTPLimit          *throttle.Limiter        // Per-client HTTP request rate, nil is unlimited
+	ClientLimit        *throttle.Limiter        // Per-client call rate of the client port, nil is unlimited
+	MaxRequestBytes    int64                    // Largest HTTP request body, 0 is unlimited
+	Audit              *audit.Log               // Membership and admin actions, nil disables auditing
+	Log                *logging.Logging         // Loggers of all subsystems
+	Metrics            *Metrics
+
+	config atomic.Pointer[config.Config]
+	health health
+}
+
+// StrongCounters are counters replicated through a consensus log instead of
+// eventually consistent propagation.
+type StrongCounters interface {
+	Strong(name string) bool
+	Increment(ctx context.Context, name string, delta int64) (int64, error)
+	Get(ctx context.Context, name string) (int64, error)
+}
+
+// BoundedCounters are counters that must never exceed a cluster-wide limit.
+type BoundedCounters interface {
+	Bounded(name string) bool
+	Increment(ctx context.Context, name string, delta int64) (int64, error)
+	Get(ctx context.Context, name string) (used int64, limit int64, err error)
+}
+
+// WindowedCounters count increments over a sliding time window, any name that
+// is not a strong or bounded counter is a windowed counter.
+type WindowedCounters interface {
+	Increment(name string, delta int64)
+	Count(name string, window time.Duration) (int64, error)
+	Buckets(name string, window time.Duration) (start time.Time, counts []int64, err error)
+	Retention() time.Duration
+}
+
+// CounterWatcher sends the changes of a counter to clients watching it.
+type CounterWatcher interface {
+	Subscribe(ctx context.Context, counter string, epoch string, since uint64, maxRate float64) (<-chan *pb.CounterValue, error)
+}
+
+// ConfigReloader reads the configuration again, applying the settings that
+// can change while the node runs. Actor is who asked for it, for the audit
+// log.
+type ConfigReloader interface {
+	Reload(actor string) (config.Changes, error)
+}
+
+// DistinctCounters estimate how many distinct items were added to a counter.
+type DistinctCounters interface {
+	Add(name string, item string)
+	Cardinality(name string) (estimate uint64, stdError float64, ok bool)
+	Precision() int
 }
 
 func (s *Server) GetOrCreateConnection(peer string) *grpc.ClientConn {
+	s.Mu.Lock()
 	existingConn, exists := s.ConnPool[peer]
+	s.Mu.Unlock()
 
 	// Return the existing connection if it's available
 	if exists && existingConn != nil {
@@ -35,8 +115,9 @@ func (s *Server) GetOrCreateConnection(peer string) *grpc.ClientConn {
 	// If no connection exists, try to create a new one
 	var conn *grpc.ClientConn
 	var err error
-	for attempt := 0; attempt < 5; attempt++ {
-		conn, err = grpc.NewClient(peer, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	rpc := s.Config().RPC
+	for attempt := 0; attempt < rpc.DialRetries; attempt++ {
+		conn, err = grpc.NewClient(peer, s.DialOptions()...)
 		if err == nil {
 			s.Mu.Lock()
 			s.ConnPool[peer] = conn
@@ -44,24 +125,63 @@ func (s *Server) GetOrCreateConnection(peer string) *grpc.ClientConn {
 			return conn
 		}
 
-		log.Printf("Failed to connect to %s (attempt %d): %v", peer, attempt+1, err)
-		time.Sleep(time.Second * time.Duration(attempt+1))
+		s.Logger("discovery").Warn("Failed to connect to peer", "peer", peer, "attempt", attempt+1, "err", err)
+		time.Sleep(rpc.DialBackoff * time.Duration(attempt+1))
 	}
 
-	log.Printf("Unable to establish connection to %s after %d retries", peer, 5)
+	s.Logger("discovery").Error("Unable to establish connection to peer", "peer", peer, "retries", rpc.DialRetries)
 	return nil
 }
 
+// Config returns the configuration of the node, the defaults if none was
+// set.
+func (s *Server) Config() *config.Config {
+	if c := s.config.Load(); c != nil {
+		return c
+	}
+	return defaultConfig
+}
+
+// SetConfig replaces the configuration of the node. Settings are read when
+// they are used, so c must not be modified afterwards.
+func (s *Server) SetConfig(c *config.Config) {
+	s.config.Store(c)
+}
+
+var defaultConfig = config.Default()
+
+// Logger returns the logger of subsystem, the default logger if the server
+// was built without NewServer.
+func (s *Server) Logger(subsystem string) *slog.Logger {
+	if s.Log == nil {
+		return slog.Default().With("subsystem", subsystem)
+	}
+	return s.Log.Logger(subsystem)
+}
+
+// TransportCredentials returns the credentials used to dial peers.
+func (s *Server) TransportCredentials() credentials.TransportCredentials {
+	if s.TLS == nil {
+		return insecure.NewCredentials()
+	}
+	return s.TLS.ClientCredentials()
+}
+
 // Register handles peer registration and returns the updated peer list.
 func (s *Server) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	if err := s.authenticate(ctx, "registration", req.Id, req.Cluster, req.Nonce, req.Proof); err != nil {
+		return nil, err
+	}
+
 	s.Mu.Lock()
 	defer s.Mu.Unlock()
 
-	log.Printf("Registering peer: %s", req.Id)
+	s.Logger("discovery").Info("Registering peer", "peer", req.Id)
 
 	// Add peer if not already present
 	if !arrays.Contains(s.Peers, req.Id) {
 		s.Peers = append(s.Peers, req.Id)
+		s.Audit.Record(audit.PeerRegistered, req.Id, req.Id, "")
 	}
 
 	// Return the updated list of peers
@@ -77,27 +197,29 @@ func (s *Server) GetPeers(ctx context.Context, _ *pb.Empty) (*pb.PeersResponse,
 
 // Heartbeat checks if the peer is alive.
 func (s *Server) Heartbeat(ctx context.Context, req *pb.HeartbeatRequest) (*pb.HeartbeatResponse, error) {
-	log.Printf("Received heartbeat from %s", req.Id)
-	return &pb.HeartbeatResponse{Alive: true}, nil
+	if err := s.authenticate(ctx, "heartbeat", req.Id, req.Cluster, req.Nonce, req.Proof); err != nil {
+		return nil, err
+	}
+	s.Logger("heartbeat").Debug("Received heartbeat", "peer", req.Id)
+	return &pb.HeartbeatResponse{Alive: true, ClientPort: s.ClientPort}, nil
 }
 
 func (s *Server) PropagateIncrement(ctx context.Context, req *pb.IncrementRequest) (*pb.IncrementResponse, error) {
-	s.Mu.Lock()
-	defer s.Mu.Unlock()
-
-	if s.SeenOps == nil {
-		s.SeenOps = make(map[string]bool)
-	}
-
-	if s.SeenOps[req.Id] {
-		return &pb.IncrementResponse{Success: true}, nil
+	ctx, span := tracing.Start(tracing.Extract(ctx), "PropagateIncrement",
+		trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attribute.String("op.id", req.Id), attribute.Int("op.count", 1+len(req.MoreIds))))
+	defer span.End()
+
+	duplicates := 0
+	for _, opID := range append([]string{req.Id}, req.MoreIds...) {
+		if !s.ClaimOp(opID) {
+			s.Metrics.IncrementsDeduplicated.Inc()
+			duplicates++
+			continue
+		}
+		s.QueueIncrement(ctx, opID)
+		s.Logger("increment").Debug("Counter incremented via propagation", "op", opID)
 	}
-
-	s.IncrementChan <- req.Id
-	//s.Counter++
-	//s.SeenOps[req.Id] = true
-
-	log.Printf("Counter incremented via propagation: %d", s.Counter)
+	span.SetAttributes(attribute.Bool("op.duplicate", duplicates == 1+len(req.MoreIds)))
 	return &pb.IncrementResponse{Success: true}, nil
 }
 
@@ -112,6 +234,8 @@ func NewServer(nodeId string) *Server {
 	s.Id = nodeId
 	s.Peers = []string{nodeId}
 	s.SeenOps = make(map[string]bool)
-	s.IncrementChan = make(chan string)
+	s.IncrementChan = make(chan IncrementOp)
+	s.Log, _ = logging.New(os.Stderr, "text", slog.LevelInfo)
+	s.Metrics = newMetrics(s)
 	return s
 }
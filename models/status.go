@@ -0,0 +1,106 @@
+package models
+
+import (
+	"context"
+	"discovery-service/lib/arrays"
+	pb "discovery-service/proto"
+	"net"
+	"time"
+)
+
+// PeerHealth is the outcome of the latest heartbeats to a peer.
+type PeerHealth struct {
+	Failures   int           // Consecutive failed heartbeats
+	Latency    time.Duration // Round trip of the last successful heartbeat
+	ClientAddr string        // Address of the peer's CounterService, from its last successful heartbeat
+}
+
+// RecordHeartbeat records the outcome of a heartbeat sent to peer and, if
+// it succeeded, the port of the peer's CounterService it answered with.
+func (s *Server) RecordHeartbeat(peer string, latency time.Duration, clientPort string, ok bool) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	if s.PeerHealth == nil {
+		s.PeerHealth = map[string]*PeerHealth{}
+	}
+	h, exists := s.PeerHealth[peer]
+	if !exists {
+		h = &PeerHealth{}
+		s.PeerHealth[peer] = h
+	}
+	if ok {
+		h.Failures = 0
+		h.Latency = latency
+		h.ClientAddr = clientAddr(peer, clientPort)
+	} else {
+		h.Failures++
+	}
+}
+
+// Status returns this node's view of the cluster. Live peers that failed
+// their latest heartbeats are reported as suspect.
+func (s *Server) Status() *pb.NodeStatus {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	status := &pb.NodeStatus{
+		Id:          s.Id,
+		DeadPeers:   append([]string{}, s.DeadPeers...),
+		Counter:     s.Counter,
+		MissedOps:   map[string]int64{},
+		HeartbeatMs: map[string]float64{},
+		Partitioned: s.Partitioned,
+	}
+	for _, peer := range arrays.Remove(s.Peers, s.Id) {
+		if h := s.PeerHealth[peer]; h != nil && h.Failures > 0 {
+			status.SuspectPeers = append(status.SuspectPeers, peer)
+		} else {
+			status.LivePeers = append(status.LivePeers, peer)
+		}
+	}
+	for peer, ops := range s.MissedOps {
+		if len(ops) > 0 {
+			status.MissedOps[peer] = int64(len(ops))
+		}
+	}
+	for peer, h := range s.PeerHealth {
+		if h.Latency > 0 {
+			status.HeartbeatMs[peer] = float64(h.Latency.Microseconds()) / 1000
+		}
+	}
+	return status
+}
+
+// ClientAddrs returns the CounterService addresses of this node and of the
+// live peers, this node first. Peers that have not answered a heartbeat yet
+// are left out, as their address is not known.
+func (s *Server) ClientAddrs() []string {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	addrs := []string{clientAddr(s.Id, s.ClientPort)}
+	for _, peer := range arrays.Remove(s.Peers, s.Id) {
+		if h := s.PeerHealth[peer]; h != nil && h.Failures == 0 && h.ClientAddr != "" {
+			addrs = append(addrs, h.ClientAddr)
+		}
+	}
+	return addrs
+}
+
+// clientAddr returns the CounterService address of the node id, which is
+// served on port of the same host, or with the peer RPCs if port is empty.
+func clientAddr(id string, port string) string {
+	if port == "" {
+		return id
+	}
+	host, _, err := net.SplitHostPort(id)
+	if err != nil {
+		return id
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// GetStatus returns this node's view of the cluster to the dashboard of
+// another node.
+func (s *Server) GetStatus(ctx context.Context, _ *pb.Empty) (*pb.NodeStatus, error) {
+	return s.Status(), nil
+}
@@ -7,6 +7,7 @@
 package proto
 
 import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -21,6 +22,55 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type EntryType int32
+
+const (
+	EntryType_ENTRY_NOOP       EntryType = 0
+	EntryType_ENTRY_INCREMENT  EntryType = 1
+	EntryType_ENTRY_MEMBERSHIP EntryType = 2
+)
+
+// Enum value maps for EntryType.
+var (
+	EntryType_name = map[int32]string{
+		0: "ENTRY_NOOP",
+		1: "ENTRY_INCREMENT",
+		2: "ENTRY_MEMBERSHIP",
+	}
+	EntryType_value = map[string]int32{
+		"ENTRY_NOOP":       0,
+		"ENTRY_INCREMENT":  1,
+		"ENTRY_MEMBERSHIP": 2,
+	}
+)
+
+func (x EntryType) Enum() *EntryType {
+	p := new(EntryType)
+	*p = x
+	return p
+}
+
+func (x EntryType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EntryType) Descriptor() protoreflect.EnumDescriptor {
+	return file_discovery_proto_enumTypes[0].Descriptor()
+}
+
+func (EntryType) Type() protoreflect.EnumType {
+	return &file_discovery_proto_enumTypes[0]
+}
+
+func (x EntryType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EntryType.Descriptor instead.
+func (EntryType) EnumDescriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{0}
+}
+
 type CounterResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Counter       int64                  `protobuf:"varint,1,opt,name=counter,proto3" json:"counter,omitempty"`
@@ -68,6 +118,9 @@ func (x *CounterResponse) GetCounter() int64 {
 type RegisterRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Cluster       string                 `protobuf:"bytes,2,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	Nonce         string                 `protobuf:"bytes,3,opt,name=nonce,proto3" json:"nonce,omitempty"` // From Challenge, answered by proof
+	Proof         []byte                 `protobuf:"bytes,4,opt,name=proof,proto3" json:"proof,omitempty"` // HMAC of cluster, id and nonce keyed by the join token
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -109,6 +162,27 @@ func (x *RegisterRequest) GetId() string {
 	return ""
 }
 
+func (x *RegisterRequest) GetCluster() string {
+	if x != nil {
+		return x.Cluster
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetProof() []byte {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
 type RegisterResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Peers         []string               `protobuf:"bytes,1,rep,name=peers,proto3" json:"peers,omitempty"`
@@ -156,6 +230,9 @@ func (x *RegisterResponse) GetPeers() []string {
 type HeartbeatRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Cluster       string                 `protobuf:"bytes,2,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	Nonce         string                 `protobuf:"bytes,3,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Proof         []byte                 `protobuf:"bytes,4,opt,name=proof,proto3" json:"proof,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -197,16 +274,235 @@ func (x *HeartbeatRequest) GetId() string {
 	return ""
 }
 
+func (x *HeartbeatRequest) GetCluster() string {
+	if x != nil {
+		return x.Cluster
+	}
+	return ""
+}
+
+func (x *HeartbeatRequest) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+func (x *HeartbeatRequest) GetProof() []byte {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+type ChallengeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChallengeRequest) Reset() {
+	*x = ChallengeRequest{}
+	mi := &file_discovery_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChallengeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChallengeRequest) ProtoMessage() {}
+
+func (x *ChallengeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChallengeRequest.ProtoReflect.Descriptor instead.
+func (*ChallengeRequest) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ChallengeRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ChallengeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cluster       string                 `protobuf:"bytes,1,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	Nonce         string                 `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"` // Single use, expires after 30 seconds
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChallengeResponse) Reset() {
+	*x = ChallengeResponse{}
+	mi := &file_discovery_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChallengeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChallengeResponse) ProtoMessage() {}
+
+func (x *ChallengeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChallengeResponse.ProtoReflect.Descriptor instead.
+func (*ChallengeResponse) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ChallengeResponse) GetCluster() string {
+	if x != nil {
+		return x.Cluster
+	}
+	return ""
+}
+
+func (x *ChallengeResponse) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+// NodeStatus is one node's view of the cluster, shown on the dashboard.
+type NodeStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	LivePeers     []string               `protobuf:"bytes,2,rep,name=live_peers,json=livePeers,proto3" json:"live_peers,omitempty"`
+	SuspectPeers  []string               `protobuf:"bytes,3,rep,name=suspect_peers,json=suspectPeers,proto3" json:"suspect_peers,omitempty"` // Alive but failing heartbeats
+	DeadPeers     []string               `protobuf:"bytes,4,rep,name=dead_peers,json=deadPeers,proto3" json:"dead_peers,omitempty"`
+	Counter       int64                  `protobuf:"varint,5,opt,name=counter,proto3" json:"counter,omitempty"`
+	MissedOps     map[string]int64       `protobuf:"bytes,6,rep,name=missed_ops,json=missedOps,proto3" json:"missed_ops,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`        // Queued resends by peer
+	HeartbeatMs   map[string]float64     `protobuf:"bytes,7,rep,name=heartbeat_ms,json=heartbeatMs,proto3" json:"heartbeat_ms,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"` // Last heartbeat round trip by peer
+	Partitioned   bool                   `protobuf:"varint,8,opt,name=partitioned,proto3" json:"partitioned,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeStatus) Reset() {
+	*x = NodeStatus{}
+	mi := &file_discovery_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeStatus) ProtoMessage() {}
+
+func (x *NodeStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeStatus.ProtoReflect.Descriptor instead.
+func (*NodeStatus) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *NodeStatus) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *NodeStatus) GetLivePeers() []string {
+	if x != nil {
+		return x.LivePeers
+	}
+	return nil
+}
+
+func (x *NodeStatus) GetSuspectPeers() []string {
+	if x != nil {
+		return x.SuspectPeers
+	}
+	return nil
+}
+
+func (x *NodeStatus) GetDeadPeers() []string {
+	if x != nil {
+		return x.DeadPeers
+	}
+	return nil
+}
+
+func (x *NodeStatus) GetCounter() int64 {
+	if x != nil {
+		return x.Counter
+	}
+	return 0
+}
+
+func (x *NodeStatus) GetMissedOps() map[string]int64 {
+	if x != nil {
+		return x.MissedOps
+	}
+	return nil
+}
+
+func (x *NodeStatus) GetHeartbeatMs() map[string]float64 {
+	if x != nil {
+		return x.HeartbeatMs
+	}
+	return nil
+}
+
+func (x *NodeStatus) GetPartitioned() bool {
+	if x != nil {
+		return x.Partitioned
+	}
+	return false
+}
+
 type HeartbeatResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Alive         bool                   `protobuf:"varint,1,opt,name=alive,proto3" json:"alive,omitempty"`
+	ClientPort    string                 `protobuf:"bytes,2,opt,name=client_port,json=clientPort,proto3" json:"client_port,omitempty"` // Port of the CounterService, empty if served with the peer RPCs
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *HeartbeatResponse) Reset() {
 	*x = HeartbeatResponse{}
-	mi := &file_discovery_proto_msgTypes[4]
+	mi := &file_discovery_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -218,7 +514,7 @@ func (x *HeartbeatResponse) String() string {
 func (*HeartbeatResponse) ProtoMessage() {}
 
 func (x *HeartbeatResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_discovery_proto_msgTypes[4]
+	mi := &file_discovery_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -231,7 +527,7 @@ func (x *HeartbeatResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HeartbeatResponse.ProtoReflect.Descriptor instead.
 func (*HeartbeatResponse) Descriptor() ([]byte, []int) {
-	return file_discovery_proto_rawDescGZIP(), []int{4}
+	return file_discovery_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *HeartbeatResponse) GetAlive() bool {
@@ -241,6 +537,13 @@ func (x *HeartbeatResponse) GetAlive() bool {
 	return false
 }
 
+func (x *HeartbeatResponse) GetClientPort() string {
+	if x != nil {
+		return x.ClientPort
+	}
+	return ""
+}
+
 type PeersResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Peers         []string               `protobuf:"bytes,1,rep,name=peers,proto3" json:"peers,omitempty"`
@@ -250,7 +553,7 @@ type PeersResponse struct {
 
 func (x *PeersResponse) Reset() {
 	*x = PeersResponse{}
-	mi := &file_discovery_proto_msgTypes[5]
+	mi := &file_discovery_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -262,7 +565,7 @@ func (x *PeersResponse) String() string {
 func (*PeersResponse) ProtoMessage() {}
 
 func (x *PeersResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_discovery_proto_msgTypes[5]
+	mi := &file_discovery_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -275,7 +578,7 @@ func (x *PeersResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PeersResponse.ProtoReflect.Descriptor instead.
 func (*PeersResponse) Descriptor() ([]byte, []int) {
-	return file_discovery_proto_rawDescGZIP(), []int{5}
+	return file_discovery_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *PeersResponse) GetPeers() []string {
@@ -293,7 +596,7 @@ type Empty struct {
 
 func (x *Empty) Reset() {
 	*x = Empty{}
-	mi := &file_discovery_proto_msgTypes[6]
+	mi := &file_discovery_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -305,7 +608,7 @@ func (x *Empty) String() string {
 func (*Empty) ProtoMessage() {}
 
 func (x *Empty) ProtoReflect() protoreflect.Message {
-	mi := &file_discovery_proto_msgTypes[6]
+	mi := &file_discovery_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -318,19 +621,20 @@ func (x *Empty) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Empty.ProtoReflect.Descriptor instead.
 func (*Empty) Descriptor() ([]byte, []int) {
-	return file_discovery_proto_rawDescGZIP(), []int{6}
+	return file_discovery_proto_rawDescGZIP(), []int{9}
 }
 
 type IncrementRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // Unique ID for the operation (deduplication)
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                          // Unique ID for the operation (deduplication)
+	MoreIds       []string               `protobuf:"bytes,2,rep,name=more_ids,json=moreIds,proto3" json:"more_ids,omitempty"` // Further ops sent in the same message
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *IncrementRequest) Reset() {
 	*x = IncrementRequest{}
-	mi := &file_discovery_proto_msgTypes[7]
+	mi := &file_discovery_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -342,7 +646,7 @@ func (x *IncrementRequest) String() string {
 func (*IncrementRequest) ProtoMessage() {}
 
 func (x *IncrementRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_discovery_proto_msgTypes[7]
+	mi := &file_discovery_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -355,7 +659,7 @@ func (x *IncrementRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use IncrementRequest.ProtoReflect.Descriptor instead.
 func (*IncrementRequest) Descriptor() ([]byte, []int) {
-	return file_discovery_proto_rawDescGZIP(), []int{7}
+	return file_discovery_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *IncrementRequest) GetId() string {
@@ -365,6 +669,13 @@ func (x *IncrementRequest) GetId() string {
 	return ""
 }
 
+func (x *IncrementRequest) GetMoreIds() []string {
+	if x != nil {
+		return x.MoreIds
+	}
+	return nil
+}
+
 type IncrementResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
@@ -374,7 +685,7 @@ type IncrementResponse struct {
 
 func (x *IncrementResponse) Reset() {
 	*x = IncrementResponse{}
-	mi := &file_discovery_proto_msgTypes[8]
+	mi := &file_discovery_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -386,7 +697,7 @@ func (x *IncrementResponse) String() string {
 func (*IncrementResponse) ProtoMessage() {}
 
 func (x *IncrementResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_discovery_proto_msgTypes[8]
+	mi := &file_discovery_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -399,7 +710,7 @@ func (x *IncrementResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use IncrementResponse.ProtoReflect.Descriptor instead.
 func (*IncrementResponse) Descriptor() ([]byte, []int) {
-	return file_discovery_proto_rawDescGZIP(), []int{8}
+	return file_discovery_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *IncrementResponse) GetSuccess() bool {
@@ -409,35 +720,2267 @@ func (x *IncrementResponse) GetSuccess() bool {
 	return false
 }
 
-var File_discovery_proto protoreflect.FileDescriptor
+type NodeList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nodes         []string               `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"` // The answering node first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_discovery_proto_rawDesc = "" +
-	"\n" +
-	"\x0fdiscovery.proto\x12\tdiscovery\"+\n" +
-	"\x0fCounterResponse\x12\x18\n" +
-	"\acounter\x18\x01 \x01(\x03R\acounter\"!\n" +
-	"\x0fRegisterRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"(\n" +
-	"\x10RegisterResponse\x12\x14\n" +
-	"\x05peers\x18\x01 \x03(\tR\x05peers\"\"\n" +
-	"\x10HeartbeatRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\")\n" +
-	"\x11HeartbeatResponse\x12\x14\n" +
-	"\x05alive\x18\x01 \x01(\bR\x05alive\"%\n" +
-	"\rPeersResponse\x12\x14\n" +
-	"\x05peers\x18\x01 \x03(\tR\x05peers\"\a\n" +
-	"\x05Empty\"\"\n" +
-	"\x10IncrementRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"-\n" +
-	"\x11IncrementResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess2\xdd\x02\n" +
-	"\tDiscovery\x12C\n" +
-	"\bRegister\x12\x1a.discovery.RegisterRequest\x1a\x1b.discovery.RegisterResponse\x126\n" +
-	"\bGetPeers\x12\x10.discovery.Empty\x1a\x18.discovery.PeersResponse\x12F\n" +
-	"\tHeartbeat\x12\x1b.discovery.HeartbeatRequest\x1a\x1c.discovery.HeartbeatResponse\x12O\n" +
-	"\x12PropagateIncrement\x12\x1b.discovery.IncrementRequest\x1a\x1c.discovery.IncrementResponse\x12:\n" +
+func (x *NodeList) Reset() {
+	*x = NodeList{}
+	mi := &file_discovery_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeList) ProtoMessage() {}
+
+func (x *NodeList) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeList.ProtoReflect.Descriptor instead.
+func (*NodeList) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *NodeList) GetNodes() []string {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+type CounterIncrementRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counter       string                 `protobuf:"bytes,1,opt,name=counter,proto3" json:"counter,omitempty"` // Empty for the default counter
+	Delta         int64                  `protobuf:"varint,2,opt,name=delta,proto3" json:"delta,omitempty"`    // 1 if not set
+	Id            string                 `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`           // Op ID of a default counter increment, reused on retries
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CounterIncrementRequest) Reset() {
+	*x = CounterIncrementRequest{}
+	mi := &file_discovery_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CounterIncrementRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CounterIncrementRequest) ProtoMessage() {}
+
+func (x *CounterIncrementRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CounterIncrementRequest.ProtoReflect.Descriptor instead.
+func (*CounterIncrementRequest) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CounterIncrementRequest) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+func (x *CounterIncrementRequest) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *CounterIncrementRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type CounterIncrementResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int64                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Duplicate     bool                   `protobuf:"varint,2,opt,name=duplicate,proto3" json:"duplicate,omitempty"` // Every op of the id had been applied before
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CounterIncrementResponse) Reset() {
+	*x = CounterIncrementResponse{}
+	mi := &file_discovery_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CounterIncrementResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CounterIncrementResponse) ProtoMessage() {}
+
+func (x *CounterIncrementResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CounterIncrementResponse.ProtoReflect.Descriptor instead.
+func (*CounterIncrementResponse) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CounterIncrementResponse) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *CounterIncrementResponse) GetDuplicate() bool {
+	if x != nil {
+		return x.Duplicate
+	}
+	return false
+}
+
+type GetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counter       string                 `protobuf:"bytes,1,opt,name=counter,proto3" json:"counter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
+	mi := &file_discovery_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRequest) ProtoMessage() {}
+
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetRequest) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+type CounterValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counter       string                 `protobuf:"bytes,1,opt,name=counter,proto3" json:"counter,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Limit         int64                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`     // Limit of a bounded counter
+	Stale         bool                   `protobuf:"varint,4,opt,name=stale,proto3" json:"stale,omitempty"`     // Read while the node is partitioned
+	Version       uint64                 `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"` // Set by Watch, increases with every change
+	Epoch         string                 `protobuf:"bytes,6,opt,name=epoch,proto3" json:"epoch,omitempty"`      // Set by Watch, versions of different epochs are unrelated
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CounterValue) Reset() {
+	*x = CounterValue{}
+	mi := &file_discovery_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CounterValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CounterValue) ProtoMessage() {}
+
+func (x *CounterValue) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CounterValue.ProtoReflect.Descriptor instead.
+func (*CounterValue) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CounterValue) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+func (x *CounterValue) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *CounterValue) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *CounterValue) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+func (x *CounterValue) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *CounterValue) GetEpoch() string {
+	if x != nil {
+		return x.Epoch
+	}
+	return ""
+}
+
+type BatchIncrementRequest struct {
+	state         protoimpl.MessageState     `protogen:"open.v1"`
+	Increments    []*CounterIncrementRequest `protobuf:"bytes,1,rep,name=increments,proto3" json:"increments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchIncrementRequest) Reset() {
+	*x = BatchIncrementRequest{}
+	mi := &file_discovery_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchIncrementRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchIncrementRequest) ProtoMessage() {}
+
+func (x *BatchIncrementRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchIncrementRequest.ProtoReflect.Descriptor instead.
+func (*BatchIncrementRequest) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BatchIncrementRequest) GetIncrements() []*CounterIncrementRequest {
+	if x != nil {
+		return x.Increments
+	}
+	return nil
+}
+
+// BatchResult is the outcome of one increment of a batch, which are applied
+// independently.
+type BatchResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int64                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Duplicate     bool                   `protobuf:"varint,2,opt,name=duplicate,proto3" json:"duplicate,omitempty"`
+	Code          int32                  `protobuf:"varint,3,opt,name=code,proto3" json:"code,omitempty"` // gRPC status code, 0 if applied
+	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchResult) Reset() {
+	*x = BatchResult{}
+	mi := &file_discovery_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchResult) ProtoMessage() {}
+
+func (x *BatchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchResult.ProtoReflect.Descriptor instead.
+func (*BatchResult) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *BatchResult) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *BatchResult) GetDuplicate() bool {
+	if x != nil {
+		return x.Duplicate
+	}
+	return false
+}
+
+func (x *BatchResult) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *BatchResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type BatchIncrementResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*BatchResult         `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchIncrementResponse) Reset() {
+	*x = BatchIncrementResponse{}
+	mi := &file_discovery_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchIncrementResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchIncrementResponse) ProtoMessage() {}
+
+func (x *BatchIncrementResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchIncrementResponse.ProtoReflect.Descriptor instead.
+func (*BatchIncrementResponse) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *BatchIncrementResponse) GetResults() []*BatchResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type WatchRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Counter string                 `protobuf:"bytes,1,opt,name=counter,proto3" json:"counter,omitempty"`
+	// Resume after the last value seen: the current value is only sent if it
+	// is newer. Ignored if the epoch is not the node's current one.
+	Epoch         string  `protobuf:"bytes,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	SinceVersion  uint64  `protobuf:"varint,3,opt,name=since_version,json=sinceVersion,proto3" json:"since_version,omitempty"`
+	MaxRate       float64 `protobuf:"fixed64,4,opt,name=max_rate,json=maxRate,proto3" json:"max_rate,omitempty"` // Updates per second, capped by the node's limit
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_discovery_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *WatchRequest) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetEpoch() string {
+	if x != nil {
+		return x.Epoch
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetSinceVersion() uint64 {
+	if x != nil {
+		return x.SinceVersion
+	}
+	return 0
+}
+
+func (x *WatchRequest) GetMaxRate() float64 {
+	if x != nil {
+		return x.MaxRate
+	}
+	return 0
+}
+
+type AdminIncrementRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counter       string                 `protobuf:"bytes,1,opt,name=counter,proto3" json:"counter,omitempty"` // Empty for the default counter
+	Delta         int64                  `protobuf:"varint,2,opt,name=delta,proto3" json:"delta,omitempty"`    // Negative only for strong counters
+	Id            string                 `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`           // Op ID of a default counter increment, reused on retries
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdminIncrementRequest) Reset() {
+	*x = AdminIncrementRequest{}
+	mi := &file_discovery_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdminIncrementRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdminIncrementRequest) ProtoMessage() {}
+
+func (x *AdminIncrementRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdminIncrementRequest.ProtoReflect.Descriptor instead.
+func (*AdminIncrementRequest) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *AdminIncrementRequest) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+func (x *AdminIncrementRequest) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *AdminIncrementRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type AdminIncrementResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int64                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Duplicate     bool                   `protobuf:"varint,2,opt,name=duplicate,proto3" json:"duplicate,omitempty"` // Every op of the id had been applied before
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdminIncrementResponse) Reset() {
+	*x = AdminIncrementResponse{}
+	mi := &file_discovery_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdminIncrementResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdminIncrementResponse) ProtoMessage() {}
+
+func (x *AdminIncrementResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdminIncrementResponse.ProtoReflect.Descriptor instead.
+func (*AdminIncrementResponse) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *AdminIncrementResponse) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *AdminIncrementResponse) GetDuplicate() bool {
+	if x != nil {
+		return x.Duplicate
+	}
+	return false
+}
+
+type PeerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Peer          string                 `protobuf:"bytes,1,opt,name=peer,proto3" json:"peer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PeerRequest) Reset() {
+	*x = PeerRequest{}
+	mi := &file_discovery_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PeerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeerRequest) ProtoMessage() {}
+
+func (x *PeerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeerRequest.ProtoReflect.Descriptor instead.
+func (*PeerRequest) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *PeerRequest) GetPeer() string {
+	if x != nil {
+		return x.Peer
+	}
+	return ""
+}
+
+type ResendResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sent          int64                  `protobuf:"varint,1,opt,name=sent,proto3" json:"sent,omitempty"`
+	Remaining     int64                  `protobuf:"varint,2,opt,name=remaining,proto3" json:"remaining,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResendResponse) Reset() {
+	*x = ResendResponse{}
+	mi := &file_discovery_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResendResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResendResponse) ProtoMessage() {}
+
+func (x *ResendResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResendResponse.ProtoReflect.Descriptor instead.
+func (*ResendResponse) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ResendResponse) GetSent() int64 {
+	if x != nil {
+		return x.Sent
+	}
+	return 0
+}
+
+func (x *ResendResponse) GetRemaining() int64 {
+	if x != nil {
+		return x.Remaining
+	}
+	return 0
+}
+
+type OpIDs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ids           []string               `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OpIDs) Reset() {
+	*x = OpIDs{}
+	mi := &file_discovery_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpIDs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpIDs) ProtoMessage() {}
+
+func (x *OpIDs) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpIDs.ProtoReflect.Descriptor instead.
+func (*OpIDs) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *OpIDs) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type Queues struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MissedOps     map[string]*OpIDs      `protobuf:"bytes,1,rep,name=missed_ops,json=missedOps,proto3" json:"missed_ops,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Ops waiting to be resent, by peer
+	SeenOps       int64                  `protobuf:"varint,2,opt,name=seen_ops,json=seenOps,proto3" json:"seen_ops,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Queues) Reset() {
+	*x = Queues{}
+	mi := &file_discovery_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Queues) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Queues) ProtoMessage() {}
+
+func (x *Queues) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Queues.ProtoReflect.Descriptor instead.
+func (*Queues) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *Queues) GetMissedOps() map[string]*OpIDs {
+	if x != nil {
+		return x.MissedOps
+	}
+	return nil
+}
+
+func (x *Queues) GetSeenOps() int64 {
+	if x != nil {
+		return x.SeenOps
+	}
+	return 0
+}
+
+type LogEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Index         int64                  `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	Type          EntryType              `protobuf:"varint,3,opt,name=type,proto3,enum=discovery.EntryType" json:"type,omitempty"`
+	Counter       string                 `protobuf:"bytes,4,opt,name=counter,proto3" json:"counter,omitempty"`
+	Delta         int64                  `protobuf:"varint,5,opt,name=delta,proto3" json:"delta,omitempty"`
+	Members       []string               `protobuf:"bytes,6,rep,name=members,proto3" json:"members,omitempty"`       // Full membership after a ENTRY_MEMBERSHIP entry
+	OpId          string                 `protobuf:"bytes,7,opt,name=op_id,json=opId,proto3" json:"op_id,omitempty"` // Increments with the same op ID are applied once
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogEntry) Reset() {
+	*x = LogEntry{}
+	mi := &file_discovery_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogEntry) ProtoMessage() {}
+
+func (x *LogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogEntry.ProtoReflect.Descriptor instead.
+func (*LogEntry) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *LogEntry) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *LogEntry) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *LogEntry) GetType() EntryType {
+	if x != nil {
+		return x.Type
+	}
+	return EntryType_ENTRY_NOOP
+}
+
+func (x *LogEntry) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+func (x *LogEntry) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *LogEntry) GetMembers() []string {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *LogEntry) GetOpId() string {
+	if x != nil {
+		return x.OpId
+	}
+	return ""
+}
+
+type VoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	CandidateId   string                 `protobuf:"bytes,2,opt,name=candidate_id,json=candidateId,proto3" json:"candidate_id,omitempty"`
+	LastLogIndex  int64                  `protobuf:"varint,3,opt,name=last_log_index,json=lastLogIndex,proto3" json:"last_log_index,omitempty"`
+	LastLogTerm   int64                  `protobuf:"varint,4,opt,name=last_log_term,json=lastLogTerm,proto3" json:"last_log_term,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VoteRequest) Reset() {
+	*x = VoteRequest{}
+	mi := &file_discovery_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoteRequest) ProtoMessage() {}
+
+func (x *VoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoteRequest.ProtoReflect.Descriptor instead.
+func (*VoteRequest) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *VoteRequest) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *VoteRequest) GetCandidateId() string {
+	if x != nil {
+		return x.CandidateId
+	}
+	return ""
+}
+
+func (x *VoteRequest) GetLastLogIndex() int64 {
+	if x != nil {
+		return x.LastLogIndex
+	}
+	return 0
+}
+
+func (x *VoteRequest) GetLastLogTerm() int64 {
+	if x != nil {
+		return x.LastLogTerm
+	}
+	return 0
+}
+
+type VoteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	VoteGranted   bool                   `protobuf:"varint,2,opt,name=vote_granted,json=voteGranted,proto3" json:"vote_granted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VoteResponse) Reset() {
+	*x = VoteResponse{}
+	mi := &file_discovery_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VoteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoteResponse) ProtoMessage() {}
+
+func (x *VoteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoteResponse.ProtoReflect.Descriptor instead.
+func (*VoteResponse) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *VoteResponse) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *VoteResponse) GetVoteGranted() bool {
+	if x != nil {
+		return x.VoteGranted
+	}
+	return false
+}
+
+type AppendEntriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	LeaderId      string                 `protobuf:"bytes,2,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`
+	PrevLogIndex  int64                  `protobuf:"varint,3,opt,name=prev_log_index,json=prevLogIndex,proto3" json:"prev_log_index,omitempty"`
+	PrevLogTerm   int64                  `protobuf:"varint,4,opt,name=prev_log_term,json=prevLogTerm,proto3" json:"prev_log_term,omitempty"`
+	Entries       []*LogEntry            `protobuf:"bytes,5,rep,name=entries,proto3" json:"entries,omitempty"`
+	LeaderCommit  int64                  `protobuf:"varint,6,opt,name=leader_commit,json=leaderCommit,proto3" json:"leader_commit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendEntriesRequest) Reset() {
+	*x = AppendEntriesRequest{}
+	mi := &file_discovery_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendEntriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendEntriesRequest) ProtoMessage() {}
+
+func (x *AppendEntriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendEntriesRequest.ProtoReflect.Descriptor instead.
+func (*AppendEntriesRequest) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *AppendEntriesRequest) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *AppendEntriesRequest) GetLeaderId() string {
+	if x != nil {
+		return x.LeaderId
+	}
+	return ""
+}
+
+func (x *AppendEntriesRequest) GetPrevLogIndex() int64 {
+	if x != nil {
+		return x.PrevLogIndex
+	}
+	return 0
+}
+
+func (x *AppendEntriesRequest) GetPrevLogTerm() int64 {
+	if x != nil {
+		return x.PrevLogTerm
+	}
+	return 0
+}
+
+func (x *AppendEntriesRequest) GetEntries() []*LogEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *AppendEntriesRequest) GetLeaderCommit() int64 {
+	if x != nil {
+		return x.LeaderCommit
+	}
+	return 0
+}
+
+type AppendEntriesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	ConflictIndex int64                  `protobuf:"varint,3,opt,name=conflict_index,json=conflictIndex,proto3" json:"conflict_index,omitempty"` // Where the leader should retry from on failure
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendEntriesResponse) Reset() {
+	*x = AppendEntriesResponse{}
+	mi := &file_discovery_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendEntriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendEntriesResponse) ProtoMessage() {}
+
+func (x *AppendEntriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendEntriesResponse.ProtoReflect.Descriptor instead.
+func (*AppendEntriesResponse) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *AppendEntriesResponse) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *AppendEntriesResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *AppendEntriesResponse) GetConflictIndex() int64 {
+	if x != nil {
+		return x.ConflictIndex
+	}
+	return 0
+}
+
+type SnapshotRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Term              int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	LeaderId          string                 `protobuf:"bytes,2,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`
+	LastIncludedIndex int64                  `protobuf:"varint,3,opt,name=last_included_index,json=lastIncludedIndex,proto3" json:"last_included_index,omitempty"`
+	LastIncludedTerm  int64                  `protobuf:"varint,4,opt,name=last_included_term,json=lastIncludedTerm,proto3" json:"last_included_term,omitempty"`
+	Counters          map[string]int64       `protobuf:"bytes,5,rep,name=counters,proto3" json:"counters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Members           []string               `protobuf:"bytes,6,rep,name=members,proto3" json:"members,omitempty"`
+	OpIds             []string               `protobuf:"bytes,7,rep,name=op_ids,json=opIds,proto3" json:"op_ids,omitempty"` // Of the increments applied up to the snapshot
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *SnapshotRequest) Reset() {
+	*x = SnapshotRequest{}
+	mi := &file_discovery_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotRequest) ProtoMessage() {}
+
+func (x *SnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotRequest.ProtoReflect.Descriptor instead.
+func (*SnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *SnapshotRequest) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *SnapshotRequest) GetLeaderId() string {
+	if x != nil {
+		return x.LeaderId
+	}
+	return ""
+}
+
+func (x *SnapshotRequest) GetLastIncludedIndex() int64 {
+	if x != nil {
+		return x.LastIncludedIndex
+	}
+	return 0
+}
+
+func (x *SnapshotRequest) GetLastIncludedTerm() int64 {
+	if x != nil {
+		return x.LastIncludedTerm
+	}
+	return 0
+}
+
+func (x *SnapshotRequest) GetCounters() map[string]int64 {
+	if x != nil {
+		return x.Counters
+	}
+	return nil
+}
+
+func (x *SnapshotRequest) GetMembers() []string {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *SnapshotRequest) GetOpIds() []string {
+	if x != nil {
+		return x.OpIds
+	}
+	return nil
+}
+
+type SnapshotResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotResponse) Reset() {
+	*x = SnapshotResponse{}
+	mi := &file_discovery_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotResponse) ProtoMessage() {}
+
+func (x *SnapshotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotResponse.ProtoReflect.Descriptor instead.
+func (*SnapshotResponse) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *SnapshotResponse) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+// RaftState is what a raft node keeps on stable storage, written before it
+// answers a vote or an append.
+type RaftState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	VotedFor      string                 `protobuf:"bytes,2,opt,name=voted_for,json=votedFor,proto3" json:"voted_for,omitempty"`
+	Snapshot      *SnapshotRequest       `protobuf:"bytes,3,opt,name=snapshot,proto3" json:"snapshot,omitempty"` // Only the last included entry, counters, members and op IDs
+	Log           []*LogEntry            `protobuf:"bytes,4,rep,name=log,proto3" json:"log,omitempty"`           // Entries after the snapshot
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RaftState) Reset() {
+	*x = RaftState{}
+	mi := &file_discovery_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RaftState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RaftState) ProtoMessage() {}
+
+func (x *RaftState) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RaftState.ProtoReflect.Descriptor instead.
+func (*RaftState) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *RaftState) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *RaftState) GetVotedFor() string {
+	if x != nil {
+		return x.VotedFor
+	}
+	return ""
+}
+
+func (x *RaftState) GetSnapshot() *SnapshotRequest {
+	if x != nil {
+		return x.Snapshot
+	}
+	return nil
+}
+
+func (x *RaftState) GetLog() []*LogEntry {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+type ProposeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counter       string                 `protobuf:"bytes,1,opt,name=counter,proto3" json:"counter,omitempty"`
+	Delta         int64                  `protobuf:"varint,2,opt,name=delta,proto3" json:"delta,omitempty"`
+	OpId          string                 `protobuf:"bytes,3,opt,name=op_id,json=opId,proto3" json:"op_id,omitempty"` // The same for retries of one increment
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProposeRequest) Reset() {
+	*x = ProposeRequest{}
+	mi := &file_discovery_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProposeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProposeRequest) ProtoMessage() {}
+
+func (x *ProposeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProposeRequest.ProtoReflect.Descriptor instead.
+func (*ProposeRequest) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ProposeRequest) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+func (x *ProposeRequest) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *ProposeRequest) GetOpId() string {
+	if x != nil {
+		return x.OpId
+	}
+	return ""
+}
+
+type ProposeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         int64                  `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+	Leader        string                 `protobuf:"bytes,2,opt,name=leader,proto3" json:"leader,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProposeResponse) Reset() {
+	*x = ProposeResponse{}
+	mi := &file_discovery_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProposeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProposeResponse) ProtoMessage() {}
+
+func (x *ProposeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProposeResponse.ProtoReflect.Descriptor instead.
+func (*ProposeResponse) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *ProposeResponse) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *ProposeResponse) GetLeader() string {
+	if x != nil {
+		return x.Leader
+	}
+	return ""
+}
+
+type ReadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counter       string                 `protobuf:"bytes,1,opt,name=counter,proto3" json:"counter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadRequest) Reset() {
+	*x = ReadRequest{}
+	mi := &file_discovery_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadRequest) ProtoMessage() {}
+
+func (x *ReadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadRequest.ProtoReflect.Descriptor instead.
+func (*ReadRequest) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ReadRequest) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+type EscrowRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counter       string                 `protobuf:"bytes,1,opt,name=counter,proto3" json:"counter,omitempty"`
+	Amount        int64                  `protobuf:"varint,2,opt,name=amount,proto3" json:"amount,omitempty"` // Allowance wanted, 0 only reports the donor's state
+	Requester     string                 `protobuf:"bytes,3,opt,name=requester,proto3" json:"requester,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EscrowRequest) Reset() {
+	*x = EscrowRequest{}
+	mi := &file_discovery_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EscrowRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EscrowRequest) ProtoMessage() {}
+
+func (x *EscrowRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EscrowRequest.ProtoReflect.Descriptor instead.
+func (*EscrowRequest) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *EscrowRequest) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+func (x *EscrowRequest) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *EscrowRequest) GetRequester() string {
+	if x != nil {
+		return x.Requester
+	}
+	return ""
+}
+
+type EscrowResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Granted       int64                  `protobuf:"varint,1,opt,name=granted,proto3" json:"granted,omitempty"`
+	Allowance     int64                  `protobuf:"varint,2,opt,name=allowance,proto3" json:"allowance,omitempty"` // Donor allowance left after the transfer
+	Used          int64                  `protobuf:"varint,3,opt,name=used,proto3" json:"used,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EscrowResponse) Reset() {
+	*x = EscrowResponse{}
+	mi := &file_discovery_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EscrowResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EscrowResponse) ProtoMessage() {}
+
+func (x *EscrowResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EscrowResponse.ProtoReflect.Descriptor instead.
+func (*EscrowResponse) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *EscrowResponse) GetGranted() int64 {
+	if x != nil {
+		return x.Granted
+	}
+	return 0
+}
+
+func (x *EscrowResponse) GetAllowance() int64 {
+	if x != nil {
+		return x.Allowance
+	}
+	return 0
+}
+
+func (x *EscrowResponse) GetUsed() int64 {
+	if x != nil {
+		return x.Used
+	}
+	return 0
+}
+
+// RateBucket is the count a single node recorded for a counter during one
+// bucket. Counts only grow, so merging takes the maximum.
+type RateBucket struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counter       string                 `protobuf:"bytes,1,opt,name=counter,proto3" json:"counter,omitempty"`
+	Node          string                 `protobuf:"bytes,2,opt,name=node,proto3" json:"node,omitempty"`
+	Start         int64                  `protobuf:"varint,3,opt,name=start,proto3" json:"start,omitempty"` // Bucket start in unix seconds
+	Count         int64                  `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RateBucket) Reset() {
+	*x = RateBucket{}
+	mi := &file_discovery_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateBucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateBucket) ProtoMessage() {}
+
+func (x *RateBucket) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateBucket.ProtoReflect.Descriptor instead.
+func (*RateBucket) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *RateBucket) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+func (x *RateBucket) GetNode() string {
+	if x != nil {
+		return x.Node
+	}
+	return ""
+}
+
+func (x *RateBucket) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *RateBucket) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type RateBuckets struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Buckets       []*RateBucket          `protobuf:"bytes,1,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RateBuckets) Reset() {
+	*x = RateBuckets{}
+	mi := &file_discovery_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateBuckets) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateBuckets) ProtoMessage() {}
+
+func (x *RateBuckets) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateBuckets.ProtoReflect.Descriptor instead.
+func (*RateBuckets) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *RateBuckets) GetBuckets() []*RateBucket {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+type RateLimitRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Limit         int64                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	WindowSeconds int64                  `protobuf:"varint,3,opt,name=window_seconds,json=windowSeconds,proto3" json:"window_seconds,omitempty"`
+	Cost          int64                  `protobuf:"varint,4,opt,name=cost,proto3" json:"cost,omitempty"` // Defaults to 1
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RateLimitRequest) Reset() {
+	*x = RateLimitRequest{}
+	mi := &file_discovery_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimitRequest) ProtoMessage() {}
+
+func (x *RateLimitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimitRequest.ProtoReflect.Descriptor instead.
+func (*RateLimitRequest) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *RateLimitRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *RateLimitRequest) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *RateLimitRequest) GetWindowSeconds() int64 {
+	if x != nil {
+		return x.WindowSeconds
+	}
+	return 0
+}
+
+func (x *RateLimitRequest) GetCost() int64 {
+	if x != nil {
+		return x.Cost
+	}
+	return 0
+}
+
+type RateLimitResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Allowed           bool                   `protobuf:"varint,1,opt,name=allowed,proto3" json:"allowed,omitempty"`
+	Limit             int64                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Remaining         int64                  `protobuf:"varint,3,opt,name=remaining,proto3" json:"remaining,omitempty"`
+	ResetSeconds      int64                  `protobuf:"varint,4,opt,name=reset_seconds,json=resetSeconds,proto3" json:"reset_seconds,omitempty"`                  // Until the oldest counted request leaves the window
+	RetryAfterSeconds int64                  `protobuf:"varint,5,opt,name=retry_after_seconds,json=retryAfterSeconds,proto3" json:"retry_after_seconds,omitempty"` // Until a denied request would be allowed
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *RateLimitResponse) Reset() {
+	*x = RateLimitResponse{}
+	mi := &file_discovery_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimitResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimitResponse) ProtoMessage() {}
+
+func (x *RateLimitResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimitResponse.ProtoReflect.Descriptor instead.
+func (*RateLimitResponse) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *RateLimitResponse) GetAllowed() bool {
+	if x != nil {
+		return x.Allowed
+	}
+	return false
+}
+
+func (x *RateLimitResponse) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *RateLimitResponse) GetRemaining() int64 {
+	if x != nil {
+		return x.Remaining
+	}
+	return 0
+}
+
+func (x *RateLimitResponse) GetResetSeconds() int64 {
+	if x != nil {
+		return x.ResetSeconds
+	}
+	return 0
+}
+
+func (x *RateLimitResponse) GetRetryAfterSeconds() int64 {
+	if x != nil {
+		return x.RetryAfterSeconds
+	}
+	return 0
+}
+
+// Sketch holds the registers of a HyperLogLog sketch, merged by taking the
+// maximum of each register.
+type Sketch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counter       string                 `protobuf:"bytes,1,opt,name=counter,proto3" json:"counter,omitempty"`
+	Precision     int32                  `protobuf:"varint,2,opt,name=precision,proto3" json:"precision,omitempty"`
+	Registers     []byte                 `protobuf:"bytes,3,opt,name=registers,proto3" json:"registers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Sketch) Reset() {
+	*x = Sketch{}
+	mi := &file_discovery_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Sketch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sketch) ProtoMessage() {}
+
+func (x *Sketch) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sketch.ProtoReflect.Descriptor instead.
+func (*Sketch) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *Sketch) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+func (x *Sketch) GetPrecision() int32 {
+	if x != nil {
+		return x.Precision
+	}
+	return 0
+}
+
+func (x *Sketch) GetRegisters() []byte {
+	if x != nil {
+		return x.Registers
+	}
+	return nil
+}
+
+type Sketches struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sketches      []*Sketch              `protobuf:"bytes,1,rep,name=sketches,proto3" json:"sketches,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Sketches) Reset() {
+	*x = Sketches{}
+	mi := &file_discovery_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Sketches) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sketches) ProtoMessage() {}
+
+func (x *Sketches) ProtoReflect() protoreflect.Message {
+	mi := &file_discovery_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sketches.ProtoReflect.Descriptor instead.
+func (*Sketches) Descriptor() ([]byte, []int) {
+	return file_discovery_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *Sketches) GetSketches() []*Sketch {
+	if x != nil {
+		return x.Sketches
+	}
+	return nil
+}
+
+var File_discovery_proto protoreflect.FileDescriptor
+
+const file_discovery_proto_rawDesc = "" +
+	"\n" +
+	"\x0fdiscovery.proto\x12\tdiscovery\x1a\x1cgoogle/api/annotations.proto\"+\n" +
+	"\x0fCounterResponse\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\x03R\acounter\"g\n" +
+	"\x0fRegisterRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\acluster\x18\x02 \x01(\tR\acluster\x12\x14\n" +
+	"\x05nonce\x18\x03 \x01(\tR\x05nonce\x12\x14\n" +
+	"\x05proof\x18\x04 \x01(\fR\x05proof\"(\n" +
+	"\x10RegisterResponse\x12\x14\n" +
+	"\x05peers\x18\x01 \x03(\tR\x05peers\"h\n" +
+	"\x10HeartbeatRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\acluster\x18\x02 \x01(\tR\acluster\x12\x14\n" +
+	"\x05nonce\x18\x03 \x01(\tR\x05nonce\x12\x14\n" +
+	"\x05proof\x18\x04 \x01(\fR\x05proof\"\"\n" +
+	"\x10ChallengeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"C\n" +
+	"\x11ChallengeResponse\x12\x18\n" +
+	"\acluster\x18\x01 \x01(\tR\acluster\x12\x14\n" +
+	"\x05nonce\x18\x02 \x01(\tR\x05nonce\"\xc9\x03\n" +
+	"\n" +
+	"NodeStatus\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"live_peers\x18\x02 \x03(\tR\tlivePeers\x12#\n" +
+	"\rsuspect_peers\x18\x03 \x03(\tR\fsuspectPeers\x12\x1d\n" +
+	"\n" +
+	"dead_peers\x18\x04 \x03(\tR\tdeadPeers\x12\x18\n" +
+	"\acounter\x18\x05 \x01(\x03R\acounter\x12C\n" +
+	"\n" +
+	"missed_ops\x18\x06 \x03(\v2$.discovery.NodeStatus.MissedOpsEntryR\tmissedOps\x12I\n" +
+	"\fheartbeat_ms\x18\a \x03(\v2&.discovery.NodeStatus.HeartbeatMsEntryR\vheartbeatMs\x12 \n" +
+	"\vpartitioned\x18\b \x01(\bR\vpartitioned\x1a<\n" +
+	"\x0eMissedOpsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\x1a>\n" +
+	"\x10HeartbeatMsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"J\n" +
+	"\x11HeartbeatResponse\x12\x14\n" +
+	"\x05alive\x18\x01 \x01(\bR\x05alive\x12\x1f\n" +
+	"\vclient_port\x18\x02 \x01(\tR\n" +
+	"clientPort\"%\n" +
+	"\rPeersResponse\x12\x14\n" +
+	"\x05peers\x18\x01 \x03(\tR\x05peers\"\a\n" +
+	"\x05Empty\"=\n" +
+	"\x10IncrementRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x19\n" +
+	"\bmore_ids\x18\x02 \x03(\tR\amoreIds\"-\n" +
+	"\x11IncrementResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\" \n" +
+	"\bNodeList\x12\x14\n" +
+	"\x05nodes\x18\x01 \x03(\tR\x05nodes\"Y\n" +
+	"\x17CounterIncrementRequest\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\tR\acounter\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x03R\x05delta\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\tR\x02id\"N\n" +
+	"\x18CounterIncrementResponse\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x03R\x05count\x12\x1c\n" +
+	"\tduplicate\x18\x02 \x01(\bR\tduplicate\"&\n" +
+	"\n" +
+	"GetRequest\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\tR\acounter\"\x9a\x01\n" +
+	"\fCounterValue\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\tR\acounter\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x03R\x05limit\x12\x14\n" +
+	"\x05stale\x18\x04 \x01(\bR\x05stale\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\x04R\aversion\x12\x14\n" +
+	"\x05epoch\x18\x06 \x01(\tR\x05epoch\"[\n" +
+	"\x15BatchIncrementRequest\x12B\n" +
+	"\n" +
+	"increments\x18\x01 \x03(\v2\".discovery.CounterIncrementRequestR\n" +
+	"increments\"k\n" +
+	"\vBatchResult\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x03R\x05count\x12\x1c\n" +
+	"\tduplicate\x18\x02 \x01(\bR\tduplicate\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\x05R\x04code\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\"J\n" +
+	"\x16BatchIncrementResponse\x120\n" +
+	"\aresults\x18\x01 \x03(\v2\x16.discovery.BatchResultR\aresults\"~\n" +
+	"\fWatchRequest\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\tR\acounter\x12\x14\n" +
+	"\x05epoch\x18\x02 \x01(\tR\x05epoch\x12#\n" +
+	"\rsince_version\x18\x03 \x01(\x04R\fsinceVersion\x12\x19\n" +
+	"\bmax_rate\x18\x04 \x01(\x01R\amaxRate\"W\n" +
+	"\x15AdminIncrementRequest\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\tR\acounter\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x03R\x05delta\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\tR\x02id\"L\n" +
+	"\x16AdminIncrementResponse\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x03R\x05count\x12\x1c\n" +
+	"\tduplicate\x18\x02 \x01(\bR\tduplicate\"!\n" +
+	"\vPeerRequest\x12\x12\n" +
+	"\x04peer\x18\x01 \x01(\tR\x04peer\"B\n" +
+	"\x0eResendResponse\x12\x12\n" +
+	"\x04sent\x18\x01 \x01(\x03R\x04sent\x12\x1c\n" +
+	"\tremaining\x18\x02 \x01(\x03R\tremaining\"\x19\n" +
+	"\x05OpIDs\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\tR\x03ids\"\xb4\x01\n" +
+	"\x06Queues\x12?\n" +
+	"\n" +
+	"missed_ops\x18\x01 \x03(\v2 .discovery.Queues.MissedOpsEntryR\tmissedOps\x12\x19\n" +
+	"\bseen_ops\x18\x02 \x01(\x03R\aseenOps\x1aN\n" +
+	"\x0eMissedOpsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12&\n" +
+	"\x05value\x18\x02 \x01(\v2\x10.discovery.OpIDsR\x05value:\x028\x01\"\xbd\x01\n" +
+	"\bLogEntry\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x03R\x04term\x12\x14\n" +
+	"\x05index\x18\x02 \x01(\x03R\x05index\x12(\n" +
+	"\x04type\x18\x03 \x01(\x0e2\x14.discovery.EntryTypeR\x04type\x12\x18\n" +
+	"\acounter\x18\x04 \x01(\tR\acounter\x12\x14\n" +
+	"\x05delta\x18\x05 \x01(\x03R\x05delta\x12\x18\n" +
+	"\amembers\x18\x06 \x03(\tR\amembers\x12\x13\n" +
+	"\x05op_id\x18\a \x01(\tR\x04opId\"\x8e\x01\n" +
+	"\vVoteRequest\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x03R\x04term\x12!\n" +
+	"\fcandidate_id\x18\x02 \x01(\tR\vcandidateId\x12$\n" +
+	"\x0elast_log_index\x18\x03 \x01(\x03R\flastLogIndex\x12\"\n" +
+	"\rlast_log_term\x18\x04 \x01(\x03R\vlastLogTerm\"E\n" +
+	"\fVoteResponse\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x03R\x04term\x12!\n" +
+	"\fvote_granted\x18\x02 \x01(\bR\vvoteGranted\"\xe5\x01\n" +
+	"\x14AppendEntriesRequest\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x03R\x04term\x12\x1b\n" +
+	"\tleader_id\x18\x02 \x01(\tR\bleaderId\x12$\n" +
+	"\x0eprev_log_index\x18\x03 \x01(\x03R\fprevLogIndex\x12\"\n" +
+	"\rprev_log_term\x18\x04 \x01(\x03R\vprevLogTerm\x12-\n" +
+	"\aentries\x18\x05 \x03(\v2\x13.discovery.LogEntryR\aentries\x12#\n" +
+	"\rleader_commit\x18\x06 \x01(\x03R\fleaderCommit\"l\n" +
+	"\x15AppendEntriesResponse\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x03R\x04term\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12%\n" +
+	"\x0econflict_index\x18\x03 \x01(\x03R\rconflictIndex\"\xd4\x02\n" +
+	"\x0fSnapshotRequest\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x03R\x04term\x12\x1b\n" +
+	"\tleader_id\x18\x02 \x01(\tR\bleaderId\x12.\n" +
+	"\x13last_included_index\x18\x03 \x01(\x03R\x11lastIncludedIndex\x12,\n" +
+	"\x12last_included_term\x18\x04 \x01(\x03R\x10lastIncludedTerm\x12D\n" +
+	"\bcounters\x18\x05 \x03(\v2(.discovery.SnapshotRequest.CountersEntryR\bcounters\x12\x18\n" +
+	"\amembers\x18\x06 \x03(\tR\amembers\x12\x15\n" +
+	"\x06op_ids\x18\a \x03(\tR\x05opIds\x1a;\n" +
+	"\rCountersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"&\n" +
+	"\x10SnapshotResponse\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x03R\x04term\"\x9b\x01\n" +
+	"\tRaftState\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x03R\x04term\x12\x1b\n" +
+	"\tvoted_for\x18\x02 \x01(\tR\bvotedFor\x126\n" +
+	"\bsnapshot\x18\x03 \x01(\v2\x1a.discovery.SnapshotRequestR\bsnapshot\x12%\n" +
+	"\x03log\x18\x04 \x03(\v2\x13.discovery.LogEntryR\x03log\"U\n" +
+	"\x0eProposeRequest\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\tR\acounter\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x03R\x05delta\x12\x13\n" +
+	"\x05op_id\x18\x03 \x01(\tR\x04opId\"?\n" +
+	"\x0fProposeResponse\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\x03R\x05value\x12\x16\n" +
+	"\x06leader\x18\x02 \x01(\tR\x06leader\"'\n" +
+	"\vReadRequest\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\tR\acounter\"_\n" +
+	"\rEscrowRequest\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\tR\acounter\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x03R\x06amount\x12\x1c\n" +
+	"\trequester\x18\x03 \x01(\tR\trequester\"\\\n" +
+	"\x0eEscrowResponse\x12\x18\n" +
+	"\agranted\x18\x01 \x01(\x03R\agranted\x12\x1c\n" +
+	"\tallowance\x18\x02 \x01(\x03R\tallowance\x12\x12\n" +
+	"\x04used\x18\x03 \x01(\x03R\x04used\"f\n" +
+	"\n" +
+	"RateBucket\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\tR\acounter\x12\x12\n" +
+	"\x04node\x18\x02 \x01(\tR\x04node\x12\x14\n" +
+	"\x05start\x18\x03 \x01(\x03R\x05start\x12\x14\n" +
+	"\x05count\x18\x04 \x01(\x03R\x05count\">\n" +
+	"\vRateBuckets\x12/\n" +
+	"\abuckets\x18\x01 \x03(\v2\x15.discovery.RateBucketR\abuckets\"u\n" +
+	"\x10RateLimitRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x03R\x05limit\x12%\n" +
+	"\x0ewindow_seconds\x18\x03 \x01(\x03R\rwindowSeconds\x12\x12\n" +
+	"\x04cost\x18\x04 \x01(\x03R\x04cost\"\xb6\x01\n" +
+	"\x11RateLimitResponse\x12\x18\n" +
+	"\aallowed\x18\x01 \x01(\bR\aallowed\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x03R\x05limit\x12\x1c\n" +
+	"\tremaining\x18\x03 \x01(\x03R\tremaining\x12#\n" +
+	"\rreset_seconds\x18\x04 \x01(\x03R\fresetSeconds\x12.\n" +
+	"\x13retry_after_seconds\x18\x05 \x01(\x03R\x11retryAfterSeconds\"^\n" +
+	"\x06Sketch\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\tR\acounter\x12\x1c\n" +
+	"\tprecision\x18\x02 \x01(\x05R\tprecision\x12\x1c\n" +
+	"\tregisters\x18\x03 \x01(\fR\tregisters\"9\n" +
+	"\bSketches\x12-\n" +
+	"\bsketches\x18\x01 \x03(\v2\x11.discovery.SketchR\bsketches*F\n" +
+	"\tEntryType\x12\x0e\n" +
+	"\n" +
+	"ENTRY_NOOP\x10\x00\x12\x13\n" +
+	"\x0fENTRY_INCREMENT\x10\x01\x12\x14\n" +
+	"\x10ENTRY_MEMBERSHIP\x10\x022\xdb\x03\n" +
+	"\tDiscovery\x12C\n" +
+	"\bRegister\x12\x1a.discovery.RegisterRequest\x1a\x1b.discovery.RegisterResponse\x126\n" +
+	"\bGetPeers\x12\x10.discovery.Empty\x1a\x18.discovery.PeersResponse\x12F\n" +
+	"\tHeartbeat\x12\x1b.discovery.HeartbeatRequest\x1a\x1c.discovery.HeartbeatResponse\x12O\n" +
+	"\x12PropagateIncrement\x12\x1b.discovery.IncrementRequest\x1a\x1c.discovery.IncrementResponse\x12:\n" +
+	"\n" +
+	"GetCounter\x12\x10.discovery.Empty\x1a\x1a.discovery.CounterResponse\x12F\n" +
+	"\tChallenge\x12\x1b.discovery.ChallengeRequest\x1a\x1c.discovery.ChallengeResponse\x124\n" +
+	"\tGetStatus\x12\x10.discovery.Empty\x1a\x15.discovery.NodeStatus2\x82\x05\n" +
+	"\x0eCounterService\x12z\n" +
+	"\tIncrement\x12\".discovery.CounterIncrementRequest\x1a#.discovery.CounterIncrementResponse\"$\x82\xd3\xe4\x93\x02\x1e\"\x19/rpc/v1/counter:increment:\x01*\x12z\n" +
+	"\tDecrement\x12\".discovery.CounterIncrementRequest\x1a#.discovery.CounterIncrementResponse\"$\x82\xd3\xe4\x93\x02\x1e\"\x19/rpc/v1/counter:decrement:\x01*\x12N\n" +
+	"\x03Get\x12\x15.discovery.GetRequest\x1a\x17.discovery.CounterValue\"\x17\x82\xd3\xe4\x93\x02\x11\x12\x0f/rpc/v1/counter\x12\x80\x01\n" +
+	"\x0eBatchIncrement\x12 .discovery.BatchIncrementRequest\x1a!.discovery.BatchIncrementResponse\")\x82\xd3\xe4\x93\x02#\"\x1e/rpc/v1/counter:batchIncrement:\x01*\x12Z\n" +
+	"\x05Watch\x12\x17.discovery.WatchRequest\x1a\x17.discovery.CounterValue\"\x1d\x82\xd3\xe4\x93\x02\x17\x12\x15/rpc/v1/counter:watch0\x01\x12I\n" +
+	"\tListNodes\x12\x10.discovery.Empty\x1a\x13.discovery.NodeList\"\x15\x82\xd3\xe4\x93\x02\x0f\x12\r/rpc/v1/nodes2\xc0\x02\n" +
+	"\x05Admin\x12P\n" +
+	"\tIncrement\x12 .discovery.AdminIncrementRequest\x1a!.discovery.AdminIncrementResponse\x12>\n" +
+	"\bSyncFrom\x12\x16.discovery.PeerRequest\x1a\x1a.discovery.CounterResponse\x12;\n" +
+	"\x06Resend\x12\x16.discovery.PeerRequest\x1a\x19.discovery.ResendResponse\x126\n" +
+	"\n" +
+	"RemovePeer\x12\x16.discovery.PeerRequest\x1a\x10.discovery.Empty\x120\n" +
+	"\tGetQueues\x12\x10.discovery.Empty\x1a\x11.discovery.Queues2\xeb\x02\n" +
+	"\x04Raft\x12>\n" +
+	"\vRequestVote\x12\x16.discovery.VoteRequest\x1a\x17.discovery.VoteResponse\x12R\n" +
+	"\rAppendEntries\x12\x1f.discovery.AppendEntriesRequest\x1a .discovery.AppendEntriesResponse\x12J\n" +
+	"\x0fInstallSnapshot\x12\x1a.discovery.SnapshotRequest\x1a\x1b.discovery.SnapshotResponse\x12@\n" +
+	"\aPropose\x12\x19.discovery.ProposeRequest\x1a\x1a.discovery.ProposeResponse\x12A\n" +
+	"\vReadCounter\x12\x16.discovery.ReadRequest\x1a\x1a.discovery.ProposeResponse2I\n" +
+	"\x06Escrow\x12?\n" +
+	"\bTransfer\x12\x18.discovery.EscrowRequest\x1a\x19.discovery.EscrowResponse2z\n" +
+	"\x06Window\x128\n" +
+	"\fMergeBuckets\x12\x16.discovery.RateBuckets\x1a\x10.discovery.Empty\x126\n" +
 	"\n" +
-	"GetCounter\x12\x10.discovery.Empty\x1a\x1a.discovery.CounterResponseB\tZ\a./protob\x06proto3"
+	"GetBuckets\x12\x10.discovery.Empty\x1a\x16.discovery.RateBuckets2Z\n" +
+	"\vRateLimiter\x12K\n" +
+	"\x0eCheckRateLimit\x12\x1b.discovery.RateLimitRequest\x1a\x1c.discovery.RateLimitResponse2x\n" +
+	"\bDistinct\x126\n" +
+	"\rMergeSketches\x12\x13.discovery.Sketches\x1a\x10.discovery.Empty\x124\n" +
+	"\vGetSketches\x12\x10.discovery.Empty\x1a\x13.discovery.SketchesB\tZ\a./protob\x06proto3"
 
 var (
 	file_discovery_proto_rawDescOnce sync.Once
@@ -451,34 +2994,138 @@ func file_discovery_proto_rawDescGZIP() []byte {
 	return file_discovery_proto_rawDescData
 }
 
-var file_discovery_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_discovery_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_discovery_proto_msgTypes = make([]protoimpl.MessageInfo, 50)
 var file_discovery_proto_goTypes = []any{
-	(*CounterResponse)(nil),   // 0: discovery.CounterResponse
-	(*RegisterRequest)(nil),   // 1: discovery.RegisterRequest
-	(*RegisterResponse)(nil),  // 2: discovery.RegisterResponse
-	(*HeartbeatRequest)(nil),  // 3: discovery.HeartbeatRequest
-	(*HeartbeatResponse)(nil), // 4: discovery.HeartbeatResponse
-	(*PeersResponse)(nil),     // 5: discovery.PeersResponse
-	(*Empty)(nil),             // 6: discovery.Empty
-	(*IncrementRequest)(nil),  // 7: discovery.IncrementRequest
-	(*IncrementResponse)(nil), // 8: discovery.IncrementResponse
+	(EntryType)(0),                   // 0: discovery.EntryType
+	(*CounterResponse)(nil),          // 1: discovery.CounterResponse
+	(*RegisterRequest)(nil),          // 2: discovery.RegisterRequest
+	(*RegisterResponse)(nil),         // 3: discovery.RegisterResponse
+	(*HeartbeatRequest)(nil),         // 4: discovery.HeartbeatRequest
+	(*ChallengeRequest)(nil),         // 5: discovery.ChallengeRequest
+	(*ChallengeResponse)(nil),        // 6: discovery.ChallengeResponse
+	(*NodeStatus)(nil),               // 7: discovery.NodeStatus
+	(*HeartbeatResponse)(nil),        // 8: discovery.HeartbeatResponse
+	(*PeersResponse)(nil),            // 9: discovery.PeersResponse
+	(*Empty)(nil),                    // 10: discovery.Empty
+	(*IncrementRequest)(nil),         // 11: discovery.IncrementRequest
+	(*IncrementResponse)(nil),        // 12: discovery.IncrementResponse
+	(*NodeList)(nil),                 // 13: discovery.NodeList
+	(*CounterIncrementRequest)(nil),  // 14: discovery.CounterIncrementRequest
+	(*CounterIncrementResponse)(nil), // 15: discovery.CounterIncrementResponse
+	(*GetRequest)(nil),               // 16: discovery.GetRequest
+	(*CounterValue)(nil),             // 17: discovery.CounterValue
+	(*BatchIncrementRequest)(nil),    // 18: discovery.BatchIncrementRequest
+	(*BatchResult)(nil),              // 19: discovery.BatchResult
+	(*BatchIncrementResponse)(nil),   // 20: discovery.BatchIncrementResponse
+	(*WatchRequest)(nil),             // 21: discovery.WatchRequest
+	(*AdminIncrementRequest)(nil),    // 22: discovery.AdminIncrementRequest
+	(*AdminIncrementResponse)(nil),   // 23: discovery.AdminIncrementResponse
+	(*PeerRequest)(nil),              // 24: discovery.PeerRequest
+	(*ResendResponse)(nil),           // 25: discovery.ResendResponse
+	(*OpIDs)(nil),                    // 26: discovery.OpIDs
+	(*Queues)(nil),                   // 27: discovery.Queues
+	(*LogEntry)(nil),                 // 28: discovery.LogEntry
+	(*VoteRequest)(nil),              // 29: discovery.VoteRequest
+	(*VoteResponse)(nil),             // 30: discovery.VoteResponse
+	(*AppendEntriesRequest)(nil),     // 31: discovery.AppendEntriesRequest
+	(*AppendEntriesResponse)(nil),    // 32: discovery.AppendEntriesResponse
+	(*SnapshotRequest)(nil),          // 33: discovery.SnapshotRequest
+	(*SnapshotResponse)(nil),         // 34: discovery.SnapshotResponse
+	(*RaftState)(nil),                // 35: discovery.RaftState
+	(*ProposeRequest)(nil),           // 36: discovery.ProposeRequest
+	(*ProposeResponse)(nil),          // 37: discovery.ProposeResponse
+	(*ReadRequest)(nil),              // 38: discovery.ReadRequest
+	(*EscrowRequest)(nil),            // 39: discovery.EscrowRequest
+	(*EscrowResponse)(nil),           // 40: discovery.EscrowResponse
+	(*RateBucket)(nil),               // 41: discovery.RateBucket
+	(*RateBuckets)(nil),              // 42: discovery.RateBuckets
+	(*RateLimitRequest)(nil),         // 43: discovery.RateLimitRequest
+	(*RateLimitResponse)(nil),        // 44: discovery.RateLimitResponse
+	(*Sketch)(nil),                   // 45: discovery.Sketch
+	(*Sketches)(nil),                 // 46: discovery.Sketches
+	nil,                              // 47: discovery.NodeStatus.MissedOpsEntry
+	nil,                              // 48: discovery.NodeStatus.HeartbeatMsEntry
+	nil,                              // 49: discovery.Queues.MissedOpsEntry
+	nil,                              // 50: discovery.SnapshotRequest.CountersEntry
 }
 var file_discovery_proto_depIdxs = []int32{
-	1, // 0: discovery.Discovery.Register:input_type -> discovery.RegisterRequest
-	6, // 1: discovery.Discovery.GetPeers:input_type -> discovery.Empty
-	3, // 2: discovery.Discovery.Heartbeat:input_type -> discovery.HeartbeatRequest
-	7, // 3: discovery.Discovery.PropagateIncrement:input_type -> discovery.IncrementRequest
-	6, // 4: discovery.Discovery.GetCounter:input_type -> discovery.Empty
-	2, // 5: discovery.Discovery.Register:output_type -> discovery.RegisterResponse
-	5, // 6: discovery.Discovery.GetPeers:output_type -> discovery.PeersResponse
-	4, // 7: discovery.Discovery.Heartbeat:output_type -> discovery.HeartbeatResponse
-	8, // 8: discovery.Discovery.PropagateIncrement:output_type -> discovery.IncrementResponse
-	0, // 9: discovery.Discovery.GetCounter:output_type -> discovery.CounterResponse
-	5, // [5:10] is the sub-list for method output_type
-	0, // [0:5] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	47, // 0: discovery.NodeStatus.missed_ops:type_name -> discovery.NodeStatus.MissedOpsEntry
+	48, // 1: discovery.NodeStatus.heartbeat_ms:type_name -> discovery.NodeStatus.HeartbeatMsEntry
+	14, // 2: discovery.BatchIncrementRequest.increments:type_name -> discovery.CounterIncrementRequest
+	19, // 3: discovery.BatchIncrementResponse.results:type_name -> discovery.BatchResult
+	49, // 4: discovery.Queues.missed_ops:type_name -> discovery.Queues.MissedOpsEntry
+	0,  // 5: discovery.LogEntry.type:type_name -> discovery.EntryType
+	28, // 6: discovery.AppendEntriesRequest.entries:type_name -> discovery.LogEntry
+	50, // 7: discovery.SnapshotRequest.counters:type_name -> discovery.SnapshotRequest.CountersEntry
+	33, // 8: discovery.RaftState.snapshot:type_name -> discovery.SnapshotRequest
+	28, // 9: discovery.RaftState.log:type_name -> discovery.LogEntry
+	41, // 10: discovery.RateBuckets.buckets:type_name -> discovery.RateBucket
+	45, // 11: discovery.Sketches.sketches:type_name -> discovery.Sketch
+	26, // 12: discovery.Queues.MissedOpsEntry.value:type_name -> discovery.OpIDs
+	2,  // 13: discovery.Discovery.Register:input_type -> discovery.RegisterRequest
+	10, // 14: discovery.Discovery.GetPeers:input_type -> discovery.Empty
+	4,  // 15: discovery.Discovery.Heartbeat:input_type -> discovery.HeartbeatRequest
+	11, // 16: discovery.Discovery.PropagateIncrement:input_type -> discovery.IncrementRequest
+	10, // 17: discovery.Discovery.GetCounter:input_type -> discovery.Empty
+	5,  // 18: discovery.Discovery.Challenge:input_type -> discovery.ChallengeRequest
+	10, // 19: discovery.Discovery.GetStatus:input_type -> discovery.Empty
+	14, // 20: discovery.CounterService.Increment:input_type -> discovery.CounterIncrementRequest
+	14, // 21: discovery.CounterService.Decrement:input_type -> discovery.CounterIncrementRequest
+	16, // 22: discovery.CounterService.Get:input_type -> discovery.GetRequest
+	18, // 23: discovery.CounterService.BatchIncrement:input_type -> discovery.BatchIncrementRequest
+	21, // 24: discovery.CounterService.Watch:input_type -> discovery.WatchRequest
+	10, // 25: discovery.CounterService.ListNodes:input_type -> discovery.Empty
+	22, // 26: discovery.Admin.Increment:input_type -> discovery.AdminIncrementRequest
+	24, // 27: discovery.Admin.SyncFrom:input_type -> discovery.PeerRequest
+	24, // 28: discovery.Admin.Resend:input_type -> discovery.PeerRequest
+	24, // 29: discovery.Admin.RemovePeer:input_type -> discovery.PeerRequest
+	10, // 30: discovery.Admin.GetQueues:input_type -> discovery.Empty
+	29, // 31: discovery.Raft.RequestVote:input_type -> discovery.VoteRequest
+	31, // 32: discovery.Raft.AppendEntries:input_type -> discovery.AppendEntriesRequest
+	33, // 33: discovery.Raft.InstallSnapshot:input_type -> discovery.SnapshotRequest
+	36, // 34: discovery.Raft.Propose:input_type -> discovery.ProposeRequest
+	38, // 35: discovery.Raft.ReadCounter:input_type -> discovery.ReadRequest
+	39, // 36: discovery.Escrow.Transfer:input_type -> discovery.EscrowRequest
+	42, // 37: discovery.Window.MergeBuckets:input_type -> discovery.RateBuckets
+	10, // 38: discovery.Window.GetBuckets:input_type -> discovery.Empty
+	43, // 39: discovery.RateLimiter.CheckRateLimit:input_type -> discovery.RateLimitRequest
+	46, // 40: discovery.Distinct.MergeSketches:input_type -> discovery.Sketches
+	10, // 41: discovery.Distinct.GetSketches:input_type -> discovery.Empty
+	3,  // 42: discovery.Discovery.Register:output_type -> discovery.RegisterResponse
+	9,  // 43: discovery.Discovery.GetPeers:output_type -> discovery.PeersResponse
+	8,  // 44: discovery.Discovery.Heartbeat:output_type -> discovery.HeartbeatResponse
+	12, // 45: discovery.Discovery.PropagateIncrement:output_type -> discovery.IncrementResponse
+	1,  // 46: discovery.Discovery.GetCounter:output_type -> discovery.CounterResponse
+	6,  // 47: discovery.Discovery.Challenge:output_type -> discovery.ChallengeResponse
+	7,  // 48: discovery.Discovery.GetStatus:output_type -> discovery.NodeStatus
+	15, // 49: discovery.CounterService.Increment:output_type -> discovery.CounterIncrementResponse
+	15, // 50: discovery.CounterService.Decrement:output_type -> discovery.CounterIncrementResponse
+	17, // 51: discovery.CounterService.Get:output_type -> discovery.CounterValue
+	20, // 52: discovery.CounterService.BatchIncrement:output_type -> discovery.BatchIncrementResponse
+	17, // 53: discovery.CounterService.Watch:output_type -> discovery.CounterValue
+	13, // 54: discovery.CounterService.ListNodes:output_type -> discovery.NodeList
+	23, // 55: discovery.Admin.Increment:output_type -> discovery.AdminIncrementResponse
+	1,  // 56: discovery.Admin.SyncFrom:output_type -> discovery.CounterResponse
+	25, // 57: discovery.Admin.Resend:output_type -> discovery.ResendResponse
+	10, // 58: discovery.Admin.RemovePeer:output_type -> discovery.Empty
+	27, // 59: discovery.Admin.GetQueues:output_type -> discovery.Queues
+	30, // 60: discovery.Raft.RequestVote:output_type -> discovery.VoteResponse
+	32, // 61: discovery.Raft.AppendEntries:output_type -> discovery.AppendEntriesResponse
+	34, // 62: discovery.Raft.InstallSnapshot:output_type -> discovery.SnapshotResponse
+	37, // 63: discovery.Raft.Propose:output_type -> discovery.ProposeResponse
+	37, // 64: discovery.Raft.ReadCounter:output_type -> discovery.ProposeResponse
+	40, // 65: discovery.Escrow.Transfer:output_type -> discovery.EscrowResponse
+	10, // 66: discovery.Window.MergeBuckets:output_type -> discovery.Empty
+	42, // 67: discovery.Window.GetBuckets:output_type -> discovery.RateBuckets
+	44, // 68: discovery.RateLimiter.CheckRateLimit:output_type -> discovery.RateLimitResponse
+	10, // 69: discovery.Distinct.MergeSketches:output_type -> discovery.Empty
+	46, // 70: discovery.Distinct.GetSketches:output_type -> discovery.Sketches
+	42, // [42:71] is the sub-list for method output_type
+	13, // [13:42] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
 }
 
 func init() { file_discovery_proto_init() }
@@ -491,13 +3138,14 @@ func file_discovery_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_discovery_proto_rawDesc), len(file_discovery_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   9,
+			NumEnums:      1,
+			NumMessages:   50,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   8,
 		},
 		GoTypes:           file_discovery_proto_goTypes,
 		DependencyIndexes: file_discovery_proto_depIdxs,
+		EnumInfos:         file_discovery_proto_enumTypes,
 		MessageInfos:      file_discovery_proto_msgTypes,
 	}.Build()
 	File_discovery_proto = out.File
@@ -24,6 +24,8 @@ const (
 	Discovery_Heartbeat_FullMethodName          = "/discovery.Discovery/Heartbeat"
 	Discovery_PropagateIncrement_FullMethodName = "/discovery.Discovery/PropagateIncrement"
 	Discovery_GetCounter_FullMethodName         = "/discovery.Discovery/GetCounter"
+	Discovery_Challenge_FullMethodName          = "/discovery.Discovery/Challenge"
+	Discovery_GetStatus_FullMethodName          = "/discovery.Discovery/GetStatus"
 )
 
 // DiscoveryClient is the client API for Discovery service.
@@ -35,6 +37,8 @@ type DiscoveryClient interface {
 	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
 	PropagateIncrement(ctx context.Context, in *IncrementRequest, opts ...grpc.CallOption) (*IncrementResponse, error)
 	GetCounter(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*CounterResponse, error)
+	Challenge(ctx context.Context, in *ChallengeRequest, opts ...grpc.CallOption) (*ChallengeResponse, error)
+	GetStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NodeStatus, error)
 }
 
 type discoveryClient struct {
@@ -95,6 +99,26 @@ func (c *discoveryClient) GetCounter(ctx context.Context, in *Empty, opts ...grp
 	return out, nil
 }
 
+func (c *discoveryClient) Challenge(ctx context.Context, in *ChallengeRequest, opts ...grpc.CallOption) (*ChallengeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChallengeResponse)
+	err := c.cc.Invoke(ctx, Discovery_Challenge_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *discoveryClient) GetStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NodeStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NodeStatus)
+	err := c.cc.Invoke(ctx, Discovery_GetStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DiscoveryServer is the server API for Discovery service.
 // All implementations must embed UnimplementedDiscoveryServer
 // for forward compatibility.
@@ -104,6 +128,8 @@ type DiscoveryServer interface {
 	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
 	PropagateIncrement(context.Context, *IncrementRequest) (*IncrementResponse, error)
 	GetCounter(context.Context, *Empty) (*CounterResponse, error)
+	Challenge(context.Context, *ChallengeRequest) (*ChallengeResponse, error)
+	GetStatus(context.Context, *Empty) (*NodeStatus, error)
 	mustEmbedUnimplementedDiscoveryServer()
 }
 
@@ -129,6 +155,12 @@ func (UnimplementedDiscoveryServer) PropagateIncrement(context.Context, *Increme
 func (UnimplementedDiscoveryServer) GetCounter(context.Context, *Empty) (*CounterResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetCounter not implemented")
 }
+func (UnimplementedDiscoveryServer) Challenge(context.Context, *ChallengeRequest) (*ChallengeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Challenge not implemented")
+}
+func (UnimplementedDiscoveryServer) GetStatus(context.Context, *Empty) (*NodeStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
 func (UnimplementedDiscoveryServer) mustEmbedUnimplementedDiscoveryServer() {}
 func (UnimplementedDiscoveryServer) testEmbeddedByValue()                   {}
 
@@ -240,6 +272,42 @@ func _Discovery_GetCounter_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Discovery_Challenge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChallengeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DiscoveryServer).Challenge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Discovery_Challenge_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DiscoveryServer).Challenge(ctx, req.(*ChallengeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Discovery_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DiscoveryServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Discovery_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DiscoveryServer).GetStatus(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Discovery_ServiceDesc is the grpc.ServiceDesc for Discovery service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -267,6 +335,1354 @@ var Discovery_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetCounter",
 			Handler:    _Discovery_GetCounter_Handler,
 		},
+		{
+			MethodName: "Challenge",
+			Handler:    _Discovery_Challenge_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _Discovery_GetStatus_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "discovery.proto",
+}
+
+const (
+	CounterService_Increment_FullMethodName      = "/discovery.CounterService/Increment"
+	CounterService_Decrement_FullMethodName      = "/discovery.CounterService/Decrement"
+	CounterService_Get_FullMethodName            = "/discovery.CounterService/Get"
+	CounterService_BatchIncrement_FullMethodName = "/discovery.CounterService/BatchIncrement"
+	CounterService_Watch_FullMethodName          = "/discovery.CounterService/Watch"
+	CounterService_ListNodes_FullMethodName      = "/discovery.CounterService/ListNodes"
+)
+
+// CounterServiceClient is the client API for CounterService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// CounterService is the API for clients of the cluster, separate from the
+// RPCs nodes use among themselves. Calls need an API key in the
+// "authorization: Bearer" metadata when API keys are configured.
+//
+// The http options map every RPC to HTTP/JSON under /rpc/v1, served by the
+// gateway generated from them: request fields are the JSON body of POSTs and
+// query parameters of GETs.
+type CounterServiceClient interface {
+	Increment(ctx context.Context, in *CounterIncrementRequest, opts ...grpc.CallOption) (*CounterIncrementResponse, error)
+	Decrement(ctx context.Context, in *CounterIncrementRequest, opts ...grpc.CallOption) (*CounterIncrementResponse, error)
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*CounterValue, error)
+	BatchIncrement(ctx context.Context, in *BatchIncrementRequest, opts ...grpc.CallOption) (*BatchIncrementResponse, error)
+	// Server-streamed counter changes, newline delimited JSON over HTTP.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CounterValue], error)
+	// The CounterService addresses of the node and its live peers.
+	ListNodes(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NodeList, error)
+}
+
+type counterServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCounterServiceClient(cc grpc.ClientConnInterface) CounterServiceClient {
+	return &counterServiceClient{cc}
+}
+
+func (c *counterServiceClient) Increment(ctx context.Context, in *CounterIncrementRequest, opts ...grpc.CallOption) (*CounterIncrementResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CounterIncrementResponse)
+	err := c.cc.Invoke(ctx, CounterService_Increment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *counterServiceClient) Decrement(ctx context.Context, in *CounterIncrementRequest, opts ...grpc.CallOption) (*CounterIncrementResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CounterIncrementResponse)
+	err := c.cc.Invoke(ctx, CounterService_Decrement_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *counterServiceClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*CounterValue, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CounterValue)
+	err := c.cc.Invoke(ctx, CounterService_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *counterServiceClient) BatchIncrement(ctx context.Context, in *BatchIncrementRequest, opts ...grpc.CallOption) (*BatchIncrementResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchIncrementResponse)
+	err := c.cc.Invoke(ctx, CounterService_BatchIncrement_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *counterServiceClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CounterValue], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CounterService_ServiceDesc.Streams[0], CounterService_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, CounterValue]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CounterService_WatchClient = grpc.ServerStreamingClient[CounterValue]
+
+func (c *counterServiceClient) ListNodes(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NodeList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NodeList)
+	err := c.cc.Invoke(ctx, CounterService_ListNodes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CounterServiceServer is the server API for CounterService service.
+// All implementations must embed UnimplementedCounterServiceServer
+// for forward compatibility.
+//
+// CounterService is the API for clients of the cluster, separate from the
+// RPCs nodes use among themselves. Calls need an API key in the
+// "authorization: Bearer" metadata when API keys are configured.
+//
+// The http options map every RPC to HTTP/JSON under /rpc/v1, served by the
+// gateway generated from them: request fields are the JSON body of POSTs and
+// query parameters of GETs.
+type CounterServiceServer interface {
+	Increment(context.Context, *CounterIncrementRequest) (*CounterIncrementResponse, error)
+	Decrement(context.Context, *CounterIncrementRequest) (*CounterIncrementResponse, error)
+	Get(context.Context, *GetRequest) (*CounterValue, error)
+	BatchIncrement(context.Context, *BatchIncrementRequest) (*BatchIncrementResponse, error)
+	// Server-streamed counter changes, newline delimited JSON over HTTP.
+	Watch(*WatchRequest, grpc.ServerStreamingServer[CounterValue]) error
+	// The CounterService addresses of the node and its live peers.
+	ListNodes(context.Context, *Empty) (*NodeList, error)
+	mustEmbedUnimplementedCounterServiceServer()
+}
+
+// UnimplementedCounterServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCounterServiceServer struct{}
+
+func (UnimplementedCounterServiceServer) Increment(context.Context, *CounterIncrementRequest) (*CounterIncrementResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Increment not implemented")
+}
+func (UnimplementedCounterServiceServer) Decrement(context.Context, *CounterIncrementRequest) (*CounterIncrementResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Decrement not implemented")
+}
+func (UnimplementedCounterServiceServer) Get(context.Context, *GetRequest) (*CounterValue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedCounterServiceServer) BatchIncrement(context.Context, *BatchIncrementRequest) (*BatchIncrementResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchIncrement not implemented")
+}
+func (UnimplementedCounterServiceServer) Watch(*WatchRequest, grpc.ServerStreamingServer[CounterValue]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedCounterServiceServer) ListNodes(context.Context, *Empty) (*NodeList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNodes not implemented")
+}
+func (UnimplementedCounterServiceServer) mustEmbedUnimplementedCounterServiceServer() {}
+func (UnimplementedCounterServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeCounterServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CounterServiceServer will
+// result in compilation errors.
+type UnsafeCounterServiceServer interface {
+	mustEmbedUnimplementedCounterServiceServer()
+}
+
+func RegisterCounterServiceServer(s grpc.ServiceRegistrar, srv CounterServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCounterServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CounterService_ServiceDesc, srv)
+}
+
+func _CounterService_Increment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CounterIncrementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CounterServiceServer).Increment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CounterService_Increment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CounterServiceServer).Increment(ctx, req.(*CounterIncrementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CounterService_Decrement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CounterIncrementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CounterServiceServer).Decrement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CounterService_Decrement_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CounterServiceServer).Decrement(ctx, req.(*CounterIncrementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CounterService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CounterServiceServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CounterService_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CounterServiceServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CounterService_BatchIncrement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchIncrementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CounterServiceServer).BatchIncrement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CounterService_BatchIncrement_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CounterServiceServer).BatchIncrement(ctx, req.(*BatchIncrementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CounterService_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CounterServiceServer).Watch(m, &grpc.GenericServerStream[WatchRequest, CounterValue]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CounterService_WatchServer = grpc.ServerStreamingServer[CounterValue]
+
+func _CounterService_ListNodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CounterServiceServer).ListNodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CounterService_ListNodes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CounterServiceServer).ListNodes(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CounterService_ServiceDesc is the grpc.ServiceDesc for CounterService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CounterService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "discovery.CounterService",
+	HandlerType: (*CounterServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Increment",
+			Handler:    _CounterService_Increment_Handler,
+		},
+		{
+			MethodName: "Decrement",
+			Handler:    _CounterService_Decrement_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _CounterService_Get_Handler,
+		},
+		{
+			MethodName: "BatchIncrement",
+			Handler:    _CounterService_BatchIncrement_Handler,
+		},
+		{
+			MethodName: "ListNodes",
+			Handler:    _CounterService_ListNodes_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _CounterService_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "discovery.proto",
+}
+
+const (
+	Admin_Increment_FullMethodName  = "/discovery.Admin/Increment"
+	Admin_SyncFrom_FullMethodName   = "/discovery.Admin/SyncFrom"
+	Admin_Resend_FullMethodName     = "/discovery.Admin/Resend"
+	Admin_RemovePeer_FullMethodName = "/discovery.Admin/RemovePeer"
+	Admin_GetQueues_FullMethodName  = "/discovery.Admin/GetQueues"
+)
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Admin lets operators act on a single node, see cmd/counterctl. Calls need
+// an admin API key in the "authorization: Bearer" metadata when API keys are
+// configured.
+type AdminClient interface {
+	Increment(ctx context.Context, in *AdminIncrementRequest, opts ...grpc.CallOption) (*AdminIncrementResponse, error)
+	SyncFrom(ctx context.Context, in *PeerRequest, opts ...grpc.CallOption) (*CounterResponse, error)
+	Resend(ctx context.Context, in *PeerRequest, opts ...grpc.CallOption) (*ResendResponse, error)
+	RemovePeer(ctx context.Context, in *PeerRequest, opts ...grpc.CallOption) (*Empty, error)
+	GetQueues(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Queues, error)
+}
+
+type adminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) Increment(ctx context.Context, in *AdminIncrementRequest, opts ...grpc.CallOption) (*AdminIncrementResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminIncrementResponse)
+	err := c.cc.Invoke(ctx, Admin_Increment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) SyncFrom(ctx context.Context, in *PeerRequest, opts ...grpc.CallOption) (*CounterResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CounterResponse)
+	err := c.cc.Invoke(ctx, Admin_SyncFrom_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Resend(ctx context.Context, in *PeerRequest, opts ...grpc.CallOption) (*ResendResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResendResponse)
+	err := c.cc.Invoke(ctx, Admin_Resend_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) RemovePeer(ctx context.Context, in *PeerRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Admin_RemovePeer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetQueues(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Queues, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Queues)
+	err := c.cc.Invoke(ctx, Admin_GetQueues_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServer is the server API for Admin service.
+// All implementations must embed UnimplementedAdminServer
+// for forward compatibility.
+//
+// Admin lets operators act on a single node, see cmd/counterctl. Calls need
+// an admin API key in the "authorization: Bearer" metadata when API keys are
+// configured.
+type AdminServer interface {
+	Increment(context.Context, *AdminIncrementRequest) (*AdminIncrementResponse, error)
+	SyncFrom(context.Context, *PeerRequest) (*CounterResponse, error)
+	Resend(context.Context, *PeerRequest) (*ResendResponse, error)
+	RemovePeer(context.Context, *PeerRequest) (*Empty, error)
+	GetQueues(context.Context, *Empty) (*Queues, error)
+	mustEmbedUnimplementedAdminServer()
+}
+
+// UnimplementedAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServer struct{}
+
+func (UnimplementedAdminServer) Increment(context.Context, *AdminIncrementRequest) (*AdminIncrementResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Increment not implemented")
+}
+func (UnimplementedAdminServer) SyncFrom(context.Context, *PeerRequest) (*CounterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SyncFrom not implemented")
+}
+func (UnimplementedAdminServer) Resend(context.Context, *PeerRequest) (*ResendResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Resend not implemented")
+}
+func (UnimplementedAdminServer) RemovePeer(context.Context, *PeerRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemovePeer not implemented")
+}
+func (UnimplementedAdminServer) GetQueues(context.Context, *Empty) (*Queues, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetQueues not implemented")
+}
+func (UnimplementedAdminServer) mustEmbedUnimplementedAdminServer() {}
+func (UnimplementedAdminServer) testEmbeddedByValue()               {}
+
+// UnsafeAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServer will
+// result in compilation errors.
+type UnsafeAdminServer interface {
+	mustEmbedUnimplementedAdminServer()
+}
+
+func RegisterAdminServer(s grpc.ServiceRegistrar, srv AdminServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Admin_ServiceDesc, srv)
+}
+
+func _Admin_Increment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminIncrementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Increment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_Increment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Increment(ctx, req.(*AdminIncrementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_SyncFrom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).SyncFrom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_SyncFrom_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).SyncFrom(ctx, req.(*PeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Resend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Resend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_Resend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Resend(ctx, req.(*PeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_RemovePeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).RemovePeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_RemovePeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).RemovePeer(ctx, req.(*PeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetQueues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetQueues(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_GetQueues_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetQueues(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Admin_ServiceDesc is the grpc.ServiceDesc for Admin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Admin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "discovery.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Increment",
+			Handler:    _Admin_Increment_Handler,
+		},
+		{
+			MethodName: "SyncFrom",
+			Handler:    _Admin_SyncFrom_Handler,
+		},
+		{
+			MethodName: "Resend",
+			Handler:    _Admin_Resend_Handler,
+		},
+		{
+			MethodName: "RemovePeer",
+			Handler:    _Admin_RemovePeer_Handler,
+		},
+		{
+			MethodName: "GetQueues",
+			Handler:    _Admin_GetQueues_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "discovery.proto",
+}
+
+const (
+	Raft_RequestVote_FullMethodName     = "/discovery.Raft/RequestVote"
+	Raft_AppendEntries_FullMethodName   = "/discovery.Raft/AppendEntries"
+	Raft_InstallSnapshot_FullMethodName = "/discovery.Raft/InstallSnapshot"
+	Raft_Propose_FullMethodName         = "/discovery.Raft/Propose"
+	Raft_ReadCounter_FullMethodName     = "/discovery.Raft/ReadCounter"
+)
+
+// RaftClient is the client API for Raft service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Raft replicates the strongly consistent counters. It is only served when a
+// node runs with --strong-counters.
+type RaftClient interface {
+	RequestVote(ctx context.Context, in *VoteRequest, opts ...grpc.CallOption) (*VoteResponse, error)
+	AppendEntries(ctx context.Context, in *AppendEntriesRequest, opts ...grpc.CallOption) (*AppendEntriesResponse, error)
+	InstallSnapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*SnapshotResponse, error)
+	Propose(ctx context.Context, in *ProposeRequest, opts ...grpc.CallOption) (*ProposeResponse, error)
+	ReadCounter(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (*ProposeResponse, error)
+}
+
+type raftClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRaftClient(cc grpc.ClientConnInterface) RaftClient {
+	return &raftClient{cc}
+}
+
+func (c *raftClient) RequestVote(ctx context.Context, in *VoteRequest, opts ...grpc.CallOption) (*VoteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VoteResponse)
+	err := c.cc.Invoke(ctx, Raft_RequestVote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftClient) AppendEntries(ctx context.Context, in *AppendEntriesRequest, opts ...grpc.CallOption) (*AppendEntriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AppendEntriesResponse)
+	err := c.cc.Invoke(ctx, Raft_AppendEntries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftClient) InstallSnapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*SnapshotResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SnapshotResponse)
+	err := c.cc.Invoke(ctx, Raft_InstallSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftClient) Propose(ctx context.Context, in *ProposeRequest, opts ...grpc.CallOption) (*ProposeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProposeResponse)
+	err := c.cc.Invoke(ctx, Raft_Propose_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftClient) ReadCounter(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (*ProposeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProposeResponse)
+	err := c.cc.Invoke(ctx, Raft_ReadCounter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RaftServer is the server API for Raft service.
+// All implementations must embed UnimplementedRaftServer
+// for forward compatibility.
+//
+// Raft replicates the strongly consistent counters. It is only served when a
+// node runs with --strong-counters.
+type RaftServer interface {
+	RequestVote(context.Context, *VoteRequest) (*VoteResponse, error)
+	AppendEntries(context.Context, *AppendEntriesRequest) (*AppendEntriesResponse, error)
+	InstallSnapshot(context.Context, *SnapshotRequest) (*SnapshotResponse, error)
+	Propose(context.Context, *ProposeRequest) (*ProposeResponse, error)
+	ReadCounter(context.Context, *ReadRequest) (*ProposeResponse, error)
+	mustEmbedUnimplementedRaftServer()
+}
+
+// UnimplementedRaftServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRaftServer struct{}
+
+func (UnimplementedRaftServer) RequestVote(context.Context, *VoteRequest) (*VoteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestVote not implemented")
+}
+func (UnimplementedRaftServer) AppendEntries(context.Context, *AppendEntriesRequest) (*AppendEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AppendEntries not implemented")
+}
+func (UnimplementedRaftServer) InstallSnapshot(context.Context, *SnapshotRequest) (*SnapshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InstallSnapshot not implemented")
+}
+func (UnimplementedRaftServer) Propose(context.Context, *ProposeRequest) (*ProposeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Propose not implemented")
+}
+func (UnimplementedRaftServer) ReadCounter(context.Context, *ReadRequest) (*ProposeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadCounter not implemented")
+}
+func (UnimplementedRaftServer) mustEmbedUnimplementedRaftServer() {}
+func (UnimplementedRaftServer) testEmbeddedByValue()              {}
+
+// UnsafeRaftServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RaftServer will
+// result in compilation errors.
+type UnsafeRaftServer interface {
+	mustEmbedUnimplementedRaftServer()
+}
+
+func RegisterRaftServer(s grpc.ServiceRegistrar, srv RaftServer) {
+	// If the following call pancis, it indicates UnimplementedRaftServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Raft_ServiceDesc, srv)
+}
+
+func _Raft_RequestVote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftServer).RequestVote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Raft_RequestVote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftServer).RequestVote(ctx, req.(*VoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Raft_AppendEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppendEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftServer).AppendEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Raft_AppendEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftServer).AppendEntries(ctx, req.(*AppendEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Raft_InstallSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftServer).InstallSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Raft_InstallSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftServer).InstallSnapshot(ctx, req.(*SnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Raft_Propose_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProposeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftServer).Propose(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Raft_Propose_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftServer).Propose(ctx, req.(*ProposeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Raft_ReadCounter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftServer).ReadCounter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Raft_ReadCounter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftServer).ReadCounter(ctx, req.(*ReadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Raft_ServiceDesc is the grpc.ServiceDesc for Raft service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Raft_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "discovery.Raft",
+	HandlerType: (*RaftServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RequestVote",
+			Handler:    _Raft_RequestVote_Handler,
+		},
+		{
+			MethodName: "AppendEntries",
+			Handler:    _Raft_AppendEntries_Handler,
+		},
+		{
+			MethodName: "InstallSnapshot",
+			Handler:    _Raft_InstallSnapshot_Handler,
+		},
+		{
+			MethodName: "Propose",
+			Handler:    _Raft_Propose_Handler,
+		},
+		{
+			MethodName: "ReadCounter",
+			Handler:    _Raft_ReadCounter_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "discovery.proto",
+}
+
+const (
+	Escrow_Transfer_FullMethodName = "/discovery.Escrow/Transfer"
+)
+
+// EscrowClient is the client API for Escrow service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Escrow moves allowance of bounded counters between nodes, so each node can
+// enforce the cluster-wide limit locally.
+type EscrowClient interface {
+	Transfer(ctx context.Context, in *EscrowRequest, opts ...grpc.CallOption) (*EscrowResponse, error)
+}
+
+type escrowClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEscrowClient(cc grpc.ClientConnInterface) EscrowClient {
+	return &escrowClient{cc}
+}
+
+func (c *escrowClient) Transfer(ctx context.Context, in *EscrowRequest, opts ...grpc.CallOption) (*EscrowResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EscrowResponse)
+	err := c.cc.Invoke(ctx, Escrow_Transfer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EscrowServer is the server API for Escrow service.
+// All implementations must embed UnimplementedEscrowServer
+// for forward compatibility.
+//
+// Escrow moves allowance of bounded counters between nodes, so each node can
+// enforce the cluster-wide limit locally.
+type EscrowServer interface {
+	Transfer(context.Context, *EscrowRequest) (*EscrowResponse, error)
+	mustEmbedUnimplementedEscrowServer()
+}
+
+// UnimplementedEscrowServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedEscrowServer struct{}
+
+func (UnimplementedEscrowServer) Transfer(context.Context, *EscrowRequest) (*EscrowResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Transfer not implemented")
+}
+func (UnimplementedEscrowServer) mustEmbedUnimplementedEscrowServer() {}
+func (UnimplementedEscrowServer) testEmbeddedByValue()                {}
+
+// UnsafeEscrowServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EscrowServer will
+// result in compilation errors.
+type UnsafeEscrowServer interface {
+	mustEmbedUnimplementedEscrowServer()
+}
+
+func RegisterEscrowServer(s grpc.ServiceRegistrar, srv EscrowServer) {
+	// If the following call pancis, it indicates UnimplementedEscrowServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Escrow_ServiceDesc, srv)
+}
+
+func _Escrow_Transfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EscrowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EscrowServer).Transfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Escrow_Transfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EscrowServer).Transfer(ctx, req.(*EscrowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Escrow_ServiceDesc is the grpc.ServiceDesc for Escrow service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Escrow_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "discovery.Escrow",
+	HandlerType: (*EscrowServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Transfer",
+			Handler:    _Escrow_Transfer_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "discovery.proto",
+}
+
+const (
+	Window_MergeBuckets_FullMethodName = "/discovery.Window/MergeBuckets"
+	Window_GetBuckets_FullMethodName   = "/discovery.Window/GetBuckets"
+)
+
+// WindowClient is the client API for Window service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Window gossips the per-node buckets of windowed rate counters.
+type WindowClient interface {
+	MergeBuckets(ctx context.Context, in *RateBuckets, opts ...grpc.CallOption) (*Empty, error)
+	GetBuckets(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*RateBuckets, error)
+}
+
+type windowClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWindowClient(cc grpc.ClientConnInterface) WindowClient {
+	return &windowClient{cc}
+}
+
+func (c *windowClient) MergeBuckets(ctx context.Context, in *RateBuckets, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Window_MergeBuckets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *windowClient) GetBuckets(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*RateBuckets, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RateBuckets)
+	err := c.cc.Invoke(ctx, Window_GetBuckets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WindowServer is the server API for Window service.
+// All implementations must embed UnimplementedWindowServer
+// for forward compatibility.
+//
+// Window gossips the per-node buckets of windowed rate counters.
+type WindowServer interface {
+	MergeBuckets(context.Context, *RateBuckets) (*Empty, error)
+	GetBuckets(context.Context, *Empty) (*RateBuckets, error)
+	mustEmbedUnimplementedWindowServer()
+}
+
+// UnimplementedWindowServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedWindowServer struct{}
+
+func (UnimplementedWindowServer) MergeBuckets(context.Context, *RateBuckets) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MergeBuckets not implemented")
+}
+func (UnimplementedWindowServer) GetBuckets(context.Context, *Empty) (*RateBuckets, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBuckets not implemented")
+}
+func (UnimplementedWindowServer) mustEmbedUnimplementedWindowServer() {}
+func (UnimplementedWindowServer) testEmbeddedByValue()                {}
+
+// UnsafeWindowServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WindowServer will
+// result in compilation errors.
+type UnsafeWindowServer interface {
+	mustEmbedUnimplementedWindowServer()
+}
+
+func RegisterWindowServer(s grpc.ServiceRegistrar, srv WindowServer) {
+	// If the following call pancis, it indicates UnimplementedWindowServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Window_ServiceDesc, srv)
+}
+
+func _Window_MergeBuckets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RateBuckets)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WindowServer).MergeBuckets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Window_MergeBuckets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WindowServer).MergeBuckets(ctx, req.(*RateBuckets))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Window_GetBuckets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WindowServer).GetBuckets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Window_GetBuckets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WindowServer).GetBuckets(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Window_ServiceDesc is the grpc.ServiceDesc for Window service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Window_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "discovery.Window",
+	HandlerType: (*WindowServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "MergeBuckets",
+			Handler:    _Window_MergeBuckets_Handler,
+		},
+		{
+			MethodName: "GetBuckets",
+			Handler:    _Window_GetBuckets_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "discovery.proto",
+}
+
+const (
+	RateLimiter_CheckRateLimit_FullMethodName = "/discovery.RateLimiter/CheckRateLimit"
+)
+
+// RateLimiterClient is the client API for RateLimiter service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// RateLimiter answers rate limit checks from the cluster-wide windowed counts.
+type RateLimiterClient interface {
+	CheckRateLimit(ctx context.Context, in *RateLimitRequest, opts ...grpc.CallOption) (*RateLimitResponse, error)
+}
+
+type rateLimiterClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRateLimiterClient(cc grpc.ClientConnInterface) RateLimiterClient {
+	return &rateLimiterClient{cc}
+}
+
+func (c *rateLimiterClient) CheckRateLimit(ctx context.Context, in *RateLimitRequest, opts ...grpc.CallOption) (*RateLimitResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RateLimitResponse)
+	err := c.cc.Invoke(ctx, RateLimiter_CheckRateLimit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RateLimiterServer is the server API for RateLimiter service.
+// All implementations must embed UnimplementedRateLimiterServer
+// for forward compatibility.
+//
+// RateLimiter answers rate limit checks from the cluster-wide windowed counts.
+type RateLimiterServer interface {
+	CheckRateLimit(context.Context, *RateLimitRequest) (*RateLimitResponse, error)
+	mustEmbedUnimplementedRateLimiterServer()
+}
+
+// UnimplementedRateLimiterServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRateLimiterServer struct{}
+
+func (UnimplementedRateLimiterServer) CheckRateLimit(context.Context, *RateLimitRequest) (*RateLimitResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckRateLimit not implemented")
+}
+func (UnimplementedRateLimiterServer) mustEmbedUnimplementedRateLimiterServer() {}
+func (UnimplementedRateLimiterServer) testEmbeddedByValue()                     {}
+
+// UnsafeRateLimiterServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RateLimiterServer will
+// result in compilation errors.
+type UnsafeRateLimiterServer interface {
+	mustEmbedUnimplementedRateLimiterServer()
+}
+
+func RegisterRateLimiterServer(s grpc.ServiceRegistrar, srv RateLimiterServer) {
+	// If the following call pancis, it indicates UnimplementedRateLimiterServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RateLimiter_ServiceDesc, srv)
+}
+
+func _RateLimiter_CheckRateLimit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RateLimitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RateLimiterServer).CheckRateLimit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RateLimiter_CheckRateLimit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RateLimiterServer).CheckRateLimit(ctx, req.(*RateLimitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RateLimiter_ServiceDesc is the grpc.ServiceDesc for RateLimiter service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RateLimiter_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "discovery.RateLimiter",
+	HandlerType: (*RateLimiterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CheckRateLimit",
+			Handler:    _RateLimiter_CheckRateLimit_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "discovery.proto",
+}
+
+const (
+	Distinct_MergeSketches_FullMethodName = "/discovery.Distinct/MergeSketches"
+	Distinct_GetSketches_FullMethodName   = "/discovery.Distinct/GetSketches"
+)
+
+// DistinctClient is the client API for Distinct service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Distinct gossips the HyperLogLog sketches of distinct counters.
+type DistinctClient interface {
+	MergeSketches(ctx context.Context, in *Sketches, opts ...grpc.CallOption) (*Empty, error)
+	GetSketches(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Sketches, error)
+}
+
+type distinctClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDistinctClient(cc grpc.ClientConnInterface) DistinctClient {
+	return &distinctClient{cc}
+}
+
+func (c *distinctClient) MergeSketches(ctx context.Context, in *Sketches, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Distinct_MergeSketches_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *distinctClient) GetSketches(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Sketches, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Sketches)
+	err := c.cc.Invoke(ctx, Distinct_GetSketches_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DistinctServer is the server API for Distinct service.
+// All implementations must embed UnimplementedDistinctServer
+// for forward compatibility.
+//
+// Distinct gossips the HyperLogLog sketches of distinct counters.
+type DistinctServer interface {
+	MergeSketches(context.Context, *Sketches) (*Empty, error)
+	GetSketches(context.Context, *Empty) (*Sketches, error)
+	mustEmbedUnimplementedDistinctServer()
+}
+
+// UnimplementedDistinctServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDistinctServer struct{}
+
+func (UnimplementedDistinctServer) MergeSketches(context.Context, *Sketches) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MergeSketches not implemented")
+}
+func (UnimplementedDistinctServer) GetSketches(context.Context, *Empty) (*Sketches, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSketches not implemented")
+}
+func (UnimplementedDistinctServer) mustEmbedUnimplementedDistinctServer() {}
+func (UnimplementedDistinctServer) testEmbeddedByValue()                  {}
+
+// UnsafeDistinctServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DistinctServer will
+// result in compilation errors.
+type UnsafeDistinctServer interface {
+	mustEmbedUnimplementedDistinctServer()
+}
+
+func RegisterDistinctServer(s grpc.ServiceRegistrar, srv DistinctServer) {
+	// If the following call pancis, it indicates UnimplementedDistinctServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Distinct_ServiceDesc, srv)
+}
+
+func _Distinct_MergeSketches_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Sketches)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DistinctServer).MergeSketches(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Distinct_MergeSketches_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DistinctServer).MergeSketches(ctx, req.(*Sketches))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Distinct_GetSketches_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DistinctServer).GetSketches(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Distinct_GetSketches_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DistinctServer).GetSketches(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Distinct_ServiceDesc is the grpc.ServiceDesc for Distinct service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Distinct_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "discovery.Distinct",
+	HandlerType: (*DistinctServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "MergeSketches",
+			Handler:    _Distinct_MergeSketches_Handler,
+		},
+		{
+			MethodName: "GetSketches",
+			Handler:    _Distinct_GetSketches_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "discovery.proto",
@@ -0,0 +1,378 @@
+// Package sdk is a Go client of the counter cluster's CounterService. It
+// bootstraps from any node, keeps track of the live nodes with ListNodes and
+// spreads calls over the live nodes, failing over to the next node when one
+// cannot be reached.
+package sdk
+
+import (
+	"context"
+	"discovery-service/lib/arrays"
+	pb "discovery-service/proto"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxDelta is the largest increment of the default counter a node accepts
+// in one call, larger batches are split.
+const maxDelta = 10000
+
+// ErrNoNodes is returned when no node of the cluster could be reached.
+var ErrNoNodes = errors.New("sdk: no reachable node")
+
+// Options configure a Client. Only Seeds is required.
+type Options struct {
+	Seeds       []string                         // CounterService addresses of nodes to bootstrap from
+	APIKey      string                           // Sent as "authorization: Bearer" when not empty
+	Credentials credentials.TransportCredentials // Insecure if nil
+	Timeout     time.Duration                    // Per call, 2s if zero
+	Attempts    int                              // Nodes tried per call, 3 if zero
+	Refresh     time.Duration                    // Membership refresh period, 10s if zero
+	Backoff     time.Duration                    // How long a failed node is skipped, 5s if zero
+
+	BatchSize     int64         // Pending async increments of a counter that trigger a flush, 100 if zero
+	BatchInterval time.Duration // Longest time async increments wait, 100ms if zero
+	OnError       func(error)   // Reports failed async increments, ignored if nil
+}
+
+// Client sends increments to the cluster. It is safe for concurrent use.
+type Client struct {
+	opts Options
+
+	mu    sync.Mutex
+	nodes []string
+	conns map[string]*grpc.ClientConn
+	down  map[string]time.Time // Nodes skipped until the given time
+	next  atomic.Uint64
+
+	pending map[string]int64 // Async increments by counter
+	flush   chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// New connects to the cluster through the first seed that answers ListNodes.
+func New(ctx context.Context, opts Options) (*Client, error) {
+	if len(opts.Seeds) == 0 {
+		return nil, errors.New("sdk: no seed nodes")
+	}
+	if opts.Credentials == nil {
+		opts.Credentials = insecure.NewCredentials()
+	}
+	opts.Timeout = or(opts.Timeout, 2*time.Second)
+	opts.Refresh = or(opts.Refresh, 10*time.Second)
+	opts.Backoff = or(opts.Backoff, 5*time.Second)
+	opts.BatchInterval = or(opts.BatchInterval, 100*time.Millisecond)
+	opts.BatchSize = or(opts.BatchSize, 100)
+	opts.Attempts = or(opts.Attempts, 3)
+
+	c := &Client{
+		opts:    opts,
+		nodes:   append([]string{}, opts.Seeds...),
+		conns:   map[string]*grpc.ClientConn{},
+		down:    map[string]time.Time{},
+		pending: map[string]int64{},
+		flush:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	if err := c.refresh(ctx); err != nil {
+		c.closeConns()
+		return nil, err
+	}
+	c.wg.Add(2)
+	go c.refreshLoop()
+	go c.batchLoop()
+	return c, nil
+}
+
+// Nodes returns the live nodes the client spreads calls over.
+func (c *Client) Nodes() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.nodes...)
+}
+
+// Increment increments the default counter once.
+func (c *Client) Increment(ctx context.Context) (int64, error) {
+	return c.IncrementBy(ctx, "", 1)
+}
+
+// IncrementBy adds delta to counter, the default counter if it is "". A
+// negative delta decrements a strong counter. The call is retried on other
+// nodes if a node cannot be reached. Increments of the default counter keep
+// their op ID so they are applied only once, even if a node timed out after
+// applying them. Named counters have no op IDs and are only retried when
+// the node was unavailable.
+func (c *Client) IncrementBy(ctx context.Context, counter string, delta int64) (int64, error) {
+	req := &pb.CounterIncrementRequest{Counter: counter, Delta: delta}
+	if counter == "" {
+		req.Id = uuid.New().String()
+	}
+	var count int64
+	err := c.call(ctx, counter == "", func(ctx context.Context, conn *grpc.ClientConn) error {
+		api := pb.NewCounterServiceClient(conn)
+		var resp *pb.CounterIncrementResponse
+		var err error
+		if delta < 0 {
+			req.Delta = -delta
+			resp, err = api.Decrement(ctx, req)
+		} else {
+			resp, err = api.Increment(ctx, req)
+		}
+		if err == nil {
+			count = resp.Count
+		}
+		return err
+	})
+	return count, err
+}
+
+// Count reads counter, the default counter if it is "", from one of the
+// nodes.
+func (c *Client) Count(ctx context.Context, counter string) (int64, error) {
+	var count int64
+	err := c.call(ctx, true, func(ctx context.Context, conn *grpc.ClientConn) error {
+		resp, err := pb.NewCounterServiceClient(conn).Get(ctx, &pb.GetRequest{Counter: counter})
+		if err == nil {
+			count = resp.Count
+		}
+		return err
+	})
+	return count, err
+}
+
+// IncrementAsync adds delta to counter in the background. Increments of the
+// same counter are batched into one call, sent when BatchSize is reached or
+// after BatchInterval. A negative delta decrements a strong counter. Failures
+// are reported to OnError.
+func (c *Client) IncrementAsync(counter string, delta int64) {
+	c.mu.Lock()
+	c.pending[counter] += delta
+	full := max(c.pending[counter], -c.pending[counter]) >= c.opts.BatchSize
+	c.mu.Unlock()
+	if full {
+		select {
+		case c.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush sends the pending async increments and returns the first error.
+func (c *Client) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = map[string]int64{}
+	c.mu.Unlock()
+
+	var first error
+	for counter, delta := range pending {
+		// Negative deltas of strong counters are sent as decrements.
+		for delta != 0 {
+			n := delta
+			if counter == "" && delta > 0 {
+				n = min(delta, maxDelta)
+			}
+			if _, err := c.IncrementBy(ctx, counter, n); err != nil {
+				err = fmt.Errorf("sdk: increment %q by %d: %w", counter, n, err)
+				if c.opts.OnError != nil {
+					c.opts.OnError(err)
+				}
+				if first == nil {
+					first = err
+				}
+			}
+			delta -= n
+		}
+	}
+	return first
+}
+
+// Close sends the pending async increments and closes the connections.
+func (c *Client) Close() error {
+	close(c.done)
+	c.wg.Wait()
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout*time.Duration(c.opts.Attempts))
+	defer cancel()
+	err := c.Flush(ctx)
+	c.closeConns()
+	return err
+}
+
+// call runs fn against up to Attempts nodes in round robin order until one
+// succeeds or fails with an error retrying cannot fix. Calls that are not
+// idempotent are not retried after timeouts, they may have been applied.
+func (c *Client) call(ctx context.Context, idempotent bool, fn func(context.Context, *grpc.ClientConn) error) error {
+	return c.callN(ctx, c.opts.Attempts, idempotent, fn)
+}
+
+func (c *Client) callN(ctx context.Context, attempts int, idempotent bool, fn func(context.Context, *grpc.ClientConn) error) error {
+	err := ErrNoNodes
+	for _, node := range c.pick(attempts) {
+		conn, dialErr := c.conn(node)
+		if dialErr != nil {
+			err = dialErr
+			continue
+		}
+		callCtx, cancel := context.WithTimeout(c.outgoing(ctx), c.opts.Timeout)
+		err = fn(callCtx, conn)
+		cancel()
+		if err == nil || !retryable(err, idempotent) || ctx.Err() != nil {
+			return err
+		}
+		c.markDown(node)
+	}
+	return err
+}
+
+// pick returns up to n nodes starting at the next one in round robin order,
+// preferring nodes that have not failed recently.
+func (c *Client) pick(n int) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.nodes) == 0 {
+		return nil
+	}
+	start := int(c.next.Add(1) - 1)
+	var up, down []string
+	for i := range c.nodes {
+		node := c.nodes[(start+i)%len(c.nodes)]
+		if time.Now().Before(c.down[node]) {
+			down = append(down, node)
+		} else {
+			up = append(up, node)
+		}
+	}
+	nodes := append(up, down...)
+	return nodes[:min(n, len(nodes))]
+}
+
+func (c *Client) markDown(node string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.down[node] = time.Now().Add(c.opts.Backoff)
+}
+
+func (c *Client) conn(node string) (*grpc.ClientConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if conn, ok := c.conns[node]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.NewClient(node, grpc.WithTransportCredentials(c.opts.Credentials))
+	if err != nil {
+		return nil, err
+	}
+	c.conns[node] = conn
+	return conn, nil
+}
+
+func (c *Client) outgoing(ctx context.Context) context.Context {
+	if c.opts.APIKey == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.opts.APIKey)
+}
+
+// refresh replaces the known nodes with the first node that answers and the
+// peers it sees alive, trying every known node. Dead and suspect peers are
+// left out. The nodes are kept when none answers so the client can recover
+// once the cluster is back.
+func (c *Client) refresh(ctx context.Context) error {
+	var peers []string
+	err := c.callN(ctx, len(c.Nodes()), true, func(ctx context.Context, conn *grpc.ClientConn) error {
+		resp, err := pb.NewCounterServiceClient(conn).ListNodes(ctx, &pb.Empty{})
+		if err == nil {
+			peers = resp.Nodes
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(peers) == 0 {
+		return ErrNoNodes
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nodes = peers
+	for node, conn := range c.conns {
+		if !arrays.Contains(peers, node) {
+			conn.Close()
+			delete(c.conns, node)
+		}
+	}
+	return nil
+}
+
+func (c *Client) refreshLoop() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.opts.Refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout*time.Duration(c.opts.Attempts))
+			c.refresh(ctx)
+			cancel()
+		}
+	}
+}
+
+func (c *Client) batchLoop() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.opts.BatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		case <-c.flush:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout*time.Duration(c.opts.Attempts))
+		c.Flush(ctx)
+		cancel()
+	}
+}
+
+func (c *Client) closeConns() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for node, conn := range c.conns {
+		conn.Close()
+		delete(c.conns, node)
+	}
+}
+
+// retryable reports whether err means the node could not handle the call,
+// as opposed to the call itself being refused.
+func retryable(err error, idempotent bool) bool {
+	switch status.Code(err) {
+	case codes.Unavailable:
+		return true
+	case codes.DeadlineExceeded, codes.Aborted:
+		return idempotent
+	}
+	return false
+}
+
+func or[T comparable](v T, fallback T) T {
+	var zero T
+	if v == zero {
+		return fallback
+	}
+	return v
+}
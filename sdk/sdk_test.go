@@ -0,0 +1,102 @@
+package sdk_test
+
+import (
+	"context"
+	"discovery-service/lib/testnode"
+	"discovery-service/sdk"
+	"net"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestClientFailover(t *testing.T) {
+	first := testnode.Start(t, nil, testnode.CounterService())
+	second := testnode.Start(t, []string{first.Id}, testnode.CounterService())
+	// Peers are listed once they answered a heartbeat
+	if !testnode.Eventually(15*time.Second, func() bool { return len(first.ClientAddrs()) == 2 }) {
+		t.Fatalf("Expected the first node to list the second, got %v", first.ClientAddrs())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	// Bootstraps past a seed that is down
+	down, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	down.Close()
+	c, err := sdk.New(ctx, sdk.Options{Seeds: []string{down.Addr().String(), first.Id}, Backoff: time.Minute})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+	if nodes := c.Nodes(); len(nodes) != 2 {
+		t.Fatalf("Expected the client to learn both nodes, got %v", nodes)
+	}
+
+	for range 10 {
+		if _, err := c.Increment(ctx); err != nil {
+			t.Fatalf("Increment failed: %v", err)
+		}
+	}
+	for range 25 {
+		c.IncrementAsync("", 1)
+	}
+	if err := c.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	for _, node := range []*testnode.Node{first, second} {
+		if !testnode.Eventually(5*time.Second, func() bool { return node.Counter(t) == 35 }) {
+			t.Fatalf("Expected %s to count 35, got %d", node.Id, node.Counter(t))
+		}
+	}
+
+	// Calls sent to the stopped node fail over to the other one
+	second.GRPC.Stop()
+	for range 5 {
+		if _, err := c.Increment(ctx); err != nil {
+			t.Fatalf("Increment after failover failed: %v", err)
+		}
+	}
+	if !testnode.Eventually(5*time.Second, func() bool { return first.Counter(t) == 40 }) {
+		t.Fatalf("Expected %s to count 40, got %d", first.Id, first.Counter(t))
+	}
+}
+
+func TestClientUsesClientPorts(t *testing.T) {
+	first := testnode.Start(t, nil, testnode.ClientPort(t))
+	second := testnode.Start(t, []string{first.Id}, testnode.ClientPort(t))
+	if !testnode.Eventually(15*time.Second, func() bool { return len(first.ClientAddrs()) == 2 }) {
+		t.Fatalf("Expected the first node to list the second, got %v", first.ClientAddrs())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	// The peer port does not serve the CounterService, the client port does
+	if _, err := sdk.New(ctx, sdk.Options{Seeds: []string{first.Id}}); err == nil {
+		t.Fatalf("Expected the peer port not to serve clients")
+	}
+	c, err := sdk.New(ctx, sdk.Options{Seeds: []string{first.ClientAddrs()[0]}})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+	want := []string{first.ClientAddrs()[0], second.ClientAddrs()[0]}
+	if nodes := c.Nodes(); !slices.Equal(nodes, want) {
+		t.Fatalf("Expected the client to learn the client ports %v, got %v", want, nodes)
+	}
+
+	for range 4 {
+		if _, err := c.Increment(ctx); err != nil {
+			t.Fatalf("Increment failed: %v", err)
+		}
+	}
+	for _, node := range []*testnode.Node{first, second} {
+		if !testnode.Eventually(5*time.Second, func() bool { return node.Counter(t) == 4 }) {
+			t.Fatalf("Expected %s to count 4, got %d", node.Id, node.Counter(t))
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package apikey
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"sync"
+)
+
+// Permission is what a key may do, each level includes the ones below it.
+type Permission int
+
+const (
+	Read Permission = iota + 1
+	Increment
+	Admin
+)
+
+var permissions = map[string]Permission{"read": Read, "increment": Increment, "admin": Admin}
+
+func (p Permission) String() string {
+	for name, perm := range permissions {
+		if perm == p {
+			return name
+		}
+	}
+	return fmt.Sprintf("Permission(%d)", int(p))
+}
+
+var (
+	ErrUnknownKey = errors.New("unknown API key")
+	ErrForbidden  = errors.New("API key is not allowed to do this")
+	ErrAdminLocal = errors.New("without API keys admin requests are only served from localhost")
+)
+
+// Local reports whether addr, a host:port, is on the node's own host. Without
+// API keys only local callers may make admin requests, so an open node
+// cannot be reconfigured or have its peers removed from elsewhere.
+func Local(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Key is an API key as configured in the keys file. Counters limits the key
+// to matching counter names (glob patterns), empty means all counters. The
+// default counter is named "", so a limited key only gets it, and the
+// requests that are not about a single counter, if "" is listed.
+type Key struct {
+	Name       string   `json:"name"`
+	Key        string   `json:"key"`
+	Permission string   `json:"permission"`
+	Counters   []string `json:"counters,omitempty"`
+
+	perm Permission
+}
+
+// Allows reports whether the key grants perm on counter, "" for the default
+// counter and requests that are not about a single counter.
+func (k *Key) Allows(perm Permission, counter string) bool {
+	if k.perm < perm {
+		return false
+	}
+	if len(k.Counters) == 0 {
+		return true
+	}
+	for _, pattern := range k.Counters {
+		if ok, _ := path.Match(pattern, counter); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Store holds the API keys loaded from a JSON file.
+type Store struct {
+	file string
+
+	tokenSecret []byte // Signs the tokens of IssueToken
+
+	mu   sync.RWMutex
+	keys map[[sha256.Size]byte]*Key
+}
+
+// Load reads the keys file, a JSON list of keys.
+func Load(file string) (*Store, error) {
+	secret, err := newTokenSecret()
+	if err != nil {
+		return nil, err
+	}
+	st := &Store{file: file, tokenSecret: secret}
+	if err := st.Reload(); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// Reload re-reads the keys file, keeping the current keys if it is invalid.
+func (st *Store) Reload() error {
+	apply, err := st.Prepare()
+	if err != nil {
+		return err
+	}
+	apply()
+	return nil
+}
+
+// Prepare re-reads the keys file and returns the function that switches to
+// the new keys, so they can be applied together with other files once all
+// of them were read.
+func (st *Store) Prepare() (apply func(), err error) {
+	data, err := os.ReadFile(st.file)
+	if err != nil {
+		return nil, err
+	}
+	var list []*Key
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", st.file, err)
+	}
+
+	keys := map[[sha256.Size]byte]*Key{}
+	for _, k := range list {
+		if k.Name == "" || k.Key == "" {
+			return nil, fmt.Errorf("every API key needs a name and a key")
+		}
+		perm, ok := permissions[k.Permission]
+		if !ok {
+			return nil, fmt.Errorf("API key %s has unknown permission %q", k.Name, k.Permission)
+		}
+		for _, pattern := range k.Counters {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("API key %s has invalid counter pattern %q", k.Name, pattern)
+			}
+		}
+		k.perm = perm
+		keys[sha256.Sum256([]byte(k.Key))] = k
+	}
+
+	return func() {
+		st.mu.Lock()
+		st.keys = keys
+		st.mu.Unlock()
+	}, nil
+}
+
+// Lookup returns the key matching secret. Keys are looked up by their hash
+// so the comparison does not leak how much of a key was guessed.
+func (st *Store) Lookup(secret string) (*Key, error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	k, ok := st.keys[sha256.Sum256([]byte(secret))]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return k, nil
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the authenticated key.
+func NewContext(ctx context.Context, k *Key) context.Context {
+	return context.WithValue(ctx, contextKey{}, k)
+}
+
+// FromContext returns the key a request was authenticated with, if any.
+func FromContext(ctx context.Context) (*Key, bool) {
+	k, ok := ctx.Value(contextKey{}).(*Key)
+	return k, ok
+}
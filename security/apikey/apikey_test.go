@@ -0,0 +1,176 @@
+package apikey_test
+
+import (
+	"discovery-service/counter/api"
+	"discovery-service/counter/window"
+	"discovery-service/models"
+	"discovery-service/security/apikey"
+	"discovery-service/web"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const keys = `[
+	{"name": "dashboard", "key": "read-key", "permission": "read"},
+	{"name": "billing", "key": "billing-key", "permission": "increment", "counters": ["invoices", "ratelimit:billing-*"]},
+	{"name": "ops", "key": "admin-key", "permission": "admin"}
+]`
+
+func call(t *testing.T, method string, url string, key string) int {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, nil)
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to call %s: %v", url, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestAPIKeyPermissions(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "keys.json")
+	os.WriteFile(file, []byte(keys), 0o600)
+	store, err := apikey.Load(file)
+	if err != nil {
+		t.Fatalf("Failed to load keys: %v", err)
+	}
+
+	s := models.NewServer("localhost:8098")
+	s.APIKeys = store
+	s.Windowed = window.NewCounters(s, time.Minute)
+	web.StartHTTPServer(s, "8098")
+	time.Sleep(500 * time.Millisecond)
+
+	base := "http://localhost:9098"
+	cases := []struct {
+		method string
+		path   string
+		key    string
+		want   int
+	}{
+		{"GET", "/counters/invoices", "", http.StatusUnauthorized},
+		{"GET", "/counters/invoices", "wrong-key", http.StatusUnauthorized},
+		{"GET", "/counters/invoices", "read-key", http.StatusOK},
+		{"POST", "/counters/invoices/increment", "read-key", http.StatusForbidden},
+		{"POST", "/counters/invoices/increment", "billing-key", http.StatusOK},
+		{"POST", "/counters/orders/increment", "billing-key", http.StatusForbidden},
+		{"GET", "/counters/orders", "billing-key", http.StatusForbidden},
+		{"POST", "/ratelimit/check?key=billing-eu&limit=5&window=10s", "billing-key", http.StatusOK},
+		{"POST", "/ratelimit/check?key=search&limit=5&window=10s", "billing-key", http.StatusForbidden},
+		{"POST", "/counters/orders/increment", "admin-key", http.StatusOK},
+		{"GET", "/peers", "read-key", http.StatusOK},
+		{"GET", "/peers", "billing-key", http.StatusForbidden},
+		{"GET", "/count", "billing-key", http.StatusForbidden},
+		{"GET", "/metrics", "billing-key", http.StatusForbidden},
+	}
+	for _, c := range cases {
+		if got := call(t, c.method, base+c.path, c.key); got != c.want {
+			t.Errorf("%s %s with %q: expected %d, got %d", c.method, c.path, c.key, c.want, got)
+		}
+	}
+
+	// X-API-Key works as well as a bearer token
+	req, _ := http.NewRequest(http.MethodGet, base+"/counters/invoices", nil)
+	req.Header.Set("X-API-Key", "read-key")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to call counter API: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected X-API-Key to be accepted, got %d", resp.StatusCode)
+	}
+}
+
+func TestWatchToken(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "keys.json")
+	os.WriteFile(file, []byte(keys), 0o600)
+	store, err := apikey.Load(file)
+	if err != nil {
+		t.Fatalf("Failed to load keys: %v", err)
+	}
+
+	s := models.NewServer("localhost:8116")
+	s.APIKeys = store
+	s.Windowed = window.NewCounters(s, time.Minute)
+	s.Watcher = api.NewHub(s, 0)
+	web.StartHTTPServer(s, "8116")
+	time.Sleep(500 * time.Millisecond)
+	base := "http://localhost:9116"
+
+	req, _ := http.NewRequest(http.MethodPost, base+"/v1/watch-token", nil)
+	req.Header.Set("Authorization", "Bearer read-key")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to get a watch token: %v", err)
+	}
+	var body struct {
+		Token string `json:"token"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || body.Token == "" {
+		t.Fatalf("Expected a watch token, got %d %+v", resp.StatusCode, body)
+	}
+	var cookie *http.Cookie
+	for _, c := range resp.Cookies() {
+		if c.Name == "watch_token" {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.Value != body.Token || !cookie.HttpOnly {
+		t.Fatalf("Expected the token in an HttpOnly cookie, got %v", resp.Cookies())
+	}
+
+	// Tokens are only accepted to watch
+	cases := []struct {
+		path string
+		want int
+	}{
+		{"/count/events?access_token=" + url.QueryEscape(body.Token), http.StatusOK},
+		{"/counters/invoices/ws?access_token=" + url.QueryEscape(body.Token), http.StatusBadRequest}, // Past auth, not a WebSocket handshake
+		{"/count/events?access_token=forged", http.StatusUnauthorized},
+		{"/counters/invoices?access_token=" + url.QueryEscape(body.Token), http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		if got := call(t, http.MethodGet, base+c.path, ""); got != c.want {
+			t.Errorf("GET %s: expected %d, got %d", c.path, c.want, got)
+		}
+	}
+	req, _ = http.NewRequest(http.MethodGet, base+"/count/events", nil)
+	req.AddCookie(cookie)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to watch: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the cookie to be accepted, got %d", resp.StatusCode)
+	}
+
+	expired, _ := store.IssueToken(&apikey.Key{Name: "dashboard"}, -time.Second)
+	if _, err := store.LookupToken(expired); err != apikey.ErrInvalidToken {
+		t.Fatalf("Expected an expired token to be refused, got %v", err)
+	}
+}
+
+func TestLocal(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"127.0.0.1:5001": true,
+		"[::1]:5001":     true,
+		"10.0.0.7:5001":  false,
+		"example.com:80": false,
+	} {
+		if got := apikey.Local(addr); got != want {
+			t.Errorf("Local(%q): expected %v, got %v", addr, want, got)
+		}
+	}
+}
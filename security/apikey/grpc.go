@@ -0,0 +1,60 @@
+package apikey
+
+import (
+	"context"
+	"discovery-service/security/throttle"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// AuthorizeRPC checks that the key sent in the "authorization: Bearer" or
+// the "x-api-key" metadata of a gRPC call grants perm on counter, like the
+// headers of the HTTP API. A nil store allows every
+// call from the node's own host, and every call that does not need Admin
+// from elsewhere, and returns a nil key. Errors are gRPC status errors.
+func (st *Store) AuthorizeRPC(ctx context.Context, perm Permission, counter string) (*Key, error) {
+	if st == nil {
+		if p, ok := peer.FromContext(ctx); perm >= Admin && (!ok || p.Addr == nil || !Local(p.Addr.String())) {
+			return nil, status.Error(codes.PermissionDenied, ErrAdminLocal.Error())
+		}
+		return nil, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	var secret string
+	for _, v := range md.Get("authorization") {
+		if token, ok := strings.CutPrefix(v, "Bearer "); ok {
+			secret = strings.TrimSpace(token)
+		}
+	}
+	if keys := md.Get("x-api-key"); secret == "" && len(keys) > 0 {
+		secret = keys[len(keys)-1]
+	}
+	if secret == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing API key")
+	}
+	key, err := st.Lookup(secret)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if !key.Allows(perm, counter) {
+		return key, status.Error(codes.PermissionDenied, ErrForbidden.Error())
+	}
+	return key, nil
+}
+
+// Caller identifies the caller of a gRPC call like the HTTP API does: the
+// name of its key if it sent one and its address otherwise, which for calls
+// through the HTTP gateway is that of the HTTP client.
+func Caller(ctx context.Context, key *Key) string {
+	if key != nil {
+		return "key:" + key.Name
+	}
+	host := throttle.ClientHost(ctx)
+	if host == "" {
+		return "unknown"
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,77 @@
+package apikey
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, forged or
+// expired.
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// IssueToken returns a token standing in for k until ttl has passed, for
+// clients that cannot send headers, such as browsers opening an EventSource
+// or a WebSocket. Tokens are signed with a secret of the store that is not
+// shared between nodes or restarts, so they are only accepted by the node
+// that issued them.
+func (st *Store) IssueToken(k *Key, ttl time.Duration) (string, time.Time) {
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	payload := strconv.FormatInt(expires.Unix(), 10) + "." + base64.RawURLEncoding.EncodeToString([]byte(k.Name))
+	return payload + "." + st.sign(payload), expires
+}
+
+// LookupToken returns the key a token issued by IssueToken stands for. A
+// key removed from the file since is unknown.
+func (st *Store) LookupToken(token string) (*Key, error) {
+	payload, mac, ok := cutLast(token, ".")
+	if !ok || !hmac.Equal([]byte(mac), []byte(st.sign(payload))) {
+		return nil, ErrInvalidToken
+	}
+	exp, encoded, _ := strings.Cut(payload, ".")
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().After(time.Unix(unix, 0)) {
+		return nil, ErrInvalidToken
+	}
+	name, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	for _, k := range st.keys {
+		if k.Name == string(name) {
+			return k, nil
+		}
+	}
+	return nil, ErrUnknownKey
+}
+
+func (st *Store) sign(payload string) string {
+	mac := hmac.New(sha256.New, st.tokenSecret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newTokenSecret() ([]byte, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("generate token secret: %w", err)
+	}
+	return secret, nil
+}
+
+func cutLast(s string, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return "", "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
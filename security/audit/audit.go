@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Actions recorded in the audit log.
+const (
+	PeerRegistered   = "peer.registered"
+	PeerRejected     = "peer.rejected"
+	PeerDead         = "peer.dead"
+	PeerHealed       = "peer.healed"
+	PeerRemoved      = "peer.removed"
+	LogLevelSet      = "log.level"
+	PartitionEntered = "partition.entered"
+	PartitionHealed  = "partition.healed"
+	ConfigReloaded   = "config.reloaded"
+)
+
+// keep is how many entries are kept in memory for queries.
+const keep = 10000
+
+// Entry is one audited action. Actor is who caused it: a node id, an API
+// key name or the client address.
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Node   string    `json:"node"`
+	Action string    `json:"action"`
+	Actor  string    `json:"actor"`
+	Target string    `json:"target,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// Filter selects entries, zero fields match everything. Action matches
+// entries whose action starts with it, so "peer." selects all membership
+// changes.
+type Filter struct {
+	Action string
+	Actor  string
+	Target string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+// Log is an append-only audit log of this node. Entries are appended to a
+// JSON lines file if one is configured and the latest are kept in memory.
+type Log struct {
+	node   string
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	file    *os.File
+	entries []Entry
+}
+
+// New creates the audit log of node, appending to file unless it is "".
+// Write failures are reported to logger.
+func New(node string, file string, logger *slog.Logger) (*Log, error) {
+	l := &Log{node: node, logger: logger}
+	if file == "" {
+		return l, nil
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	l.file = f
+	return l, nil
+}
+
+// Record appends an entry. It is a no-op on a nil log so callers do not need
+// to check whether auditing is enabled.
+func (l *Log) Record(action string, actor string, target string, detail string) {
+	if l == nil {
+		return
+	}
+	e := Entry{Time: time.Now().UTC(), Node: l.node, Action: action, Actor: actor, Target: target, Detail: detail}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, e)
+	if len(l.entries) > keep {
+		l.entries = append([]Entry(nil), l.entries[len(l.entries)-keep:]...)
+	}
+	if l.file != nil {
+		line, _ := json.Marshal(e)
+		if _, err := fmt.Fprintf(l.file, "%s\n", line); err != nil {
+			l.logger.Error("Failed to write audit entry", "err", err)
+		}
+	}
+}
+
+// Query returns the entries matching f, newest first.
+func (l *Log) Query(f Filter) []Entry {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := []Entry{}
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		e := l.entries[i]
+		if !f.matches(e) {
+			continue
+		}
+		result = append(result, e)
+		if f.Limit > 0 && len(result) == f.Limit {
+			break
+		}
+	}
+	return result
+}
+
+func (f Filter) matches(e Entry) bool {
+	switch {
+	case f.Action != "" && !strings.HasPrefix(e.Action, f.Action):
+		return false
+	case f.Actor != "" && e.Actor != f.Actor:
+		return false
+	case f.Target != "" && e.Target != f.Target:
+		return false
+	case !f.Since.IsZero() && e.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && e.Time.After(f.Until):
+		return false
+	}
+	return true
+}
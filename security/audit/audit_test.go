@@ -0,0 +1,76 @@
+package audit_test
+
+import (
+	"bufio"
+	"context"
+	"discovery-service/models"
+	"discovery-service/proto"
+	"discovery-service/security/audit"
+	"discovery-service/web"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAuditMembershipChanges(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.log")
+	s := models.NewServer("localhost:8100")
+	auditLog, err := audit.New(s.Id, file, s.Logger("audit"))
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	s.Audit = auditLog
+	web.StartHTTPServer(s, "8100")
+	time.Sleep(500 * time.Millisecond)
+
+	if _, err := s.Register(context.Background(), &proto.RegisterRequest{Id: "localhost:5001"}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, "http://localhost:9100/admin/peers/localhost:5001", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to call remove API: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected removal to succeed, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get("http://localhost:9100/admin/audit?target=localhost:5001&action=peer.")
+	if err != nil {
+		t.Fatalf("Failed to call audit API: %v", err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Entries []audit.Entry `json:"entries"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode audit response: %v", err)
+	}
+	if len(result.Entries) != 2 || result.Entries[0].Action != audit.PeerRemoved || result.Entries[1].Action != audit.PeerRegistered {
+		t.Fatalf("Expected removal then registration, newest first, got %+v", result.Entries)
+	}
+	if result.Entries[0].Actor != "ip:127.0.0.1" && result.Entries[0].Actor != "ip:::1" {
+		t.Fatalf("Expected the removal to be attributed to the client, got %q", result.Entries[0].Actor)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatalf("Failed to open audit file: %v", err)
+	}
+	defer f.Close()
+	lines := 0
+	for scanner := bufio.NewScanner(f); scanner.Scan(); lines++ {
+		var e audit.Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("Invalid audit line %q: %v", scanner.Text(), err)
+		}
+	}
+	if lines != 2 {
+		t.Fatalf("Expected 2 entries in the audit file, got %d", lines)
+	}
+}
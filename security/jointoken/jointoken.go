@@ -0,0 +1,211 @@
+package jointoken
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultTTL is how long challenges and call proofs are accepted unless
+// SetTTL is called.
+const defaultTTL = 30 * time.Second
+
+// Metadata of the proof sent with every call between nodes.
+const (
+	clusterKey = "x-join-cluster"
+	nodeKey    = "x-join-node"
+	timeKey    = "x-join-time"
+	proofKey   = "x-join-proof"
+)
+
+var (
+	// ErrWrongCluster is returned to nodes configured for another cluster.
+	ErrWrongCluster = errors.New("node belongs to another cluster")
+	ErrBadProof     = errors.New("invalid join token proof")
+)
+
+// Authenticator admits only nodes of the same cluster. With a join token
+// configured, a node also proves it knows the token by answering a single
+// use challenge with an HMAC, so the token never crosses the wire.
+type Authenticator struct {
+	cluster string
+	token   []byte
+
+	ttl atomic.Int64 // time.Duration
+
+	mu       sync.Mutex
+	nonces   map[string]time.Time
+	rejected atomic.Int64
+}
+
+// New creates an authenticator for cluster. An empty token only checks the
+// cluster name.
+func New(cluster string, token string) *Authenticator {
+	a := &Authenticator{cluster: cluster, nonces: map[string]time.Time{}}
+	if token != "" {
+		a.token = []byte(token)
+	}
+	a.ttl.Store(int64(defaultTTL))
+	return a
+}
+
+// SetTTL changes how long challenges and call proofs are accepted. Nodes
+// whose clocks differ by more than ttl cannot call each other.
+func (a *Authenticator) SetTTL(ttl time.Duration) {
+	a.ttl.Store(int64(ttl))
+}
+
+// Cluster is the name of the cluster this node belongs to.
+func (a *Authenticator) Cluster() string {
+	return a.cluster
+}
+
+// Challenge issues a nonce the peer has to answer, or "" when no join token
+// is configured.
+func (a *Authenticator) Challenge() string {
+	if a.token == nil {
+		return ""
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	nonce := hex.EncodeToString(b)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	for n, expiry := range a.nonces {
+		if now.After(expiry) {
+			delete(a.nonces, n)
+		}
+	}
+	a.nonces[nonce] = now.Add(time.Duration(a.ttl.Load()))
+	return nonce
+}
+
+// Proof answers a challenge on behalf of node id.
+func (a *Authenticator) Proof(id string, nonce string) []byte {
+	if a.token == nil || nonce == "" {
+		return nil
+	}
+	return sign(a.token, a.cluster, id, nonce)
+}
+
+// Verify checks the cluster name and, with a join token configured, the
+// proof for a nonce issued by Challenge. Every failure is counted.
+func (a *Authenticator) Verify(cluster string, id string, nonce string, proof []byte) error {
+	err := a.verify(cluster, id, nonce, proof)
+	if err != nil {
+		a.rejected.Add(1)
+	}
+	return err
+}
+
+// Rejected returns the number of rejected join attempts and heartbeats.
+func (a *Authenticator) Rejected() int64 {
+	return a.rejected.Load()
+}
+
+func (a *Authenticator) verify(cluster string, id string, nonce string, proof []byte) error {
+	if cluster != a.cluster {
+		return fmt.Errorf("%w: %s is in cluster %q, expected %q", ErrWrongCluster, id, cluster, a.cluster)
+	}
+	if a.token == nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	expiry, ok := a.nonces[nonce]
+	delete(a.nonces, nonce)
+	a.mu.Unlock()
+	if !ok || time.Now().After(expiry) {
+		return fmt.Errorf("%w: unknown or expired challenge", ErrBadProof)
+	}
+	if !hmac.Equal(proof, sign(a.token, cluster, id, nonce)) {
+		return ErrBadProof
+	}
+	return nil
+}
+
+func sign(token []byte, cluster string, id string, nonce string) []byte {
+	mac := hmac.New(sha256.New, token)
+	mac.Write([]byte(cluster + "\n" + id + "\n" + nonce))
+	return mac.Sum(nil)
+}
+
+// Credentials proves on every call node id makes that it is a member of the
+// cluster. With a join token configured, calls carry the time signed with
+// the token, which is accepted for the TTL. Without mutual TLS a proof can
+// be replayed within that time by anyone who can read the traffic.
+func (a *Authenticator) Credentials(id string) credentials.PerRPCCredentials {
+	return callCredentials{a: a, id: id}
+}
+
+type callCredentials struct {
+	a  *Authenticator
+	id string
+}
+
+func (c callCredentials) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	md := map[string]string{clusterKey: c.a.cluster, nodeKey: c.id}
+	if c.a.token != nil {
+		now := strconv.FormatInt(time.Now().Unix(), 10)
+		md[timeKey] = now
+		md[proofKey] = hex.EncodeToString(sign(c.a.token, c.a.cluster, c.id, "call:"+now))
+	}
+	return md, nil
+}
+
+func (callCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// VerifyCall checks the proof sent by Credentials with an incoming call and
+// returns the node that made it. Every failure is counted.
+func (a *Authenticator) VerifyCall(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	value := func(key string) string {
+		if v := md.Get(key); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	id := value(nodeKey)
+	err := a.verifyCall(value(clusterKey), id, value(timeKey), value(proofKey))
+	if err != nil {
+		a.rejected.Add(1)
+	}
+	return id, err
+}
+
+func (a *Authenticator) verifyCall(cluster string, id string, at string, proof string) error {
+	if id == "" {
+		return fmt.Errorf("%w: the call carries no join proof", ErrBadProof)
+	}
+	if cluster != a.cluster {
+		return fmt.Errorf("%w: %s is in cluster %q, expected %q", ErrWrongCluster, id, cluster, a.cluster)
+	}
+	if a.token == nil {
+		return nil
+	}
+
+	sent, err := strconv.ParseInt(at, 10, 64)
+	ttl := time.Duration(a.ttl.Load())
+	if age := time.Since(time.Unix(sent, 0)); err != nil || age > ttl || age < -ttl {
+		return fmt.Errorf("%w: missing or expired call proof", ErrBadProof)
+	}
+	mac, err := hex.DecodeString(proof)
+	if err != nil || !hmac.Equal(mac, sign(a.token, cluster, id, "call:"+at)) {
+		return ErrBadProof
+	}
+	return nil
+}
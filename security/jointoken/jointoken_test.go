@@ -0,0 +1,132 @@
+package jointoken_test
+
+import (
+	"context"
+	"discovery-service/models"
+	"discovery-service/proto"
+	"discovery-service/security/jointoken"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"net"
+	"testing"
+	"time"
+)
+
+func startAuthNode(t *testing.T, auth *jointoken.Authenticator) (proto.DiscoveryClient, string) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := models.NewServer(lis.Addr().String())
+	s.Auth = auth
+
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(s.UnaryServerInterceptor()))
+	proto.RegisterDiscoveryServer(grpcServer, s)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return proto.NewDiscoveryClient(conn), lis.Addr().String()
+}
+
+func register(t *testing.T, peer proto.DiscoveryClient, auth *jointoken.Authenticator) error {
+	t.Helper()
+	node := models.NewServer("localhost:5001")
+	node.Auth = auth
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := node.NewRegisterRequest(ctx, peer)
+	if err != nil {
+		return err
+	}
+	_, err = peer.Register(ctx, req)
+	return err
+}
+
+func TestJoinToken(t *testing.T) {
+	auth := jointoken.New("prod", "secret")
+	peer, _ := startAuthNode(t, auth)
+
+	if err := register(t, peer, jointoken.New("prod", "secret")); err != nil {
+		t.Fatalf("Expected registration with the join token to succeed: %v", err)
+	}
+
+	err := register(t, peer, jointoken.New("prod", "guess"))
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Expected Unauthenticated for a wrong token, got %v", err)
+	}
+
+	err = register(t, peer, jointoken.New("test", "secret"))
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Expected FailedPrecondition for another cluster, got %v", err)
+	}
+
+	err = register(t, peer, nil)
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Expected FailedPrecondition for a node without a cluster, got %v", err)
+	}
+
+	// A proof cannot be replayed
+	node := jointoken.New("prod", "secret")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	challenge, err := peer.Challenge(ctx, &proto.ChallengeRequest{Id: "localhost:5001"})
+	if err != nil {
+		t.Fatalf("Challenge failed: %v", err)
+	}
+	req := &proto.HeartbeatRequest{Id: "localhost:5001", Cluster: "prod", Nonce: challenge.Nonce, Proof: node.Proof("localhost:5001", challenge.Nonce)}
+	if _, err := peer.Heartbeat(ctx, req); err != nil {
+		t.Fatalf("Expected heartbeat to succeed: %v", err)
+	}
+	if _, err := peer.Heartbeat(ctx, req); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Expected a replayed proof to be rejected, got %v", err)
+	}
+
+	if got := auth.Rejected(); got != 4 {
+		t.Fatalf("Expected 4 rejected attempts, got %d", got)
+	}
+}
+
+func TestPeerCallsNeedProof(t *testing.T) {
+	auth := jointoken.New("prod", "secret")
+	peer, addr := startAuthNode(t, auth)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := peer.GetCounter(ctx, &proto.Empty{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Expected a call without a proof to be refused, got %v", err)
+	}
+	// Without API keys clients can still read the membership.
+	if _, err := peer.GetStatus(ctx, &proto.Empty{}); err != nil {
+		t.Fatalf("Expected GetStatus to be open to clients: %v", err)
+	}
+
+	for _, c := range []struct {
+		auth *jointoken.Authenticator
+		want codes.Code
+	}{
+		{jointoken.New("prod", "secret"), codes.OK},
+		{jointoken.New("prod", "guess"), codes.Unauthenticated},
+		{jointoken.New("test", "secret"), codes.FailedPrecondition},
+	} {
+		node := models.NewServer("localhost:5001")
+		node.Auth = c.auth
+		conn, err := grpc.NewClient(addr, node.DialOptions()...)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		_, err = proto.NewDiscoveryClient(conn).GetCounter(ctx, &proto.Empty{})
+		conn.Close()
+		if status.Code(err) != c.want {
+			t.Errorf("Expected %s for cluster %s, got %v", c.want, c.auth.Cluster(), err)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package mtls_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"discovery-service/models"
+	"discovery-service/proto"
+	"discovery-service/security/mtls"
+	"encoding/pem"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newCA(t *testing.T) *testCA {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// writeNode writes the CA, and a node certificate for host signed by it, to dir.
+func (ca *testCA) writeNode(t *testing.T, dir string, host string) (string, string, string) {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, host+".pem")
+	keyFile := filepath.Join(dir, host+"-key.pem")
+	os.WriteFile(caFile, ca.pem, 0o600)
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return caFile, certFile, keyFile
+}
+
+func startTLSNode(t *testing.T, r *mtls.Reloader) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := models.NewServer(lis.Addr().String())
+	s.TLS = r
+
+	grpcServer := grpc.NewServer(grpc.Creds(r.ServerCredentials()))
+	proto.RegisterDiscoveryServer(grpcServer, s)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+	return lis.Addr().String()
+}
+
+func register(t *testing.T, addr string, r *mtls.Reloader, id string) error {
+	t.Helper()
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(r.ClientCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err = proto.NewDiscoveryClient(conn).Register(ctx, &proto.RegisterRequest{Id: id})
+	return err
+}
+
+func TestMutualTLSRegistration(t *testing.T) {
+	ca := newCA(t)
+	server, err := mtls.Load(ca.writeNode(t, t.TempDir(), "localhost"))
+	if err != nil {
+		t.Fatalf("Failed to load server certificates: %v", err)
+	}
+	addr := startTLSNode(t, server)
+
+	node, err := mtls.Load(ca.writeNode(t, t.TempDir(), "localhost"))
+	if err != nil {
+		t.Fatalf("Failed to load node certificates: %v", err)
+	}
+	if err := register(t, addr, node, "localhost:5001"); err != nil {
+		t.Fatalf("Expected registration with a matching certificate to succeed: %v", err)
+	}
+
+	// The certificate is valid but does not belong to the claimed node
+	err = register(t, addr, node, "other-host:5001")
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("Expected PermissionDenied for a mismatched node id, got %v", err)
+	}
+
+	// Certificates from another CA are rejected during the handshake
+	stranger, err := mtls.Load(newCA(t).writeNode(t, t.TempDir(), "localhost"))
+	if err != nil {
+		t.Fatalf("Failed to load stranger certificates: %v", err)
+	}
+	if err := register(t, addr, stranger, "localhost:5002"); err == nil {
+		t.Fatalf("Expected a certificate from another CA to be rejected")
+	}
+}
+
+func TestReloadRotatesCA(t *testing.T) {
+	dir := t.TempDir()
+	oldCA := newCA(t)
+	server, err := mtls.Load(oldCA.writeNode(t, dir, "localhost"))
+	if err != nil {
+		t.Fatalf("Failed to load server certificates: %v", err)
+	}
+	addr := startTLSNode(t, server)
+
+	newCA := newCA(t)
+	node, err := mtls.Load(newCA.writeNode(t, t.TempDir(), "localhost"))
+	if err != nil {
+		t.Fatalf("Failed to load node certificates: %v", err)
+	}
+	if err := register(t, addr, node, "localhost:5001"); err == nil {
+		t.Fatalf("Expected a certificate from the new CA to be rejected before rotation")
+	}
+
+	// Rotate the server to the new CA in place and reload
+	newCA.writeNode(t, dir, "localhost")
+	if err := server.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if err := register(t, addr, node, "localhost:5001"); err != nil {
+		t.Fatalf("Expected registration to succeed after rotation: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package throttle
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gatewayToken marks the calls of the node's own HTTP gateway, the only
+// calls whose x-forwarded-for is trusted. It never leaves the process.
+var gatewayToken = newGatewayToken()
+
+const gatewayTokenKey = "x-gateway-token"
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter is a token bucket per client. Every client may send burst
+// requests at once and rate requests per second after that.
+type Limiter struct {
+	rate  float64
+	burst float64
+	idle  time.Duration // How long a quiet client's bucket is kept
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// New creates a limiter that forgets clients quiet for idle, nil if rate is
+// not positive so limits can be switched off.
+func New(rate float64, burst int, idle time.Duration) *Limiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &Limiter{
+		rate:      rate,
+		burst:     math.Max(float64(burst), 1),
+		idle:      idle,
+		buckets:   map[string]*bucket{},
+		lastSweep: time.Now(),
+	}
+}
+
+// SetRate changes the limits of clients, a rate that is not positive lets
+// every request through. Buckets keep the tokens clients have used, so a
+// larger burst is available at once and a smaller one caps them, and refill
+// at the new rate from now on.
+func (l *Limiter) SetRate(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	next := math.Max(float64(burst), 1)
+	for _, b := range l.buckets {
+		b.tokens = math.Min(next, b.tokens+math.Max(next-l.burst, 0))
+	}
+	l.rate = rate
+	l.burst = next
+}
+
+// Allow takes a token from client's bucket. If the bucket is empty it
+// returns false and how long until a token is available.
+func (l *Limiter) Allow(client string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate <= 0 {
+		return true, 0
+	}
+	l.sweep(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops the buckets of clients that have been quiet long enough for
+// their bucket to refill, so the map does not grow with every address seen.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idle {
+		return
+	}
+	l.lastSweep = now
+	for client, b := range l.buckets {
+		if now.Sub(b.last) > l.idle {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// UnaryServerInterceptor rejects calls from peers over their limit with
+// ResourceExhausted and a RetryInfo detail.
+func UnaryServerInterceptor(l *Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if ok, retry := l.Allow(ClientHost(ctx)); !ok {
+			return nil, exhausted(info.FullMethod, retry)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming calls.
+func StreamServerInterceptor(l *Limiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if ok, retry := l.Allow(ClientHost(ss.Context())); !ok {
+			return exhausted(info.FullMethod, retry)
+		}
+		return handler(srv, ss)
+	}
+}
+
+func exhausted(method string, retry time.Duration) error {
+	st := status.Newf(codes.ResourceExhausted, "too many requests to %s, retry in %s", method, retry.Round(time.Millisecond))
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retry)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// GatewayMetadata marks the calls of the node's own HTTP gateway, so they
+// are limited as the HTTP client they were made for. It is meant for
+// runtime.WithMetadata.
+func GatewayMetadata(context.Context, *http.Request) metadata.MD {
+	return metadata.Pairs(gatewayTokenKey, gatewayToken)
+}
+
+// ClientHost is the client a call is limited as: the host of the peer, or
+// for calls from the node's own HTTP gateway the HTTP client they were made
+// for. Other callers cannot pass for someone else with x-forwarded-for, as
+// they do not have the gateway's token.
+func ClientHost(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		if md, ok := metadata.FromIncomingContext(ctx); ok && fromGateway(md) {
+			if fwd := md.Get("x-forwarded-for"); len(fwd) > 0 {
+				hops := strings.Split(fwd[len(fwd)-1], ",")
+				return strings.TrimSpace(hops[len(hops)-1])
+			}
+		}
+	}
+	return host
+}
+
+func fromGateway(md metadata.MD) bool {
+	tokens := md.Get(gatewayTokenKey)
+	return len(tokens) == 1 && subtle.ConstantTimeCompare([]byte(tokens[0]), []byte(gatewayToken)) == 1
+}
+
+func newGatewayToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
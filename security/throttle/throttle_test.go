@@ -0,0 +1,107 @@
+package throttle_test
+
+import (
+	"context"
+	"discovery-service/models"
+	"discovery-service/proto"
+	"discovery-service/security/throttle"
+	"discovery-service/web"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPLimits(t *testing.T) {
+	s := models.NewServer("localhost:8099")
+	s.HTTPLimit = throttle.New(1, 3, time.Minute)
+	s.MaxRequestBytes = 16
+	web.StartHTTPServer(s, "8099")
+	time.Sleep(500 * time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get("http://localhost:9099/peers")
+		if err != nil {
+			t.Fatalf("Failed to call peers API: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected request %d within the burst to succeed, got %d", i, resp.StatusCode)
+		}
+	}
+
+	resp, err := http.Get("http://localhost:9099/peers")
+	if err != nil {
+		t.Fatalf("Failed to call peers API: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "1" {
+		t.Fatalf("Expected 429 with Retry-After 1, got %d %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+
+	time.Sleep(time.Second)
+	resp, err = http.Post("http://localhost:9099/peers", "text/plain", strings.NewReader(strings.Repeat("x", 100)))
+	if err != nil {
+		t.Fatalf("Failed to call peers API: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected 413 for a large body, got %d", resp.StatusCode)
+	}
+}
+
+func TestGRPCLimit(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	limit := throttle.New(1, 2, time.Minute)
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(throttle.UnaryServerInterceptor(limit)))
+	proto.RegisterDiscoveryServer(grpcServer, models.NewServer(lis.Addr().String()))
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer conn.Close()
+	client := proto.NewDiscoveryClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetPeers(ctx, &proto.Empty{}); err != nil {
+			t.Fatalf("Expected call %d within the burst to succeed: %v", i, err)
+		}
+	}
+
+	_, err = client.GetPeers(ctx, &proto.Empty{})
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("Expected ResourceExhausted, got %v", err)
+	}
+
+	// Only the node's own gateway can have calls limited as another client
+	spoofed := metadata.AppendToOutgoingContext(ctx, "x-forwarded-for", "203.0.113.9")
+	if _, err := client.GetPeers(spoofed, &proto.Empty{}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected x-forwarded-for without the gateway token to be ignored, got %v", err)
+	}
+	forwarded := metadata.NewOutgoingContext(ctx, metadata.Join(throttle.GatewayMetadata(ctx, nil), metadata.Pairs("x-forwarded-for", "203.0.113.9")))
+	if _, err := client.GetPeers(forwarded, &proto.Empty{}); err != nil {
+		t.Fatalf("Expected a gateway call to be limited as its HTTP client: %v", err)
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok && info.RetryDelay.AsDuration() > 0 {
+			return
+		}
+	}
+	t.Fatalf("Expected a RetryInfo detail, got %v", st.Details())
+}
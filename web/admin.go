@@ -0,0 +1,137 @@
+package web
+
+import (
+	"discovery-service/config"
+	"discovery-service/discovery/heartbeat"
+	"discovery-service/models"
+	"discovery-service/security/apikey"
+	"discovery-service/security/audit"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// auditHandler returns the audit log, newest first, e.g.
+// /admin/audit?action=peer.&since=2024-01-01T00:00:00Z&limit=50.
+func auditHandler(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		f := audit.Filter{Action: q.Get("action"), Actor: q.Get("actor"), Target: q.Get("target")}
+
+		var err error
+		if v := q.Get("since"); v != "" {
+			if f.Since, err = time.Parse(time.RFC3339, v); err != nil {
+				http.Error(w, "invalid since, expected RFC 3339", http.StatusBadRequest)
+				return
+			}
+		}
+		if v := q.Get("until"); v != "" {
+			if f.Until, err = time.Parse(time.RFC3339, v); err != nil {
+				http.Error(w, "invalid until, expected RFC 3339", http.StatusBadRequest)
+				return
+			}
+		}
+		if v := q.Get("limit"); v != "" {
+			if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 0 {
+				http.Error(w, "invalid limit", http.StatusBadRequest)
+				return
+			}
+		}
+		if s.Audit == nil {
+			http.Error(w, "audit log is disabled", http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"entries": s.Audit.Query(f)})
+	}
+}
+
+// removePeerHandler force-removes a peer from this node's membership.
+func removePeerHandler(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		peer := r.PathValue("id")
+		if !heartbeat.Forget(s, peer) {
+			http.Error(w, "unknown peer "+peer, http.StatusNotFound)
+			return
+		}
+		s.Audit.Record(audit.PeerRemoved, actor(r), peer, "")
+		writeJSON(w, map[string]interface{}{"removed": peer})
+	}
+}
+
+// actor identifies who sent r for the audit log, the API key name if the
+// request was authenticated and the client address otherwise.
+func actor(r *http.Request) string {
+	if key, ok := apikey.FromContext(r.Context()); ok {
+		return "key:" + key.Name
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// logLevelHandler shows the log levels on GET, sets the level of a subsystem
+// (or the default level without one) on PUT, e.g.
+// PUT /admin/loglevel?subsystem=raft&level=debug, and makes a subsystem log
+// at the default level again on DELETE.
+func logLevelHandler(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subsystem := r.URL.Query().Get("subsystem")
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			var level slog.Level
+			if err := level.UnmarshalText([]byte(r.URL.Query().Get("level"))); err != nil {
+				http.Error(w, "invalid level, expected debug, info, warn or error", http.StatusBadRequest)
+				return
+			}
+			s.Log.SetLevel(subsystem, level)
+			s.Audit.Record(audit.LogLevelSet, actor(r), subsystem, level.String())
+		case http.MethodDelete:
+			if subsystem == "" {
+				http.Error(w, "subsystem is required", http.StatusBadRequest)
+				return
+			}
+			s.Log.ResetLevel(subsystem)
+			s.Audit.Record(audit.LogLevelSet, actor(r), subsystem, "default")
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		level, overrides := s.Log.Levels()
+		subsystems := map[string]string{}
+		for name, l := range overrides {
+			subsystems[name] = l.String()
+		}
+		writeJSON(w, map[string]interface{}{"level": level.String(), "subsystems": subsystems})
+	}
+}
+
+// reloadHandler reloads the configuration of the node and returns which
+// changed settings were applied and which need a restart.
+func reloadHandler(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.Reloader == nil {
+			http.Error(w, "configuration reload is not available", http.StatusServiceUnavailable)
+			return
+		}
+		changes, err := s.Reloader.Reload(actor(r))
+		if errors.Is(err, config.ErrInvalid) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			// Certificates or keys that cannot be read are the node's
+			// problem, not the caller's.
+			http.Error(w, "reload failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, changes)
+	}
+}
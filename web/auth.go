@@ -0,0 +1,120 @@
+package web
+
+import (
+	"discovery-service/counter/ratelimit"
+	"discovery-service/models"
+	"discovery-service/security/apikey"
+	"net/http"
+	"strings"
+)
+
+// authorize wraps h so it only runs for requests with an API key granting
+// perm, on the counter returned by scope if it is not nil. Without API keys
+// configured every request is allowed, except admin requests from other
+// hosts.
+func authorize(s *models.Server, perm apikey.Permission, scope func(*http.Request) string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.APIKeys == nil {
+			if perm >= apikey.Admin && !apikey.Local(r.RemoteAddr) {
+				httpError(w, r, apikey.ErrAdminLocal.Error(), http.StatusForbidden)
+				return
+			}
+			h(w, r)
+			return
+		}
+
+		secret := requestKey(r)
+		if secret == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="discovery"`)
+			httpError(w, r, "missing API key", http.StatusUnauthorized)
+			return
+		}
+		key, err := s.APIKeys.Lookup(secret)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="discovery", error="invalid_token"`)
+			httpError(w, r, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		counter := ""
+		if scope != nil {
+			counter = scope(r)
+		}
+		if !key.Allows(perm, counter) {
+			s.Logger("web").Info("API key denied", "key", key.Name, "method", r.Method, "path", r.URL.Path)
+			httpError(w, r, apikey.ErrForbidden.Error(), http.StatusForbidden)
+			return
+		}
+		h(w, r.WithContext(apikey.NewContext(r.Context(), key)))
+	}
+}
+
+// watchTokenCookie holds a token from /v1/watch-token for browsers.
+const watchTokenCookie = "watch_token"
+
+// authorizeWatch wraps a watch handler like authorize with the Read
+// permission, also accepting a token from /v1/watch-token in the
+// access_token parameter or the watch_token cookie, as browsers cannot send
+// headers with an EventSource or a WebSocket.
+func authorizeWatch(s *models.Server, scope func(*http.Request) string, h http.HandlerFunc) http.HandlerFunc {
+	next := authorize(s, apikey.Read, scope, h)
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("access_token")
+		if cookie, err := r.Cookie(watchTokenCookie); err == nil && token == "" {
+			token = cookie.Value
+		}
+		if s.APIKeys == nil || requestKey(r) != "" || token == "" {
+			next(w, r)
+			return
+		}
+
+		key, err := s.APIKeys.LookupToken(token)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="discovery", error="invalid_token"`)
+			httpError(w, r, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		counter := ""
+		if scope != nil {
+			counter = scope(r)
+		}
+		if !key.Allows(apikey.Read, counter) {
+			s.Logger("web").Info("API key denied", "key", key.Name, "method", r.Method, "path", r.URL.Path)
+			httpError(w, r, apikey.ErrForbidden.Error(), http.StatusForbidden)
+			return
+		}
+		h(w, r.WithContext(apikey.NewContext(r.Context(), key)))
+	}
+}
+
+// requestKey reads the API key from an "Authorization: Bearer" or an
+// X-API-Key header.
+func requestKey(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+func pathCounter(r *http.Request) string {
+	return r.PathValue("name")
+}
+
+// publicCounter wraps h so it refuses the counters of rate limit keys.
+func publicCounter(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ratelimit.Reserved(r.PathValue("name")) {
+			httpError(w, r, "counter names starting with "+ratelimit.Prefix+" are reserved", http.StatusBadRequest)
+			return
+		}
+		h(w, r)
+	}
+}
+
+func queryCounter(r *http.Request) string {
+	return r.URL.Query().Get("counter")
+}
+
+func rateLimitKey(r *http.Request) string {
+	return ratelimit.Prefix + r.URL.Query().Get("key")
+}
@@ -0,0 +1,182 @@
+package web
+
+import (
+	"discovery-service/counter/bounded"
+	"discovery-service/models"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// counterIncrementHandler increments a named counter, dispatching on the
+// kind of counter the name is configured as.
+func counterIncrementHandler(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+
+		delta := int64(1)
+		if d := r.URL.Query().Get("delta"); d != "" {
+			var err error
+			if delta, err = strconv.ParseInt(d, 10, 64); err != nil {
+				http.Error(w, "invalid delta", http.StatusBadRequest)
+				return
+			}
+		}
+
+		switch {
+		case s.Strong != nil && s.Strong.Strong(name):
+			count, err := s.Strong.Increment(r.Context(), name, delta)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			writeJSON(w, map[string]interface{}{"name": name, "count": count})
+
+		case s.Bounded != nil && s.Bounded.Bounded(name):
+			if delta <= 0 {
+				http.Error(w, "delta must be positive", http.StatusBadRequest)
+				return
+			}
+			remaining, err := s.Bounded.Increment(r.Context(), name, delta)
+			// As on /v1, retrying later does not help.
+			if errors.Is(err, bounded.ErrLimitReached) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, map[string]interface{}{"name": name, "allowed": true, "local_remaining": remaining})
+
+		case s.Windowed != nil:
+			// Buckets only grow, gossip keeps the largest value of each.
+			if delta <= 0 {
+				http.Error(w, "delta must be positive", http.StatusBadRequest)
+				return
+			}
+			s.Windowed.Increment(name, delta)
+			count, _ := s.Windowed.Count(name, s.Windowed.Retention())
+			writeJSON(w, map[string]interface{}{"name": name, "count": count, "window": s.Windowed.Retention().String()})
+
+		default:
+			http.Error(w, "unknown counter "+name, http.StatusNotFound)
+		}
+	}
+}
+
+// counterHandler reads a named counter.
+func counterHandler(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+
+		switch {
+		case s.Strong != nil && s.Strong.Strong(name):
+			count, err := s.Strong.Get(r.Context(), name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			writeJSON(w, map[string]interface{}{"name": name, "count": count})
+
+		case s.Bounded != nil && s.Bounded.Bounded(name):
+			used, limit, err := s.Bounded.Get(r.Context(), name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, map[string]interface{}{"name": name, "count": used, "limit": limit})
+
+		case s.Windowed != nil:
+			count, _ := s.Windowed.Count(name, s.Windowed.Retention())
+			writeJSON(w, map[string]interface{}{"name": name, "count": count, "window": s.Windowed.Retention().String()})
+
+		default:
+			http.Error(w, "unknown counter "+name, http.StatusNotFound)
+		}
+	}
+}
+
+// counterRateHandler reports how often a windowed counter was incremented
+// over the requested window, 60s unless given.
+func counterRateHandler(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if s.Windowed == nil {
+			http.Error(w, "windowed counters are disabled", http.StatusNotFound)
+			return
+		}
+
+		window := min(time.Minute, s.Windowed.Retention())
+		if v := r.URL.Query().Get("window"); v != "" {
+			var err error
+			if window, err = time.ParseDuration(v); err != nil {
+				http.Error(w, "invalid window", http.StatusBadRequest)
+				return
+			}
+		}
+
+		count, err := s.Windowed.Count(name, window)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"name":   name,
+			"window": window.String(),
+			"count":  count,
+			"rate":   float64(count) / window.Seconds(),
+		})
+	}
+}
+
+// counterAddHandler adds an item to a distinct counter.
+func counterAddHandler(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if s.Distinct == nil {
+			http.Error(w, "distinct counters are disabled", http.StatusNotFound)
+			return
+		}
+		item := r.URL.Query().Get("item")
+		if item == "" {
+			http.Error(w, "item is required", http.StatusBadRequest)
+			return
+		}
+
+		s.Distinct.Add(name, item)
+		estimate, _, _ := s.Distinct.Cardinality(name)
+		writeJSON(w, map[string]interface{}{"name": name, "cardinality": estimate})
+	}
+}
+
+// counterCardinalityHandler reports the estimated number of distinct items
+// of a distinct counter.
+func counterCardinalityHandler(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if s.Distinct == nil {
+			http.Error(w, "distinct counters are disabled", http.StatusNotFound)
+			return
+		}
+
+		estimate, stdError, ok := s.Distinct.Cardinality(name)
+		if !ok {
+			http.Error(w, "unknown counter "+name, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"name":        name,
+			"cardinality": estimate,
+			"precision":   s.Distinct.Precision(),
+			"std_error":   stdError,
+		})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
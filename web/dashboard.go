@@ -0,0 +1,104 @@
+package web
+
+import (
+	"context"
+	"discovery-service/models"
+	pb "discovery-service/proto"
+	_ "embed"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+//go:embed dashboard.html
+var dashboardHTML []byte
+
+// nodeView is one node's status as shown on the dashboard.
+type nodeView struct {
+	ID          string             `json:"id"`
+	Reachable   bool               `json:"reachable"`
+	Error       string             `json:"error,omitempty"`
+	Live        []string           `json:"live"`
+	Suspect     []string           `json:"suspect"`
+	Dead        []string           `json:"dead"`
+	Counter     int64              `json:"counter"`
+	MissedOps   map[string]int64   `json:"missed_ops"`
+	HeartbeatMs map[string]float64 `json:"heartbeat_ms"`
+	Partitioned bool               `json:"partitioned"`
+	Diverged    bool               `json:"diverged"`
+}
+
+func dashboardHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(dashboardHTML)
+}
+
+// clusterStatusHandler collects the status of this node and of every peer it
+// knows about, and flags nodes whose counter differs from the value most
+// nodes agree on.
+func clusterStatusHandler(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		local := s.Status()
+		peers := append(append([]string{}, local.LivePeers...), local.SuspectPeers...)
+		peers = append(peers, local.DeadPeers...)
+
+		views := make([]nodeView, len(peers)+1)
+		views[0] = toView(local)
+		var wg sync.WaitGroup
+		for i, peer := range peers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				views[i+1] = peerView(r.Context(), s, peer)
+			}()
+		}
+		wg.Wait()
+
+		counts := map[int64]int{}
+		for _, v := range views {
+			if v.Reachable {
+				counts[v.Counter]++
+			}
+		}
+		var agreed int64
+		for value, n := range counts {
+			if n > counts[agreed] || (n == counts[agreed] && value > agreed) {
+				agreed = value
+			}
+		}
+		for i := range views {
+			views[i].Diverged = views[i].Reachable && views[i].Counter != agreed
+		}
+		sort.Slice(views[1:], func(i, j int) bool { return views[i+1].ID < views[j+1].ID })
+
+		writeJSON(w, map[string]interface{}{"nodes": views, "agreed_counter": agreed})
+	}
+}
+
+func peerView(ctx context.Context, s *models.Server, peer string) nodeView {
+	conn := s.GetOrCreateConnection(peer)
+	if conn == nil {
+		return nodeView{ID: peer, Error: "no connection"}
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.Config().HTTP.StatusTimeout)
+	defer cancel()
+	status, err := pb.NewDiscoveryClient(conn).GetStatus(ctx, &pb.Empty{})
+	if err != nil {
+		return nodeView{ID: peer, Error: err.Error()}
+	}
+	return toView(status)
+}
+
+func toView(status *pb.NodeStatus) nodeView {
+	return nodeView{
+		ID:          status.Id,
+		Reachable:   true,
+		Live:        status.LivePeers,
+		Suspect:     status.SuspectPeers,
+		Dead:        status.DeadPeers,
+		Counter:     status.Counter,
+		MissedOps:   status.MissedOps,
+		HeartbeatMs: status.HeartbeatMs,
+		Partitioned: status.Partitioned,
+	}
+}
@@ -0,0 +1,86 @@
+package web
+
+import (
+	"encoding/json"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"strings"
+)
+
+// apiError is the body of every error of the /v1 API.
+type apiError struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func newAPIError(status int, msg string) apiError {
+	code, ok := errorCodes[status]
+	if !ok {
+		code = "unknown"
+	}
+	return apiError{Error: errorDetail{Status: status, Code: code, Message: msg}}
+}
+
+// errorCodes are the machine readable codes of /v1 errors by HTTP status.
+var errorCodes = map[int]string{
+	http.StatusBadRequest:            "invalid_argument",
+	http.StatusUnauthorized:          "unauthenticated",
+	http.StatusForbidden:             "permission_denied",
+	http.StatusNotFound:              "not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusNotAcceptable:         "not_acceptable",
+	http.StatusConflict:              "conflict",
+	http.StatusRequestEntityTooLarge: "request_too_large",
+	http.StatusUnsupportedMediaType:  "unsupported_media_type",
+	http.StatusTooManyRequests:       "resource_exhausted",
+	http.StatusInternalServerError:   "internal",
+	http.StatusServiceUnavailable:    "unavailable",
+}
+
+// httpError replies with msg and code like http.Error, as a JSON error body
+// for the /v1 API and the gateway.
+func httpError(w http.ResponseWriter, r *http.Request, msg string, code int) {
+	if !strings.HasPrefix(r.URL.Path, "/v1/") && !strings.HasPrefix(r.URL.Path, "/rpc/") {
+		http.Error(w, msg, code)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(newAPIError(code, msg))
+}
+
+// rpcError replies with the HTTP equivalent of a gRPC status error.
+func rpcError(w http.ResponseWriter, r *http.Request, err error) {
+	code, msg := rpcStatus(err)
+	httpError(w, r, msg, code)
+}
+
+// rpcStatus maps a gRPC status error to an HTTP status and message.
+func rpcStatus(err error) (int, string) {
+	st := status.Convert(err)
+	code := http.StatusInternalServerError
+	switch st.Code() {
+	case codes.InvalidArgument, codes.OutOfRange:
+		code = http.StatusBadRequest
+	case codes.Unauthenticated:
+		code = http.StatusUnauthorized
+	case codes.PermissionDenied:
+		code = http.StatusForbidden
+	case codes.NotFound:
+		code = http.StatusNotFound
+	case codes.AlreadyExists, codes.FailedPrecondition, codes.Aborted:
+		code = http.StatusConflict
+	case codes.ResourceExhausted:
+		code = http.StatusTooManyRequests
+	case codes.Unavailable, codes.DeadlineExceeded:
+		code = http.StatusServiceUnavailable
+	}
+	return code, st.Message()
+}
@@ -0,0 +1,61 @@
+package web
+
+import (
+	"context"
+	"discovery-service/models"
+	pb "discovery-service/proto"
+	"discovery-service/security/throttle"
+	"errors"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"net/http"
+	"strings"
+)
+
+// gateway returns the HTTP/JSON transcoding of the CounterService, generated
+// from the http options in discovery.proto. It calls the node's own gRPC
+// server rather than the service directly, so requests go through the same
+// interceptors, authorization and streaming as gRPC clients.
+func gateway(s *models.Server, grpcPort string) (http.Handler, error) {
+	port := s.ClientPort
+	if port == "" {
+		port = strings.TrimPrefix(grpcPort, ":")
+	}
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithIncomingHeaderMatcher(gatewayHeader),
+		runtime.WithMetadata(throttle.GatewayMetadata),
+		runtime.WithErrorHandler(gatewayError),
+	)
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(s.TransportCredentials())}
+	err := pb.RegisterCounterServiceHandlerFromEndpoint(context.Background(), mux, "localhost:"+port, opts)
+	if err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
+
+// gatewayHeader forwards trace context and X-API-Key along with the headers
+// the gateway forwards by default, such as Authorization.
+func gatewayHeader(key string) (string, bool) {
+	switch k := strings.ToLower(key); k {
+	case "traceparent", "tracestate", "x-api-key":
+		return k, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// gatewayError replies with the JSON error body of the /v1 API.
+func gatewayError(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	var routing *runtime.HTTPStatusError
+	if errors.As(err, &routing) {
+		_, msg := rpcStatus(routing.Err)
+		httpError(w, r, msg, routing.HTTPStatus)
+		return
+	}
+	rpcError(w, r, err)
+}
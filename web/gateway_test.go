@@ -0,0 +1,147 @@
+package web_test
+
+import (
+	"bufio"
+	"discovery-service/counter/api"
+	"discovery-service/counter/window"
+	"discovery-service/models"
+	"discovery-service/proto"
+	"discovery-service/security/apikey"
+	"discovery-service/web"
+	"encoding/json"
+	"google.golang.org/grpc"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func gatewayCall(t *testing.T, method string, path string, key string, body string) (int, map[string]interface{}) {
+	t.Helper()
+	req, _ := http.NewRequest(method, "http://localhost:9114"+path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("%s %s returned no JSON: %q", method, path, data)
+	}
+	return resp.StatusCode, result
+}
+
+func TestGateway(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "keys.json")
+	os.WriteFile(file, []byte(`[
+		{"name": "dashboard", "key": "read-key", "permission": "read"},
+		{"name": "app", "key": "app-key", "permission": "increment"}
+	]`), 0o600)
+	store, err := apikey.Load(file)
+	if err != nil {
+		t.Fatalf("Failed to load keys: %v", err)
+	}
+
+	s := models.NewServer("localhost:8114")
+	s.APIKeys = store
+	s.Windowed = window.NewCounters(s, time.Minute)
+	lis, err := net.Listen("tcp", ":8114")
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	proto.RegisterCounterServiceServer(grpcServer, api.NewService(s))
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	web.StartHTTPServer(s, "8114")
+	time.Sleep(200 * time.Millisecond)
+
+	for i, want := range []bool{false, true} {
+		code, result := gatewayCall(t, "POST", "/rpc/v1/counter:increment", "app-key", `{"delta": 2, "id": "once"}`)
+		if code != http.StatusOK || result["duplicate"] != want {
+			t.Fatalf("Expected increment %d to succeed with duplicate %v, got %d %v", i, want, code, result)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	if code, result := gatewayCall(t, "GET", "/rpc/v1/counter", "read-key", ""); code != http.StatusOK || result["count"] != "2" {
+		t.Fatalf("Expected the counter to be 2, got %d %v", code, result)
+	}
+
+	code, result := gatewayCall(t, "POST", "/rpc/v1/counter:batchIncrement", "app-key",
+		`{"increments": [{"counter": "clicks", "delta": 3}, {"counter": "clicks", "delta": -1}]}`)
+	results, _ := result["results"].([]interface{})
+	if code != http.StatusOK || len(results) != 2 || results[0].(map[string]interface{})["count"] != "3" || results[1].(map[string]interface{})["code"] != 3.0 {
+		t.Fatalf("Unexpected batch result %d %v", code, result)
+	}
+	if code, result := gatewayCall(t, "GET", "/rpc/v1/counter?counter=clicks", "read-key", ""); code != http.StatusOK || result["count"] != "3" {
+		t.Fatalf("Expected clicks to be 3, got %d %v", code, result)
+	}
+
+	// X-API-Key works as well as a bearer token, as on the rest of the API
+	req, _ := http.NewRequest("GET", "http://localhost:9114/rpc/v1/counter", nil)
+	req.Header.Set("X-API-Key", "read-key")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected X-API-Key to be accepted, got %d", resp.StatusCode)
+	}
+
+	cases := []struct {
+		method string
+		path   string
+		key    string
+		body   string
+		status int
+		code   string
+	}{
+		{"POST", "/rpc/v1/counter:increment", "", `{}`, http.StatusUnauthorized, "unauthenticated"},
+		{"POST", "/rpc/v1/counter:increment", "read-key", `{}`, http.StatusForbidden, "permission_denied"},
+		{"POST", "/rpc/v1/counter:decrement", "app-key", `{"counter": "clicks"}`, http.StatusBadRequest, "invalid_argument"},
+		{"POST", "/rpc/v1/counter:increment", "app-key", `{"delta": "many"}`, http.StatusBadRequest, "invalid_argument"},
+		{"GET", "/rpc/v1/nothing", "read-key", "", http.StatusNotFound, "not_found"},
+	}
+	for _, c := range cases {
+		code, result := gatewayCall(t, c.method, c.path, c.key, c.body)
+		if code != c.status || errorCode(result) != c.code {
+			t.Errorf("%s %s: expected %d %s, got %d %v", c.method, c.path, c.status, c.code, code, result)
+		}
+	}
+
+	// Watch streams the changes as newline delimited JSON
+	req, _ = http.NewRequest("GET", "http://localhost:9114/rpc/v1/counter:watch", nil)
+	req.Header.Set("Authorization", "Bearer read-key")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer resp.Body.Close()
+	lines := bufio.NewScanner(resp.Body)
+	if !lines.Scan() {
+		t.Fatalf("Watch ended: %v", lines.Err())
+	}
+	var first struct {
+		Result map[string]interface{} `json:"result"`
+	}
+	if err := json.Unmarshal(lines.Bytes(), &first); err != nil || first.Result["count"] != "2" {
+		t.Fatalf("Expected a first value of 2, got %s", lines.Bytes())
+	}
+	gatewayCall(t, "POST", "/rpc/v1/counter:increment", "app-key", `{}`)
+	if !lines.Scan() {
+		t.Fatalf("Watch ended: %v", lines.Err())
+	}
+	if err := json.Unmarshal(lines.Bytes(), &first); err != nil || first.Result["count"] != "3" {
+		t.Fatalf("Expected the increment to be streamed, got %s", lines.Bytes())
+	}
+}
@@ -0,0 +1,29 @@
+package web
+
+import (
+	"discovery-service/models"
+	"net/http"
+)
+
+// healthHandler reports the result of checks as JSON, with 503 if any
+// failed. Health endpoints need no API key so load balancers can probe them.
+func healthHandler(checks func() map[string]error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		results := checks()
+		report := map[string]string{}
+		for name, err := range results {
+			report[name] = "ok"
+			if err != nil {
+				report[name] = err.Error()
+			}
+		}
+
+		status := "ok"
+		if !models.Healthy(results) {
+			status = "fail"
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		writeJSON(w, map[string]interface{}{"status": status, "checks": report})
+	}
+}
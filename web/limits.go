@@ -0,0 +1,39 @@
+package web
+
+import (
+	"discovery-service/models"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// limitRequests rejects requests from clients over their rate limit with 429
+// and bodies larger than s.MaxRequestBytes with 413. Clients are told apart
+// by API key when one is sent, by IP address otherwise.
+func limitRequests(s *models.Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, retry := s.HTTPLimit.Allow(clientID(s, r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+			httpError(w, r, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		if s.MaxRequestBytes > 0 {
+			if r.ContentLength > s.MaxRequestBytes {
+				httpError(w, r, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, s.MaxRequestBytes)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func clientID(s *models.Server, r *http.Request) string {
+	if s.APIKeys != nil {
+		if key, err := s.APIKeys.Lookup(requestKey(r)); err == nil {
+			return "key:" + key.Name
+		}
+	}
+	return actor(r)
+}
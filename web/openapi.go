@@ -0,0 +1,146 @@
+package web
+
+import "strings"
+
+type object = map[string]interface{}
+
+func ref(schema string) object {
+	return object{"$ref": "#/components/schemas/" + schema}
+}
+
+func properties(required []string, props object) object {
+	schema := object{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+var (
+	integer = object{"type": "integer", "format": "int64"}
+	str     = object{"type": "string"}
+	boolean = object{"type": "boolean"}
+	number  = object{"type": "number"}
+)
+
+// schemas describe the JSON bodies of the /v1 API.
+var schemas = object{
+	"Error": properties([]string{"error"}, object{
+		"error": ref("ErrorDetail"),
+	}),
+	"ErrorDetail": properties([]string{"status", "code", "message"}, object{
+		"status":  object{"type": "integer", "description": "HTTP status"},
+		"code":    object{"type": "string", "description": "Machine readable error code, e.g. not_found"},
+		"message": str,
+	}),
+	"CounterValue": properties([]string{"counter", "count", "stale"}, object{
+		"counter": object{"type": "string", "description": "Empty for the default counter"},
+		"count":   integer,
+		"limit":   object{"type": "integer", "format": "int64", "description": "Limit of a bounded counter"},
+		"stale":   object{"type": "boolean", "description": "Read while the node is partitioned from the majority"},
+	}),
+	"IncrementRequest": properties(nil, object{
+		"delta": object{"type": "integer", "format": "int64", "minimum": 1, "default": 1},
+		"id":    object{"type": "string", "maxLength": maxIdempotencyKey, "description": "Op ID of a default counter increment, retries with the same id are applied once"},
+	}),
+	"IncrementResponse": properties([]string{"count", "duplicate"}, object{
+		"count":     integer,
+		"duplicate": object{"type": "boolean", "description": "Every op of the id had been applied before"},
+	}),
+	"BatchIncrementRequest": properties([]string{"increments"}, object{
+		"increments": object{"type": "array", "items": properties(nil, object{
+			"counter": object{"type": "string", "description": "Empty for the default counter"},
+			"delta":   object{"type": "integer", "format": "int64", "minimum": 1, "default": 1},
+			"id":      str,
+		})},
+	}),
+	"BatchIncrementResponse": properties([]string{"results"}, object{
+		"results": object{"type": "array", "items": properties(nil, object{
+			"count":     integer,
+			"duplicate": boolean,
+			"error":     ref("ErrorDetail"),
+		})},
+	}),
+	"Rate": properties([]string{"counter", "window", "count", "rate"}, object{
+		"counter": str,
+		"window":  str,
+		"count":   integer,
+		"rate":    object{"type": "number", "description": "Increments per second over the window"},
+	}),
+	"AddItemRequest": properties([]string{"item"}, object{
+		"item": str,
+	}),
+	"Cardinality": properties([]string{"counter", "cardinality", "std_error"}, object{
+		"counter":     str,
+		"cardinality": integer,
+		"std_error":   number,
+	}),
+	"Peers": properties([]string{"id", "peers", "dead_peers", "partitioned"}, object{
+		"id":          str,
+		"peers":       object{"type": "array", "items": str},
+		"dead_peers":  object{"type": "array", "items": str},
+		"partitioned": boolean,
+	}),
+	"WatchToken": properties([]string{"token", "expires_at"}, object{
+		"token":      object{"type": "string", "description": "Sent as ?access_token= to the watch endpoints, also set as the watch_token cookie"},
+		"expires_at": object{"type": "string", "format": "date-time"},
+	}),
+}
+
+// openAPI builds the OpenAPI 3 document of routes.
+func openAPI(routes []route) object {
+	paths := object{}
+	for _, rt := range routes {
+		var params []object
+		for _, segment := range strings.Split(rt.pattern, "/") {
+			if name, ok := strings.CutPrefix(segment, "{"); ok {
+				params = append(params, object{"name": strings.TrimSuffix(name, "}"), "in": "path", "required": true, "schema": str})
+			}
+		}
+		for _, p := range rt.query {
+			params = append(params, object{"name": p.name, "in": "query", "description": p.description, "schema": object{"type": p.kind}})
+		}
+		for _, p := range rt.headers {
+			params = append(params, object{"name": p.name, "in": "header", "description": p.description, "schema": object{"type": p.kind}})
+		}
+
+		op := object{
+			"operationId": rt.id,
+			"summary":     rt.summary,
+			"description": "Requires the " + rt.perm.String() + " permission when API keys are configured.",
+			"security":    []object{{"bearer": []string{}}},
+			"responses": object{
+				"200":     object{"description": "OK", "content": object{"application/json": object{"schema": ref(rt.response)}}},
+				"default": object{"description": "Error", "content": object{"application/json": object{"schema": ref("Error")}}},
+			},
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		if rt.request != "" {
+			op["requestBody"] = object{"content": object{"application/json": object{"schema": ref(rt.request)}}}
+		}
+
+		item, ok := paths[rt.pattern].(object)
+		if !ok {
+			item = object{}
+			paths[rt.pattern] = item
+		}
+		item[strings.ToLower(rt.method)] = op
+	}
+
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":   "Distributed counter API",
+			"version": "1",
+		},
+		"paths": paths,
+		"components": object{
+			"schemas": schemas,
+			"securitySchemes": object{
+				"bearer": object{"type": "http", "scheme": "bearer", "description": "API key, also accepted in an X-API-Key header"},
+			},
+		},
+	}
+}
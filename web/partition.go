@@ -0,0 +1,31 @@
+package web
+
+import (
+	"discovery-service/models"
+	"net/http"
+)
+
+// minorityWrite wraps a write handler so it is refused with 503 while the
+// node is partitioned from the majority, if the node is configured to go
+// read-only in a minority.
+func minorityWrite(s *models.Server, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.ReadOnlyInMinority && s.IsPartitioned() {
+			w.Header().Set("Retry-After", "5")
+			httpError(w, r, "node is partitioned from the majority of the cluster", http.StatusServiceUnavailable)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// minorityRead wraps a read handler so answers given while the node is
+// partitioned carry a Warning header, as they may be stale.
+func minorityRead(s *models.Server, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.IsPartitioned() {
+			w.Header().Set("Warning", `110 - "Response is Stale: node is partitioned from the majority of the cluster"`)
+		}
+		h(w, r)
+	}
+}
@@ -0,0 +1,69 @@
+package web
+
+import (
+	"discovery-service/counter/ratelimit"
+	"discovery-service/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// rateLimitHandler checks and counts a request against a rate limit, e.g.
+// POST /ratelimit/check?key=tenant-1&limit=100&window=60s. The result is also
+// reported in RateLimit-* headers so gateways can pass them through.
+func rateLimitHandler(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		limit, err := strconv.ParseInt(q.Get("limit"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		window, err := time.ParseDuration(q.Get("window"))
+		if err != nil {
+			http.Error(w, "invalid window", http.StatusBadRequest)
+			return
+		}
+		cost := int64(1)
+		if c := q.Get("cost"); c != "" {
+			if cost, err = strconv.ParseInt(c, 10, 64); err != nil {
+				http.Error(w, "invalid cost", http.StatusBadRequest)
+				return
+			}
+		}
+
+		res, err := ratelimit.Check(s.Windowed, q.Get("key"), limit, window, cost)
+		if errors.Is(err, ratelimit.ErrDisabled) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.FormatInt(res.Limit, 10))
+		h.Set("RateLimit-Remaining", strconv.FormatInt(res.Remaining, 10))
+		h.Set("RateLimit-Reset", strconv.FormatInt(ratelimit.Seconds(res.Reset), 10))
+		h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", res.Limit, ratelimit.Seconds(window)))
+		status := http.StatusOK
+		if !res.Allowed {
+			h.Set("Retry-After", strconv.FormatInt(ratelimit.Seconds(res.RetryAfter), 10))
+			status = http.StatusTooManyRequests
+		}
+
+		h.Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		writeJSON(w, map[string]interface{}{
+			"allowed":             res.Allowed,
+			"limit":               res.Limit,
+			"remaining":           res.Remaining,
+			"reset_seconds":       ratelimit.Seconds(res.Reset),
+			"retry_after_seconds": ratelimit.Seconds(res.RetryAfter),
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package web
+
+import (
+	"discovery-service/counter/api"
+	"discovery-service/models"
+	pb "discovery-service/proto"
+	"discovery-service/security/apikey"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// route is one operation of the /v1 API. The routes are both served and
+// described in the OpenAPI document, so the two cannot drift apart.
+type route struct {
+	method   string
+	pattern  string
+	id       string // OpenAPI operationId
+	summary  string
+	perm     apikey.Permission
+	scope    func(*http.Request) string
+	write    bool    // Refused in a minority partition if configured
+	query    []param // Query parameters
+	request  string  // Schema of the JSON body, none if ""
+	response string  // Schema of the JSON response
+	headers  []param // Request headers
+	handler  http.HandlerFunc
+}
+
+type param struct {
+	name        string
+	kind        string // JSON schema type
+	description string
+}
+
+func v1Routes(s *models.Server) []route {
+	return []route{
+		{method: "GET", pattern: "/v1/counter", id: "getDefaultCounter", summary: "Read the default counter",
+			perm: apikey.Read, response: "CounterValue", handler: v1Get(s)},
+		{method: "POST", pattern: "/v1/counter/increment", id: "incrementDefaultCounter", summary: "Increment the default counter",
+			perm: apikey.Increment, write: true, request: "IncrementRequest", response: "IncrementResponse",
+			headers: []param{{"Idempotency-Key", "string", "Op ID of the increment, retries with the same key are applied once"}},
+			handler: v1Increment(s, 1)},
+		{method: "GET", pattern: "/v1/counters/{name}", id: "getCounter", summary: "Read a named counter",
+			perm: apikey.Read, scope: pathCounter, response: "CounterValue", handler: v1Get(s)},
+		{method: "POST", pattern: "/v1/counters/{name}/increment", id: "incrementCounter", summary: "Increment a named counter",
+			perm: apikey.Increment, scope: pathCounter, write: true, request: "IncrementRequest", response: "IncrementResponse",
+			handler: v1Increment(s, 1)},
+		{method: "POST", pattern: "/v1/counters/{name}/decrement", id: "decrementCounter", summary: "Decrement a strong counter",
+			perm: apikey.Increment, scope: pathCounter, write: true, request: "IncrementRequest", response: "IncrementResponse",
+			handler: v1Increment(s, -1)},
+		{method: "POST", pattern: "/v1/batch/increment", id: "batchIncrement", summary: "Apply several increments, each on its own",
+			perm: apikey.Increment, write: true, request: "BatchIncrementRequest", response: "BatchIncrementResponse",
+			handler: v1BatchIncrement(s)},
+		{method: "GET", pattern: "/v1/counters/{name}/rate", id: "getCounterRate", summary: "Rate of a windowed counter",
+			perm: apikey.Read, scope: pathCounter, response: "Rate",
+			query:   []param{{"window", "string", "Window as a Go duration, 1m by default"}},
+			handler: v1Rate(s)},
+		{method: "POST", pattern: "/v1/counters/{name}/items", id: "addItem", summary: "Add an item to a distinct counter",
+			perm: apikey.Increment, scope: pathCounter, write: true, request: "AddItemRequest", response: "Cardinality",
+			handler: v1AddItem(s)},
+		{method: "GET", pattern: "/v1/counters/{name}/cardinality", id: "getCardinality", summary: "Estimated distinct items of a distinct counter",
+			perm: apikey.Read, scope: pathCounter, response: "Cardinality", handler: v1Cardinality(s)},
+		{method: "GET", pattern: "/v1/peers", id: "listPeers", summary: "Members this node knows about",
+			perm: apikey.Read, response: "Peers", handler: v1Peers(s)},
+		{method: "POST", pattern: "/v1/watch-token", id: "createWatchToken", summary: "Short-lived token to watch counters without headers",
+			perm: apikey.Read, scope: queryCounter, response: "WatchToken",
+			query:   []param{{"counter", "string", "Counter to watch, the default counter if empty; its key is checked again when watching"}},
+			handler: v1WatchToken(s)},
+	}
+}
+
+// registerV1 adds the /v1 API to mux. Every path answers other methods
+// with 405 and unknown paths with 404, in the API's error format.
+func registerV1(s *models.Server, mux *http.ServeMux) {
+	routes := v1Routes(s)
+	allowed := map[string][]string{}
+	for _, rt := range routes {
+		h := rt.handler
+		if rt.write {
+			h = minorityWrite(s, h)
+		} else {
+			h = minorityRead(s, h)
+		}
+		if rt.scope != nil {
+			h = publicCounter(h)
+		}
+		mux.HandleFunc(rt.method+" "+rt.pattern, negotiate(authorize(s, rt.perm, rt.scope, h)))
+		allowed[rt.pattern] = append(allowed[rt.pattern], rt.method)
+	}
+	for pattern, methods := range allowed {
+		allow := strings.Join(methods, ", ")
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Allow", allow)
+			httpError(w, r, "method not allowed, expected "+allow, http.StatusMethodNotAllowed)
+		})
+	}
+
+	spec, _ := json.Marshal(openAPI(routes))
+	mux.HandleFunc("GET /v1/openapi.json", negotiate(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	}))
+	mux.HandleFunc("/v1/", func(w http.ResponseWriter, r *http.Request) {
+		httpError(w, r, "no such endpoint "+r.URL.Path, http.StatusNotFound)
+	})
+}
+
+// negotiate refuses requests that do not accept JSON, the only
+// representation of the API.
+func negotiate(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !acceptsJSON(r.Header.Get("Accept")) {
+			httpError(w, r, "only application/json is available", http.StatusNotAcceptable)
+			return
+		}
+		h(w, r)
+	}
+}
+
+func acceptsJSON(accept string) bool {
+	if accept == "" {
+		return true
+	}
+	for _, item := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil || params["q"] == "0" || params["q"] == "0.0" {
+			continue
+		}
+		if mediaType == "*/*" || mediaType == "application/*" || mediaType == "application/json" {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeBody reads the JSON body of r into v. An empty body leaves v as is.
+// On failure it replies with the error and returns false.
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.ContentLength == 0 && r.Header.Get("Transfer-Encoding") == "" {
+		return true
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		httpError(w, r, "request body must be application/json", http.StatusUnsupportedMediaType)
+		return false
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			httpError(w, r, "request body too large", http.StatusRequestEntityTooLarge)
+			return false
+		}
+		httpError(w, r, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+type incrementRequest struct {
+	Delta   int64  `json:"delta"`
+	ID      string `json:"id"`
+	Counter string `json:"counter,omitempty"` // Only in batches
+}
+
+func counterValue(v *pb.CounterValue) map[string]interface{} {
+	value := map[string]interface{}{"counter": v.Counter, "count": v.Count, "stale": v.Stale}
+	if v.Limit > 0 {
+		value["limit"] = v.Limit
+	}
+	return value
+}
+
+func v1Get(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		value, err := api.Get(r.Context(), s, r.PathValue("name"))
+		if err != nil {
+			rpcError(w, r, err)
+			return
+		}
+		writeJSON(w, counterValue(value))
+	}
+}
+
+func v1Increment(s *models.Server, sign int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := incrementRequest{Delta: 1}
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		if key := r.Header.Get("Idempotency-Key"); key != "" {
+			req.ID = key
+		}
+		if req.Delta <= 0 {
+			httpError(w, r, "delta must be positive", http.StatusBadRequest)
+			return
+		}
+		if len(req.ID) > maxIdempotencyKey {
+			httpError(w, r, fmt.Sprintf("id is longer than %d bytes", maxIdempotencyKey), http.StatusBadRequest)
+			return
+		}
+
+		count, duplicate, err := api.Increment(r.Context(), s, actor(r), r.PathValue("name"), sign*req.Delta, req.ID)
+		if err != nil {
+			rpcError(w, r, err)
+			return
+		}
+		if duplicate {
+			w.Header().Set("Idempotent-Replayed", "true")
+		}
+		writeJSON(w, map[string]interface{}{"count": count, "duplicate": duplicate})
+	}
+}
+
+func v1BatchIncrement(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Increments []incrementRequest `json:"increments"`
+		}
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		incs := make([]*pb.CounterIncrementRequest, len(req.Increments))
+		for i, inc := range req.Increments {
+			incs[i] = &pb.CounterIncrementRequest{Counter: inc.Counter, Delta: inc.Delta, Id: inc.ID}
+		}
+		key, _ := apikey.FromContext(r.Context())
+		batch, err := api.Batch(r.Context(), s, actor(r), incs, func(counter string) error {
+			if key != nil && !key.Allows(apikey.Increment, counter) {
+				return status.Error(codes.PermissionDenied, apikey.ErrForbidden.Error())
+			}
+			return nil
+		})
+		if err != nil {
+			rpcError(w, r, err)
+			return
+		}
+		results := make([]map[string]interface{}, len(batch))
+		for i, result := range batch {
+			if result.Code != int32(codes.OK) {
+				results[i] = map[string]interface{}{"error": newAPIError(rpcStatus(status.Error(codes.Code(result.Code), result.Error))).Error}
+				continue
+			}
+			results[i] = map[string]interface{}{"count": result.Count, "duplicate": result.Duplicate}
+		}
+		writeJSON(w, map[string]interface{}{"results": results})
+	}
+}
+
+func v1Rate(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.Windowed == nil {
+			httpError(w, r, "windowed counters are disabled", http.StatusNotFound)
+			return
+		}
+		window := min(time.Minute, s.Windowed.Retention())
+		if v := r.URL.Query().Get("window"); v != "" {
+			var err error
+			if window, err = time.ParseDuration(v); err != nil {
+				httpError(w, r, "invalid window", http.StatusBadRequest)
+				return
+			}
+		}
+		count, err := s.Windowed.Count(r.PathValue("name"), window)
+		if err != nil {
+			httpError(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"counter": r.PathValue("name"),
+			"window":  window.String(),
+			"count":   count,
+			"rate":    float64(count) / window.Seconds(),
+		})
+	}
+}
+
+func v1AddItem(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.Distinct == nil {
+			httpError(w, r, "distinct counters are disabled", http.StatusNotFound)
+			return
+		}
+		var req struct {
+			Item string `json:"item"`
+		}
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		if req.Item == "" {
+			httpError(w, r, "item is required", http.StatusBadRequest)
+			return
+		}
+		name := r.PathValue("name")
+		s.Distinct.Add(name, req.Item)
+		estimate, stdError, _ := s.Distinct.Cardinality(name)
+		writeJSON(w, map[string]interface{}{"counter": name, "cardinality": estimate, "std_error": stdError})
+	}
+}
+
+func v1Cardinality(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.Distinct == nil {
+			httpError(w, r, "distinct counters are disabled", http.StatusNotFound)
+			return
+		}
+		name := r.PathValue("name")
+		estimate, stdError, ok := s.Distinct.Cardinality(name)
+		if !ok {
+			httpError(w, r, "unknown counter "+name, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"counter": name, "cardinality": estimate, "std_error": stdError})
+	}
+}
+
+func v1Peers(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.Mu.Lock()
+		peers := slices.Clone(s.Peers)
+		dead := slices.Clone(s.DeadPeers)
+		partitioned := s.Partitioned
+		s.Mu.Unlock()
+		writeJSON(w, map[string]interface{}{"id": s.Id, "peers": peers, "dead_peers": dead, "partitioned": partitioned})
+	}
+}
+
+// v1WatchToken issues a token standing in for the caller's key on the
+// watch endpoints, returned and set as a cookie, for browsers that cannot
+// send the key in a header.
+func v1WatchToken(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, ok := apikey.FromContext(r.Context())
+		if !ok {
+			httpError(w, r, "API keys are not enabled, watching needs no token", http.StatusNotFound)
+			return
+		}
+		ttl := s.Config().Watch.TokenTTL
+		token, expires := s.APIKeys.IssueToken(key, ttl)
+		http.SetCookie(w, &http.Cookie{
+			Name:     watchTokenCookie,
+			Value:    token,
+			Path:     "/",
+			MaxAge:   int(ttl.Seconds()),
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteStrictMode,
+		})
+		writeJSON(w, map[string]interface{}{"token": token, "expires_at": expires.UTC().Format(time.RFC3339)})
+	}
+}
@@ -0,0 +1,108 @@
+package web_test
+
+import (
+	"discovery-service/counter/window"
+	"discovery-service/models"
+	"discovery-service/web"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func call(t *testing.T, method string, path string, headers map[string]string, body string) (*http.Response, map[string]interface{}) {
+	t.Helper()
+	req, _ := http.NewRequest(method, "http://localhost:9113"+path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("%s %s returned no JSON: %q", method, path, data)
+	}
+	return resp, result
+}
+
+func errorCode(result map[string]interface{}) string {
+	detail, _ := result["error"].(map[string]interface{})
+	code, _ := detail["code"].(string)
+	return code
+}
+
+func TestV1API(t *testing.T) {
+	s := models.NewServer("localhost:8113")
+	s.Windowed = window.NewCounters(s, time.Minute)
+	web.StartHTTPServer(s, "8113")
+	time.Sleep(200 * time.Millisecond)
+
+	for i, want := range []bool{false, true} {
+		resp, result := call(t, "POST", "/v1/counter/increment", map[string]string{"Idempotency-Key": "once"}, `{"delta": 2}`)
+		if resp.StatusCode != http.StatusOK || result["duplicate"] != want {
+			t.Fatalf("Expected increment %d to succeed with duplicate %v, got %d %v", i, want, resp.StatusCode, result)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, result := call(t, "GET", "/v1/counter", nil, ""); result["count"] != 2.0 {
+		t.Fatalf("Expected the counter to be 2, got %v", result)
+	}
+
+	resp, result := call(t, "POST", "/v1/batch/increment", nil, `{"increments": [{"counter": "clicks", "delta": 3}, {"counter": "clicks", "delta": -1}]}`)
+	results, _ := result["results"].([]interface{})
+	if resp.StatusCode != http.StatusOK || len(results) != 2 || results[0].(map[string]interface{})["count"] != 3.0 || errorCode(results[1].(map[string]interface{})) != "invalid_argument" {
+		t.Fatalf("Unexpected batch result %d %v", resp.StatusCode, result)
+	}
+
+	tooMany := `{"increments": [` + strings.Repeat(`{"counter": "clicks"},`, 1000) + `{"counter": "clicks"}]}`
+	cases := []struct {
+		method  string
+		path    string
+		headers map[string]string
+		body    string
+		status  int
+		code    string
+	}{
+		{"GET", "/v1/counter/increment", nil, "", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"POST", "/v1/counters/clicks/decrement", nil, "", http.StatusBadRequest, "invalid_argument"},
+		{"POST", "/v1/counters/clicks/increment", nil, `{"delta": "many"}`, http.StatusBadRequest, "invalid_argument"},
+		{"POST", "/v1/counters/clicks/increment", map[string]string{"Content-Type": "text/plain"}, "1", http.StatusUnsupportedMediaType, "unsupported_media_type"},
+		{"GET", "/v1/counter", map[string]string{"Accept": "text/html"}, "", http.StatusNotAcceptable, "not_acceptable"},
+		{"GET", "/v1/nothing", nil, "", http.StatusNotFound, "not_found"},
+		{"POST", "/v1/counters/ratelimit:alice/increment", nil, "", http.StatusBadRequest, "invalid_argument"},
+		{"GET", "/v1/counters/ratelimit:alice/rate", nil, "", http.StatusBadRequest, "invalid_argument"},
+		{"POST", "/v1/batch/increment", nil, tooMany, http.StatusBadRequest, "invalid_argument"},
+		{"POST", "/v1/batch/increment", nil, `{"increments": [{"delta": 6000}, {"delta": 6000}]}`, http.StatusBadRequest, "invalid_argument"},
+	}
+	for _, c := range cases {
+		resp, result := call(t, c.method, c.path, c.headers, c.body)
+		if resp.StatusCode != c.status || errorCode(result) != c.code {
+			t.Fatalf("%s %s: expected %d %s, got %d %v", c.method, c.path, c.status, c.code, resp.StatusCode, result)
+		}
+	}
+
+	// The legacy increment endpoint only takes POST
+	resp, err := http.Get("http://localhost:9113/increment")
+	if err != nil {
+		t.Fatalf("GET /increment failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("Expected GET /increment to be refused, got %d", resp.StatusCode)
+	}
+
+	_, spec := call(t, "GET", "/v1/openapi.json", nil, "")
+	paths, _ := spec["paths"].(map[string]interface{})
+	if spec["openapi"] != "3.0.3" || paths["/v1/counters/{name}/increment"] == nil || paths["/v1/batch/increment"] == nil {
+		t.Fatalf("Expected the OpenAPI document to describe the API, got %v", spec)
+	}
+}
@@ -0,0 +1,117 @@
+package web
+
+import (
+	"context"
+	"discovery-service/models"
+	pb "discovery-service/proto"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/net/websocket"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// watchEvent is a counter value pushed to HTTP watchers.
+type watchEvent struct {
+	Counter string `json:"counter"`
+	Count   int64  `json:"count"`
+	Limit   int64  `json:"limit,omitempty"`
+	Stale   bool   `json:"stale"`
+	Version uint64 `json:"version"`
+	Epoch   string `json:"epoch"`
+}
+
+func toEvent(v *pb.CounterValue) watchEvent {
+	return watchEvent{Counter: v.Counter, Count: v.Count, Limit: v.Limit, Stale: v.Stale, Version: v.Version, Epoch: v.Epoch}
+}
+
+// subscribe starts watching the counter of r, resuming after the epoch and
+// version given by resume ("epoch:version") if any. ?max_rate= lowers the
+// number of updates per second.
+func subscribe(s *models.Server, ctx context.Context, r *http.Request, resume string) (<-chan *pb.CounterValue, int, error) {
+	if s.Watcher == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("watching counters is disabled")
+	}
+	var maxRate float64
+	if v := r.URL.Query().Get("max_rate"); v != "" {
+		var err error
+		if maxRate, err = strconv.ParseFloat(v, 64); err != nil || maxRate <= 0 {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid max_rate")
+		}
+	}
+	var epoch string
+	var since uint64
+	if resume != "" {
+		e, v, _ := strings.Cut(resume, ":")
+		if n, err := strconv.ParseUint(v, 10, 64); err == nil {
+			epoch, since = e, n
+		}
+	}
+	values, err := s.Watcher.Subscribe(ctx, r.PathValue("name"), epoch, since, maxRate)
+	if err != nil {
+		return nil, http.StatusNotFound, err
+	}
+	return values, http.StatusOK, nil
+}
+
+// eventsHandler streams the changes of a counter as Server-Sent Events. The
+// id of every event is "epoch:version", which browsers send back as
+// Last-Event-ID when they reconnect so the stream resumes where it was.
+func eventsHandler(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resume := r.Header.Get("Last-Event-ID")
+		if resume == "" {
+			resume = r.URL.Query().Get("last_event_id")
+		}
+		values, code, err := subscribe(s, r.Context(), r, resume)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+
+		rc := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		rc.Flush()
+		for value := range values {
+			data, _ := json.Marshal(toEvent(value))
+			if _, err := fmt.Fprintf(w, "id: %s:%d\nevent: counter\ndata: %s\n\n", value.Epoch, value.Version, data); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// websocketHandler streams the changes of a counter as JSON messages over a
+// WebSocket. Clients resume with ?last_event_id=epoch:version.
+func websocketHandler(s *models.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		values, code, err := subscribe(s, ctx, r, r.URL.Query().Get("last_event_id"))
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+
+		websocket.Server{Handler: func(ws *websocket.Conn) {
+			// Clients only send to close, reading notices it.
+			go func() {
+				var discard []byte
+				for websocket.Message.Receive(ws, &discard) == nil {
+				}
+				cancel()
+			}()
+			for value := range values {
+				if err := websocket.JSON.Send(ws, toEvent(value)); err != nil {
+					return
+				}
+			}
+		}}.ServeHTTP(w, r)
+	}
+}
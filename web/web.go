@@ -2,74 +2,134 @@ package web
 
 import (
 	"discovery-service/counter/increment"
+	"discovery-service/lib/tracing"
 	"discovery-service/models"
+	"discovery-service/security/apikey"
 	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
-	"log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"net/http"
-	"strconv"
-	"strings"
+	"os"
 )
 
+// maxIdempotencyKey is the longest Idempotency-Key accepted on /increment.
+const maxIdempotencyKey = 255
+
 func StartHTTPServer(s *models.Server, grpcPort string) http.Handler {
-	httpPort := ComputeHTTPPort(grpcPort)
+	httpPort, err := s.Config().HTTPPort(grpcPort)
+	if err != nil {
+		s.Logger("web").Error("Invalid gRPC port", "port", grpcPort)
+		os.Exit(1)
+	}
 	mux := http.NewServeMux()
-	mux.HandleFunc("/peers", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/peers", authorize(s, apikey.Read, nil, func(w http.ResponseWriter, r *http.Request) {
 		s.Mu.Lock()
 		defer s.Mu.Unlock()
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string][]string{
-			"peers": s.Peers,
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"peers":       s.Peers,
+			"partitioned": s.Partitioned,
 		})
-	})
+	}))
 
-	mux.HandleFunc("/increment", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("POST /increment", authorize(s, apikey.Increment, nil, minorityWrite(s, func(w http.ResponseWriter, r *http.Request) {
+		// A client retrying with the same Idempotency-Key sends the same op,
+		// which is only applied once.
+		key := r.Header.Get("Idempotency-Key")
+		if len(key) > maxIdempotencyKey {
+			http.Error(w, fmt.Sprintf("Idempotency-Key is longer than %d bytes", maxIdempotencyKey), http.StatusBadRequest)
+			return
+		}
 		opID := uuid.New().String()
+		if key != "" {
+			opID = models.ClientOpID(actor(r), "", key, 0)
+		}
+		ctx, span := tracing.Start(r.Context(), "increment", trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("op.id", opID)))
+		defer span.End()
+
+		if !s.ClaimOp(opID) {
+			s.Metrics.IncrementsDeduplicated.Inc()
+			span.SetAttributes(attribute.Bool("op.duplicate", true))
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("Counter already incremented"))
+			return
+		}
 
 		//s.Mu.Lock()
 		//s.Counter++
 		//s.SeenOps[opID] = true
 		//s.Mu.Unlock()
-		s.IncrementChan <- opID
-		increment.PropagateIncrement(s, opID)
+		s.QueueIncrement(ctx, opID)
+		increment.PropagateIncrement(ctx, s, opID)
 
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("Counter incremented"))
-	})
+	})))
 
-	mux.HandleFunc("/count", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/count", authorize(s, apikey.Read, nil, minorityRead(s, func(w http.ResponseWriter, r *http.Request) {
 		count := s.Counter
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]int64{
 			"count": count,
 		})
-	})
+	})))
 
-	go func() {
-		for opId := range s.IncrementChan {
-			s.SeenOps[opId] = true
-			s.Counter++
-		}
-	}()
+	mux.HandleFunc("GET /count/events", authorizeWatch(s, nil, eventsHandler(s)))
+	mux.HandleFunc("GET /count/ws", authorizeWatch(s, nil, websocketHandler(s)))
+	mux.HandleFunc("GET /counters/{name}/events", authorizeWatch(s, pathCounter, publicCounter(eventsHandler(s))))
+	mux.HandleFunc("GET /counters/{name}/ws", authorizeWatch(s, pathCounter, publicCounter(websocketHandler(s))))
+	mux.HandleFunc("POST /counters/{name}/increment", authorize(s, apikey.Increment, pathCounter, publicCounter(minorityWrite(s, counterIncrementHandler(s)))))
+	mux.HandleFunc("/counters/{name}", authorize(s, apikey.Read, pathCounter, publicCounter(minorityRead(s, counterHandler(s)))))
+	mux.HandleFunc("/counters/{name}/rate", authorize(s, apikey.Read, pathCounter, publicCounter(minorityRead(s, counterRateHandler(s)))))
+	mux.HandleFunc("POST /counters/{name}/add", authorize(s, apikey.Increment, pathCounter, publicCounter(minorityWrite(s, counterAddHandler(s)))))
+	mux.HandleFunc("/counters/{name}/cardinality", authorize(s, apikey.Read, pathCounter, publicCounter(minorityRead(s, counterCardinalityHandler(s)))))
+	mux.HandleFunc("POST /ratelimit/check", authorize(s, apikey.Increment, rateLimitKey, minorityWrite(s, rateLimitHandler(s))))
+	mux.HandleFunc("GET /admin/audit", authorize(s, apikey.Admin, nil, auditHandler(s)))
+	mux.HandleFunc("DELETE /admin/peers/{id}", authorize(s, apikey.Admin, nil, removePeerHandler(s)))
+	mux.HandleFunc("/healthz", healthHandler(s.Liveness))
+	mux.HandleFunc("/readyz", healthHandler(s.Readiness))
+	mux.HandleFunc("/metrics", authorize(s, apikey.Read, nil, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		s.Metrics.Registry.WriteText(w)
+	}))
+	mux.HandleFunc("GET /dashboard", dashboardHandler)
+	mux.HandleFunc("GET /cluster/status", authorize(s, apikey.Read, nil, clusterStatusHandler(s)))
+	mux.HandleFunc("/admin/loglevel", authorize(s, apikey.Admin, nil, logLevelHandler(s)))
+	mux.HandleFunc("POST /admin/reload", authorize(s, apikey.Admin, nil, reloadHandler(s)))
+	registerV1(s, mux)
+	if gw, err := gateway(s, grpcPort); err != nil {
+		s.Logger("web").Error("Failed to start the HTTP/JSON gateway", "err", err)
+	} else {
+		mux.Handle("/rpc/", gw)
+	}
 
+	go s.ApplyIncrements()
+
+	handler := limitRequests(s, mux)
+	logger := s.Logger("web")
 	go func() {
-		log.Printf("HTTP server listening on %s", httpPort)
-		if err := http.ListenAndServe(httpPort, mux); err != nil {
-			log.Fatalf("HTTP server failed: %v", err)
+		logger.Info("HTTP server listening", "addr", httpPort)
+		// Like the client port, HTTPS does not ask for a client certificate;
+		// callers authenticate with API keys.
+		if s.TLS != nil {
+			srv := &http.Server{Addr: httpPort, Handler: handler, TLSConfig: s.TLS.ClientAPIConfig()}
+			if err := srv.ListenAndServeTLS("", ""); err != nil {
+				logger.Error("HTTP server failed", "err", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := http.ListenAndServe(httpPort, handler); err != nil {
+			logger.Error("HTTP server failed", "err", err)
+			os.Exit(1)
 		}
 	}()
 
-	return mux
-}
-
-func ComputeHTTPPort(grpcPort string) string {
-	p := strings.TrimPrefix(grpcPort, ":")
-	portNum, err := strconv.Atoi(p)
-	if err != nil {
-		log.Fatalf("Invalid gRPC port: %s", grpcPort)
-	}
-	return fmt.Sprintf(":%d", portNum+1000)
+	return handler
 }